@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/Headliner38/Subscription_Service/internal/service"
+)
+
+const apiKeyUsage = "usage: apikey create -name NAME -user USER_ID [-role user|admin] [-org ORGANIZATION_ID] [-expires DATE]|list [-user USER_ID]|revoke ID"
+
+// runAPIKey выполняет подкоманду apikey. Через неё выпускается первый ключ администратора,
+// когда API ещё недоступно без аутентификации.
+func runAPIKey(ctx context.Context, svc *service.SubscriptionService, args []string) error {
+	if len(args) == 0 {
+		return errors.New(apiKeyUsage)
+	}
+
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+		name := fs.String("name", "", "key name")
+		user := fs.String("user", "", "owner user ID")
+		role := fs.String("role", "user", "owner role: user or admin")
+		org := fs.String("org", "", "organization ID; empty for a key that works with any organization")
+		expires := fs.String("expires", "", "expiration time, RFC 3339 or YYYY-MM-DD")
+		if err := fs.Parse(args[1:]); err != nil {
+			return errors.New(apiKeyUsage)
+		}
+
+		key, secret, err := svc.CreateAPIKey(ctx, service.APIKeyInput{Name: *name, UserID: *user, Role: *role, ExpiresAt: expires, OrganizationID: *org})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("id:     %s\nuser:   %s\nrole:   %s\n", key.ID, key.UserID, key.Role)
+		if key.OrganizationID != "" {
+			fmt.Printf("org:    %s\n", key.OrganizationID)
+		}
+		fmt.Printf("key:    %s\n", secret)
+		fmt.Println("Store the key now: it cannot be shown again.")
+		return nil
+	case "list":
+		fs := flag.NewFlagSet("apikey list", flag.ContinueOnError)
+		user := fs.String("user", "", "owner user ID")
+		if err := fs.Parse(args[1:]); err != nil {
+			return errors.New(apiKeyUsage)
+		}
+
+		keys, err := svc.ListAPIKeys(ctx, *user)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			status := "active"
+			switch {
+			case key.RevokedAt != nil:
+				status = "revoked at " + key.RevokedAt.Format("2006-01-02 15:04:05")
+			case key.ExpiresAt != nil:
+				status = "expires at " + key.ExpiresAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%s  %-10s %s  %-5s  %-24s %s\n", key.ID, key.Prefix, key.UserID, key.Role, key.Name, status)
+		}
+		return nil
+	case "revoke":
+		if len(args) != 2 {
+			return errors.New(apiKeyUsage)
+		}
+		_, err := svc.RevokeAPIKey(ctx, args[1])
+		return err
+	default:
+		return errors.New(apiKeyUsage)
+	}
+}
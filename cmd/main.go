@@ -16,16 +16,24 @@
 // @securityDefinitions.apikey ApiKeyAuth
 // @in header
 // @name Authorization
+// @description API-ключ или JWT в формате "Bearer <token>"; API-ключ можно передать и в заголовке X-API-Key
 package main
 
 import (
+	"context"
 	"database/sql"
+	"flag"
 	"log"
 
 	_ "github.com/Headliner38/Subscription_Service/docs" // Swagger docs
+	"github.com/Headliner38/Subscription_Service/internal/auth"
 	"github.com/Headliner38/Subscription_Service/internal/config"
 	"github.com/Headliner38/Subscription_Service/internal/handler"
+	"github.com/Headliner38/Subscription_Service/internal/migrate"
+	"github.com/Headliner38/Subscription_Service/internal/ratelimit"
+	"github.com/Headliner38/Subscription_Service/internal/repository"
 	"github.com/Headliner38/Subscription_Service/internal/service"
+	"github.com/Headliner38/Subscription_Service/migrations"
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
 	swaggerFiles "github.com/swaggo/files"
@@ -39,6 +47,10 @@ func main() {
 	cfg := config.LoadConfig()
 	log.Printf("[MAIN] Configuration loaded successfully")
 
+	// Флаги командной строки: -migrate переопределяет AUTO_MIGRATE
+	autoMigrate := flag.Bool("migrate", cfg.AutoMigrate, "apply pending database migrations on startup")
+	flag.Parse()
+
 	// Подключение к БД
 	connStr := "host=" + cfg.DBHost + " port=" + cfg.DBPort + " user=" + cfg.DBUser + " password=" + cfg.DBPassword + " dbname=" + cfg.DBName + " sslmode=disable"
 	log.Printf("[MAIN] Connecting to database at %s:%s", cfg.DBHost, cfg.DBPort)
@@ -55,19 +67,83 @@ func main() {
 	}
 	log.Printf("[MAIN] Database connection established successfully")
 
+	migrator, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		log.Fatalf("[FATAL] Failed to load migrations: %v", err)
+	}
+
+	// Подкоманда migrate: выполняем её и завершаемся
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrate(context.Background(), migrator, flag.Args()[1:]); err != nil {
+			log.Fatalf("[FATAL] Migration failed: %v", err)
+		}
+		return
+	}
+
+	if *autoMigrate {
+		if err := migrator.Up(context.Background()); err != nil {
+			log.Fatalf("[FATAL] Failed to apply migrations: %v", err)
+		}
+		log.Printf("[MAIN] Database schema is up to date (version %d)", migrator.Latest())
+	}
+
 	// Инициализируем сервисы и обработчики
-	subscriptionService := &service.SubscriptionService{DB: db}
-	log.Printf("[MAIN] Services initialized")
+	repo := repository.NewPostgresRepository(db)
+	repo.RowSecurity = cfg.TenantRowSecurity
+	subscriptionService := &service.SubscriptionService{Repo: repo}
+	log.Printf("[MAIN] Services initialized (tenant row security: %t)", cfg.TenantRowSecurity)
+
+	// Подкоманда apikey: управление API-ключами без HTTP, в том числе выпуск первого ключа администратора
+	if flag.Arg(0) == "apikey" {
+		if err := runAPIKey(context.Background(), subscriptionService, flag.Args()[1:]); err != nil {
+			log.Fatalf("[FATAL] API key command failed: %v", err)
+		}
+		return
+	}
+
+	// Аутентификация: API-ключи из базы и, если задан JWKS_FILE, JWT
+	var authenticators []auth.Authenticator
+	if cfg.AuthEnabled {
+		authenticators = append(authenticators, auth.NewAPIKeyAuthenticator(subscriptionService.AuthenticateAPIKey))
+		if cfg.JWKSFile != "" {
+			jwtAuth, err := auth.NewJWTAuthenticator(auth.JWTConfig{JWKSFile: cfg.JWKSFile, Issuer: cfg.JWTIssuer, Audience: cfg.JWTAudience})
+			if err != nil {
+				log.Fatalf("[FATAL] Failed to load JWKS from %s: %v", cfg.JWKSFile, err)
+			}
+			authenticators = append(authenticators, jwtAuth)
+			log.Printf("[MAIN] JWT authentication enabled with keys from %s", cfg.JWKSFile)
+		}
+	} else {
+		log.Printf("[MAIN] WARNING: authentication is disabled, API is open to everyone")
+	}
+
+	// Фоновая очистка мягко удалённых подписок
+	if cfg.DeletedRetention > 0 && cfg.PurgeInterval > 0 {
+		go subscriptionService.RunPurgeJob(context.Background(), cfg.PurgeInterval, cfg.DeletedRetention)
+	}
 
 	// роутер
 	r := gin.New() // gin.New() для кастомного логирования
+	// IP клиента для ограничения частоты запросов: X-Forwarded-For учитывается только от доверенных прокси
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("[FATAL] Invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	// middleware для логирования
+	r.Use(handler.RequestIDMiddleware())
 	r.Use(handler.LoggerMiddleware())
 	r.Use(gin.Recovery()) // recovery middleware
-
-	handler.SetupRoutes(r, subscriptionService)
-	log.Printf("[MAIN] Routes configured")
+	r.Use(handler.ErrorMiddleware())
+
+	handler.SetupRoutes(r, subscriptionService, handler.Options{
+		RequireIfMatch: cfg.RequireIfMatch,
+		Authenticators: authenticators,
+		RateLimitStore: ratelimit.NewMemoryStore(),
+		RateLimit:      cfg.RateLimit,
+		RateLimits:     cfg.RateLimitGroups,
+		IPRateLimit:    cfg.IPRateLimit,
+	})
+	log.Printf("[MAIN] Routes configured (rate limit %s per client, overrides %v, %s per IP before authentication)", cfg.RateLimit, cfg.RateLimitGroups, cfg.IPRateLimit)
 
 	// Swagger UI
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -24,9 +24,14 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/subscriptions": {
+        "/api-keys": {
             "get": {
-                "description": "Получает список всех подписок",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает API-ключи без секретов, включая отозванные и просроченные; с организацией запроса — только ключи этой организации. Доступно только администраторам.",
                 "consumes": [
                     "application/json"
                 ],
@@ -34,21 +39,56 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "api-keys"
+                ],
+                "summary": "Список API-ключей",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID владельца ключей",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID организации запроса; по умолчанию организация ключа или токена",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    }
                 ],
-                "summary": "Список подписок",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.Subscription"
-                            }
+                            "$ref": "#/definitions/handler.APIKeysResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "503": {
+                        "description": "Service Unavailable",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
@@ -56,7 +96,12 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Создаёт новую подписку для пользователя",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Выпускает API-ключ для пользователя. Ключ, привязанный к организации, работает только с её данными. Секрет возвращается в поле key только один раз: хранится лишь его хеш. Ключ передаётся в заголовке X-API-Key или Authorization: Bearer. Доступно только администраторам.",
                 "consumes": [
                     "application/json"
                 ],
@@ -64,25 +109,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "api-keys"
                 ],
-                "summary": "Создать подписку",
+                "summary": "Выпустить API-ключ",
                 "parameters": [
                     {
-                        "description": "Данные подписки",
-                        "name": "subscription",
+                        "description": "Данные ключа",
+                        "name": "key",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handler.CreateSubscriptionRequest"
+                            "$ref": "#/definitions/handler.CreateAPIKeyRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID организации запроса; по умолчанию организация ключа или токена",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/model.Subscription"
+                            "$ref": "#/definitions/handler.CreateAPIKeyResponse"
                         }
                     },
                     "400": {
@@ -90,13 +141,112 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/subscriptions/total": {
+        "/api-keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Отзывает API-ключ: запросы с ним сразу получают 401. Отозванный ключ остаётся в списке. Доступно только администраторам.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "Отозвать API-ключ",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID ключа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID организации запроса; по умолчанию организация ключа или токена",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/audit": {
             "get": {
-                "description": "Подсчитывает общую стоимость подписок с фильтрацией",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает записи журнала аудита изменений подписок от новых к старым. С организацией запроса — только записи этой организации. Доступно только администраторам.",
                 "consumes": [
                     "application/json"
                 ],
@@ -104,40 +254,88 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "audit"
                 ],
-                "summary": "Подсчитать общую стоимость",
+                "summary": "Журнал аудита",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID пользователя",
+                        "description": "ID подписки",
+                        "name": "subscription_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID владельца подписки",
                         "name": "user_id",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Название сервиса",
-                        "name": "service_name",
+                        "description": "ID того, кто выполнил изменение",
+                        "name": "actor",
                         "in": "query"
                     },
                     {
+                        "enum": [
+                            "create",
+                            "update",
+                            "delete",
+                            "restore",
+                            "pause",
+                            "resume",
+                            "cancel",
+                            "set_price",
+                            "delete_price"
+                        ],
                         "type": "string",
-                        "description": "Начальная дата (MM-YYYY)",
-                        "name": "start_date",
+                        "description": "Действие",
+                        "name": "action",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Конечная дата (MM-YYYY)",
-                        "name": "end_date",
+                        "description": "ID запроса",
+                        "name": "request_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Не раньше (RFC 3339 или YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Не позже (RFC 3339 или YYYY-MM-DD — день включительно)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Размер страницы (1-500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы (next_cursor из предыдущего ответа)",
+                        "name": "cursor",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID организации запроса; по умолчанию организация ключа или токена",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handler.TotalCostResponse"
+                            "$ref": "#/definitions/handler.AuditResponse"
                         }
                     },
                     "400": {
@@ -145,13 +343,42 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/subscriptions/{id}": {
+        "/exchange-rates": {
             "get": {
-                "description": "Получает подписку по ID",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает курсы валют, упорядоченные по паре и дате. Курс действует с указанной даты до следующего курса той же пары.",
                 "consumes": [
                     "application/json"
                 ],
@@ -159,35 +386,77 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "exchange-rates"
                 ],
-                "summary": "Получить подписку",
+                "summary": "Список курсов валют",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID подписки",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Базовая валюта (ISO 4217)",
+                        "name": "base_currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Котируемая валюта (ISO 4217)",
+                        "name": "quote_currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсы с даты (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсы по дату (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.Subscription"
+                            "$ref": "#/definitions/handler.ExchangeRatesResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/exchange-rates/{base}/{quote}/{date}": {
             "put": {
-                "description": "Обновляет существующую подписку",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Сохраняет курс: одна единица base стоит rate единиц quote начиная с даты date. Курс той же пары на ту же дату заменяется. Доступно только администраторам.",
                 "consumes": [
                     "application/json"
                 ],
@@ -195,24 +464,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "exchange-rates"
                 ],
-                "summary": "Обновить подписку",
+                "summary": "Установить курс валюты",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID подписки",
-                        "name": "id",
+                        "description": "Базовая валюта (ISO 4217)",
+                        "name": "base",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Новые данные подписки",
-                        "name": "subscription",
+                        "type": "string",
+                        "description": "Котируемая валюта (ISO 4217)",
+                        "name": "quote",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата начала действия курса (YYYY-MM-DD)",
+                        "name": "date",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Курс",
+                        "name": "rate",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handler.UpdateSubscriptionRequest"
+                            "$ref": "#/definitions/handler.ExchangeRateRequest"
                         }
                     }
                 ],
@@ -220,7 +503,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.Subscription"
+                            "$ref": "#/definitions/model.ExchangeRate"
                         }
                     },
                     "400": {
@@ -229,8 +512,26 @@ const docTemplate = `{
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
@@ -238,7 +539,12 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "Удаляет подписку по ID",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет курс пары валют на дату. Доступно только администраторам.",
                 "consumes": [
                     "application/json"
                 ],
@@ -246,126 +552,3655 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "exchange-rates"
                 ],
-                "summary": "Удалить подписку",
+                "summary": "Удалить курс валюты",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID подписки",
-                        "name": "id",
+                        "description": "Базовая валюта (ISO 4217)",
+                        "name": "base",
                         "in": "path",
                         "required": true
-                    }
-                ],
+                    },
+                    {
+                        "type": "string",
+                        "description": "Котируемая валюта (ISO 4217)",
+                        "name": "quote",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата начала действия курса (YYYY-MM-DD)",
+                        "name": "date",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
                 "responses": {
                     "204": {
                         "description": "No Content"
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handler.CreateSubscriptionRequest": {
+        },
+        "/services": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает сервисы каталога, упорядоченные по названию",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "services"
+                ],
+                "summary": "Список сервисов каталога",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Категория сервиса",
+                        "name": "category",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ServicesResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Добавляет сервис в каталог. Название и синонимы не зависят от регистра и должны быть уникальны среди всех сервисов каталога. Доступно только администраторам.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "services"
+                ],
+                "summary": "Добавить сервис в каталог",
+                "parameters": [
+                    {
+                        "description": "Данные сервиса",
+                        "name": "service",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.ServiceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.Service"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/services/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает сервис каталога по ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "services"
+                ],
+                "summary": "Получить сервис каталога",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID сервиса",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Service"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Полностью заменяет данные сервиса. Уже созданные подписки сохраняют свои service_name и цену. Доступно только администраторам.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "services"
+                ],
+                "summary": "Обновить сервис каталога",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID сервиса",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новые данные сервиса",
+                        "name": "service",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.ServiceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Service"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет сервис из каталога. Подписки сервиса остаются и отвязываются от каталога: service_id становится пустым, service_name сохраняется. Доступно только администраторам.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "services"
+                ],
+                "summary": "Удалить сервис из каталога",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID сервиса",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Получает список подписок с фильтрацией, сортировкой и постраничной выдачей по курсору.\nВ форматах csv, ndjson и xlsx (параметр format или заголовок Accept) выгружаются все подходящие подписки без постраничной разбивки.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Список подписок",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название сервиса",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Валюта подписки (ISO 4217)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Категория подписки",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Теги через запятую",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "any",
+                            "all"
+                        ],
+                        "type": "string",
+                        "default": "any",
+                        "description": "Подписка должна иметь хотя бы один из тегов (any) или все теги (all)",
+                        "name": "tags_match",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Минимальная цена в минимальных единицах валюты",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Максимальная цена в минимальных единицах валюты",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подписка активна в указанном месяце (MM-YYYY) или в указанный день (YYYY-MM-DD)",
+                        "name": "active_at",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Только подписки, изменённые после момента (RFC 3339)",
+                        "name": "updated_since",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "active",
+                            "paused",
+                            "cancelled",
+                            "expired"
+                        ],
+                        "type": "string",
+                        "description": "Статус жизненного цикла",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "start_date",
+                            "price",
+                            "service_name",
+                            "created_at",
+                            "updated_at"
+                        ],
+                        "type": "string",
+                        "default": "start_date",
+                        "description": "Поле сортировки",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "asc",
+                        "description": "Направление сортировки",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Размер страницы (1-500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы из next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Включить удалённые подписки (для администраторов)",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "Формат ответа; по умолчанию определяется заголовком Accept",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID организации запроса; по умолчанию организация ключа или токена",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ListSubscriptionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Создаёт новую подписку для пользователя",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Создать подписку",
+                "parameters": [
+                    {
+                        "description": "Данные подписки",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateSubscriptionRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID организации запроса; по умолчанию организация ключа или токена",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/batch": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Выполняет по порядку операции create, update и delete. В атомарном режиме (atomic=true) все операции выполняются в одной транзакции: при первой ошибке изменения откатываются, а ответ содержит ошибку с индексом операции. Иначе операции выполняются независимо, и для каждой возвращается свой статус. Если требуется If-Match, для update и delete обязательно поле version.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Пакетные операции с подписками",
+                "parameters": [
+                    {
+                        "description": "Операции",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.BatchRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID организации запроса; по умолчанию организация ключа или токена",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Атомарный пакет: версия не совпала",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/changes": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает упорядоченные события создания, изменения и удаления подписок после токена since. Для продолжения чтения передайте next_token в since.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Лента изменений подписок",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен продолжения (next_token из предыдущего ответа); пусто — с начала ленты",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Максимальное число событий (1-1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID организации запроса; по умолчанию организация ключа или токена",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ChangesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/import": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Импортирует подписки из файла с заголовком service_name, price, currency, user_id, start_date, end_date (порядок колонок любой, лишние колонки игнорируются). Файл передаётся телом запроса (text/csv или XLSX) либо полем file в multipart/form-data. Строки проверяются по тем же правилам, что и при создании; дубликаты (тот же пользователь, сервис и дата начала; 01-2024 равно 2024-01-01, а 2024-01-15 — другая дата) пропускаются. С dry_run=true ничего не сохраняется, а ответ содержит ошибки по строкам. Строки сохраняются пачками: если чтение файла или запись прервались после сохранения части строк, ответ с кодом ошибки (413, 503, ...) содержит итог импорта с aborted=true и committed_through_row — номером последней сохранённой строки; повторять импорт нужно со следующей строки.",
+                "consumes": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Импорт подписок из CSV или XLSX",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "Формат файла, если его нельзя определить по Content-Type или имени файла",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только проверить файл, ничего не сохраняя",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID организации запроса; по умолчанию организация ключа или токена",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ImportResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/reports/by-tag": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Суммирует стоимость подписок за период по тегам. Стоимость считается так же, как в /subscriptions/total, с учётом пересечения с периодом, пауз, изменений цен и пересчёта валют.\nПодписка с несколькими тегами входит в строку каждого из них, поэтому сумма строк может превышать total. Подписки без тегов собираются в строку с пустым tag.\nЕсли задан tags, выводятся только строки перечисленных тегов. С group_by=month строки разбиты по месяцам.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Отчёт о расходах по тегам",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Дополнительная группировка: month",
+                        "name": "group_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Теги через запятую",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "any",
+                            "all"
+                        ],
+                        "type": "string",
+                        "default": "any",
+                        "description": "Подписка должна иметь хотя бы один из тегов (any) или все теги (all)",
+                        "name": "tags_match",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название сервиса",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID сервиса из каталога",
+                        "name": "service_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Категория подписки",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (MM-YYYY или YYYY-MM-DD)",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода включительно (MM-YYYY — весь месяц, или YYYY-MM-DD)",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "RUB",
+                        "description": "Валюта отчёта (ISO 4217)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Учитывать удалённые подписки (для администраторов)",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID организации запроса; по умолчанию организация ключа или токена",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.TagReportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/reports/spend": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Раскладывает стоимость подписок по оплачиваемым месяцам периода и суммирует её по группам group_by. Пересечение с периодом, паузы и пересчёт валют учитываются так же, как в /subscriptions/total, поэтому total совпадает с total_cost. Строки отсортированы по месяцу, сервису, пользователю и категории; группы без расходов не выводятся, а поле months содержит все месяцы периода для построения графика.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Отчёт о расходах по месяцам",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Измерения группировки через запятую: month, service_name, user_id, category (по умолчанию month)",
+                        "name": "group_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID пользователя (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название сервиса",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID сервиса из каталога",
+                        "name": "service_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Категория подписки",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Теги через запятую",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "any",
+                            "all"
+                        ],
+                        "type": "string",
+                        "default": "any",
+                        "description": "Подписка должна иметь хотя бы один из тегов (any) или все теги (all)",
+                        "name": "tags_match",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (MM-YYYY или YYYY-MM-DD)",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода включительно (MM-YYYY — весь месяц, или YYYY-MM-DD)",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "RUB",
+                        "description": "Валюта отчёта (ISO 4217)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Учитывать удалённые подписки (для администраторов)",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID организации запроса; по умолчанию организация ключа или токена",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.SpendReportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/total": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Подсчитывает стоимость подписок за период. Цена списывается в начале каждого цикла оплаты (billing_period × interval_count)\nи распределяется по дням цикла, поэтому в период попадает только его доля: годовая подписка даёт в каждый месяц около 1/12 цены.\nДоля каждого месяца пересчитывается в валюту currency по курсу, действовавшему в последний день месяца; суммы — в минимальных единицах валюты.\nВ форматах csv, ndjson и xlsx выгружается разбивка по подпискам (строки items); итог равен сумме колонки cost.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Подсчитать общую стоимость",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название сервиса",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID сервиса из каталога",
+                        "name": "service_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Категория подписки",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Теги через запятую",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "any",
+                            "all"
+                        ],
+                        "type": "string",
+                        "default": "any",
+                        "description": "Подписка должна иметь хотя бы один из тегов (any) или все теги (all)",
+                        "name": "tags_match",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начальная дата (MM-YYYY или YYYY-MM-DD)",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конечная дата включительно (MM-YYYY — весь месяц, или YYYY-MM-DD)",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "RUB",
+                        "description": "Валюта итога (ISO 4217)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Учитывать удалённые подписки (для администраторов)",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "Формат ответа; по умолчанию определяется заголовком Accept",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID организации запроса; по умолчанию организация ключа или токена",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.TotalCostResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Получает подписку по ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Получить подписку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Вернуть подписку, даже если она удалена (для администраторов)",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID организации запроса; по умолчанию организация ключа или токена",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия подписки"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Обновляет существующую подписку",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Обновить подписку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag подписки из предыдущего ответа, например \\",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Новые данные подписки",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.UpdateSubscriptionRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID организации запроса; по умолчанию организация ключа или токена",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия подписки"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия не совпала; в теле актуальное состояние",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Мягко удаляет подписку по ID: она скрывается из списков и расчётов, но может быть восстановлена до окончательной очистки",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Удалить подписку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag подписки из предыдущего ответа, например \\",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID организации запроса; по умолчанию организация ключа или токена",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия не совпала; в теле актуальное состояние",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Применяет JSON Merge Patch (RFC 7396): отсутствующие поля не меняются, \"end_date\": null убирает дату окончания, \"category\": null и \"tags\": null — категорию и теги. tags заменяет список тегов целиком. Результат проверяется по тем же правилам, что и при PUT.",
+                "consumes": [
+                    "application/merge-patch+json",
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Частично обновить подписку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag подписки из предыдущего ответа, например \\",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Изменяемые поля",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.PatchSubscriptionRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID организации запроса; по умолчанию организация ключа или токена",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия подписки"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия не совпала; в теле актуальное состояние",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Переводит активную или приостановленную подписку в статус cancelled. Если end_date не задан или позже текущего месяца, он сдвигается на текущий месяц.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Отменить подписку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID организации запроса; по умолчанию организация ключа или токена",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/history": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает записи журнала аудита подписки от новых к старым: кто, когда и в каком запросе её изменил, со значениями изменённых полей до и после. История удалённой подписки тоже доступна.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "История изменений подписки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Размер страницы (1-500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы (next_cursor из предыдущего ответа)",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID организации запроса; по умолчанию организация ключа или токена",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.AuditResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/pause": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Переводит активную подписку в статус paused. Циклы оплаты, в день списания которых подписка на паузе, не учитываются в стоимости.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Приостановить подписку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID организации запроса; по умолчанию организация ключа или токена",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/prices": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает начальную цену подписки и её изменения, упорядоченные по дате. Каждая цена действует со своей даты до следующего изменения.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "История цен подписки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID организации запроса; по умолчанию организация ключа или токена",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.PriceHistoryResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/prices/{date}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Сохраняет цену, действующую с даты date (в прошлом или будущем) до следующего изменения. Стоимость и отчёты считают каждый цикл оплаты по цене на день списания.\nЦена на ту же дату заменяется. Дата должна быть позже start_date и не позже end_date.\nИзменение цены повышает версию подписки и попадает в ленту изменений как updated; заголовок ETag ответа содержит новую версию.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Изменить цену подписки с даты",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата начала действия цены (YYYY-MM-DD или MM-YYYY)",
+                        "name": "date",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новая цена",
+                        "name": "price",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.PriceChangeRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag подписки из предыдущего ответа, например \\",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID организации запроса; по умолчанию организация ключа или токена",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.PriceChange"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия не совпала; в теле актуальное состояние",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет изменение цены подписки на дату; с этой даты снова действует предыдущая цена.\nВерсия подписки повышается, как при изменении цены; заголовок ETag ответа содержит новую версию.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Удалить изменение цены",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата начала действия цены (YYYY-MM-DD или MM-YYYY)",
+                        "name": "date",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag подписки из предыдущего ответа, например \\",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID организации запроса; по умолчанию организация ключа или токена",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия не совпала; в теле актуальное состояние",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Восстанавливает мягко удалённую подписку. Доступно только администраторам.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Восстановить подписку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID организации запроса; по умолчанию организация ключа или токена",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/resume": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает приостановленную подписку в статус active",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Возобновить подписку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID организации запроса; по умолчанию организация ключа или токена",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Subscription"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handler.APIKeysResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.APIKey"
+                    }
+                }
+            }
+        },
+        "handler.AuditResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.AuditEntry"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string",
+                    "example": "YXVkOjQy"
+                }
+            }
+        },
+        "handler.BatchItemResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/handler.ErrorResponse"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "op": {
+                    "type": "string",
+                    "example": "create"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 201
+                },
+                "subscription": {
+                    "$ref": "#/definitions/model.Subscription"
+                }
+            }
+        },
+        "handler.BatchOperationRequest": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "description": "ID подписки для update и delete",
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "op": {
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete"
+                    ],
+                    "example": "create"
+                },
+                "subscription": {
+                    "$ref": "#/definitions/handler.BatchSubscriptionData"
+                },
+                "version": {
+                    "description": "Version — ожидаемая версия (ETag) для update и delete",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "handler.BatchRequest": {
+            "type": "object",
+            "required": [
+                "operations"
+            ],
+            "properties": {
+                "atomic": {
+                    "description": "Atomic — выполнить все операции в одной транзакции: при первой ошибке пакет откатывается целиком",
+                    "type": "boolean",
+                    "example": false
+                },
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.BatchOperationRequest"
+                    }
+                }
+            }
+        },
+        "handler.BatchResponse": {
+            "type": "object",
+            "properties": {
+                "atomic": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.BatchItemResult"
+                    }
+                },
+                "succeeded": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "handler.BatchSubscriptionData": {
+            "type": "object",
+            "properties": {
+                "billing_period": {
+                    "type": "string",
+                    "enum": [
+                        "weekly",
+                        "monthly",
+                        "quarterly",
+                        "yearly"
+                    ],
+                    "example": "monthly"
+                },
+                "category": {
+                    "type": "string",
+                    "example": "streaming"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "end_date": {
+                    "type": "string",
+                    "example": "12-2024"
+                },
+                "interval_count": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "organization_id": {
+                    "description": "OrganizationID учитывается только в create",
+                    "type": "string",
+                    "example": "0b5d8f3e-2c4a-4e6b-9d1f-3a5c7e9b1d2f"
+                },
+                "price": {
+                    "type": "integer",
+                    "example": 99900
+                },
+                "service_id": {
+                    "type": "string",
+                    "example": "7a1f3c2e-5b4d-4e8f-9a6b-1c2d3e4f5a6b"
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "Netflix"
+                },
+                "start_date": {
+                    "type": "string",
+                    "example": "01-2024"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "family",
+                        "work"
+                    ]
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "handler.ChangesResponse": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.SubscriptionChange"
+                    }
+                },
+                "has_more": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "next_token": {
+                    "type": "string",
+                    "example": "Y2hnOjQy"
+                }
+            }
+        },
+        "handler.CreateAPIKeyRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "user_id"
+            ],
+            "properties": {
+                "expires_at": {
+                    "description": "RFC 3339 или YYYY-MM-DD; без него ключ бессрочный",
+                    "type": "string",
+                    "example": "2026-01-01T00:00:00Z"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "billing-export"
+                },
+                "organization_id": {
+                    "description": "OrganizationID привязывает ключ к организации; по умолчанию организация запроса, без неё ключ работает с любой организацией",
+                    "type": "string",
+                    "example": "0b5d8f3e-2c4a-4e6b-9d1f-3a5c7e9b1d2f"
+                },
+                "role": {
+                    "description": "по умолчанию user",
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "admin"
+                    ],
+                    "example": "user"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "handler.CreateAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-01-01T12:00:00Z"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2026-01-01T00:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "3c1e2f4a-8b7d-4c6e-9f0a-1b2c3d4e5f60"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "ssk_Ab3dE9fGh1jKl2mNo3pQr4sTu5vWx6yZ7aBc8dEf9gH"
+                },
+                "last_used_at": {
+                    "type": "string",
+                    "example": "2025-03-01T08:30:00Z"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "billing-export"
+                },
+                "organization_id": {
+                    "description": "пусто — ключ работает с любой организацией",
+                    "type": "string",
+                    "example": "0b5d8f3e-2c4a-4e6b-9d1f-3a5c7e9b1d2f"
+                },
+                "prefix": {
+                    "description": "начало ключа, чтобы его можно было узнать",
+                    "type": "string",
+                    "example": "ssk_Ab3dE9"
+                },
+                "revoked_at": {
+                    "type": "string",
+                    "example": "2025-06-01T00:00:00Z"
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "admin"
+                    ],
+                    "example": "user"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "handler.CreateSubscriptionRequest": {
+            "type": "object",
+            "required": [
+                "start_date",
+                "user_id"
+            ],
+            "properties": {
+                "billing_period": {
+                    "description": "BillingPeriod и IntervalCount задают расписание списаний; по умолчанию раз в месяц",
+                    "type": "string",
+                    "enum": [
+                        "weekly",
+                        "monthly",
+                        "quarterly",
+                        "yearly"
+                    ],
+                    "example": "monthly"
+                },
+                "category": {
+                    "description": "Category — категория подписки; по умолчанию категория сервиса из каталога",
+                    "type": "string",
+                    "example": "streaming"
+                },
+                "currency": {
+                    "description": "ISO 4217, по умолчанию RUB",
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "end_date": {
+                    "description": "включительно; месяц MM-YYYY — целиком",
+                    "type": "string",
+                    "example": "12-2024"
+                },
+                "interval_count": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "organization_id": {
+                    "description": "OrganizationID — организация подписки; по умолчанию организация запроса (X-Tenant-ID или ключа)",
+                    "type": "string",
+                    "example": "0b5d8f3e-2c4a-4e6b-9d1f-3a5c7e9b1d2f"
+                },
+                "price": {
+                    "description": "в минимальных единицах валюты; по умолчанию цена из каталога",
+                    "type": "integer",
+                    "example": 99900
+                },
+                "service_id": {
+                    "description": "ServiceID — сервис из каталога; без него service_name ищется в каталоге по названию и синонимам",
+                    "type": "string",
+                    "example": "7a1f3c2e-5b4d-4e8f-9a6b-1c2d3e4f5a6b"
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "Netflix"
+                },
+                "start_date": {
+                    "description": "MM-YYYY или YYYY-MM-DD",
+                    "type": "string",
+                    "example": "01-2024"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "family",
+                        "work"
+                    ]
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "handler.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "validation_error"
+                },
+                "details": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.FieldError"
+                    }
+                },
+                "error": {
+                    "type": "string",
+                    "example": "Invalid request"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                }
+            }
+        },
+        "handler.ExchangeRateRequest": {
+            "type": "object",
+            "required": [
+                "rate"
+            ],
+            "properties": {
+                "rate": {
+                    "type": "string",
+                    "example": "92.45"
+                }
+            }
+        },
+        "handler.ExchangeRatesResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ExchangeRate"
+                    }
+                }
+            }
+        },
+        "handler.ListSubscriptionsResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Subscription"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string",
+                    "example": "eyJzIjoic3RhcnRfZGF0ZSJ9"
+                }
+            }
+        },
+        "handler.PatchSubscriptionRequest": {
+            "type": "object",
+            "properties": {
+                "billing_period": {
+                    "type": "string",
+                    "enum": [
+                        "weekly",
+                        "monthly",
+                        "quarterly",
+                        "yearly"
+                    ],
+                    "example": "yearly"
+                },
+                "category": {
+                    "type": "string",
+                    "example": "streaming"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "end_date": {
+                    "type": "string",
+                    "example": "12-2024"
+                },
+                "interval_count": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "price": {
+                    "type": "integer",
+                    "example": 99900
+                },
+                "service_id": {
+                    "type": "string",
+                    "example": "7a1f3c2e-5b4d-4e8f-9a6b-1c2d3e4f5a6b"
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "Netflix"
+                },
+                "start_date": {
+                    "type": "string",
+                    "example": "01-2024"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "family",
+                        "work"
+                    ]
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "handler.PriceChangeRequest": {
+            "type": "object",
+            "required": [
+                "price"
+            ],
+            "properties": {
+                "price": {
+                    "description": "в минимальных единицах валюты подписки",
+                    "type": "integer",
+                    "example": 119900
+                }
+            }
+        },
+        "handler.PriceHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "initial_price": {
+                    "description": "действует со start_date до первого изменения",
+                    "type": "integer",
+                    "example": 99900
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.PriceChange"
+                    }
+                },
+                "subscription_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                }
+            }
+        },
+        "handler.ServiceRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "aliases": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "NFLX",
+                        "Нетфликс"
+                    ]
+                },
+                "category": {
+                    "type": "string",
+                    "example": "streaming"
+                },
+                "currency": {
+                    "description": "по умолчанию RUB",
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "default_price": {
+                    "description": "в минимальных единицах currency",
+                    "type": "integer",
+                    "example": 99900
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Netflix"
+                },
+                "vendor_url": {
+                    "type": "string",
+                    "example": "https://www.netflix.com"
+                }
+            }
+        },
+        "handler.ServicesResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Service"
+                    }
+                }
+            }
+        },
+        "handler.SpendReportResponse": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string",
+                    "example": "streaming"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "end_date": {
+                    "type": "string",
+                    "example": "12-2025"
+                },
+                "group_by": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "month",
+                        "service_name"
+                    ]
+                },
+                "months": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "01-2025",
+                        "02-2025"
+                    ]
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.SpendRow"
+                    }
+                },
+                "service_id": {
+                    "type": "string",
+                    "example": "7a1f3c2e-5b4d-4e8f-9a6b-1c2d3e4f5a6b"
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "Netflix"
+                },
+                "start_date": {
+                    "type": "string",
+                    "example": "01-2025"
+                },
+                "tags": {
+                    "type": "string",
+                    "example": "streaming,work"
+                },
+                "tags_match": {
+                    "type": "string",
+                    "example": "any"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 1198800
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "handler.TagReportResponse": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string",
+                    "example": "streaming"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "end_date": {
+                    "type": "string",
+                    "example": "12-2025"
+                },
+                "group_by": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "month"
+                    ]
+                },
+                "months": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "01-2025",
+                        "02-2025"
+                    ]
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.TagSpendRow"
+                    }
+                },
+                "service_id": {
+                    "type": "string",
+                    "example": "7a1f3c2e-5b4d-4e8f-9a6b-1c2d3e4f5a6b"
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "Netflix"
+                },
+                "start_date": {
+                    "type": "string",
+                    "example": "01-2025"
+                },
+                "tags": {
+                    "type": "string",
+                    "example": "streaming,work"
+                },
+                "tags_match": {
+                    "type": "string",
+                    "example": "any"
+                },
+                "total": {
+                    "description": "расходы на подписки отчёта, каждая учтена один раз",
+                    "type": "integer",
+                    "example": 1198800
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "handler.TotalCostResponse": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string",
+                    "example": "streaming"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "end_date": {
+                    "type": "string",
+                    "example": "12-2024"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.SubscriptionCost"
+                    }
+                },
+                "service_id": {
+                    "type": "string",
+                    "example": "7a1f3c2e-5b4d-4e8f-9a6b-1c2d3e4f5a6b"
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "Netflix"
+                },
+                "start_date": {
+                    "type": "string",
+                    "example": "01-2024"
+                },
+                "tags": {
+                    "type": "string",
+                    "example": "streaming,work"
+                },
+                "tags_match": {
+                    "type": "string",
+                    "example": "any"
+                },
+                "total_cost": {
+                    "description": "в минимальных единицах currency",
+                    "type": "integer",
+                    "example": 299700
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "handler.UpdateSubscriptionRequest": {
             "type": "object",
             "required": [
-                "price",
-                "service_name",
                 "start_date",
                 "user_id"
             ],
             "properties": {
+                "billing_period": {
+                    "description": "BillingPeriod и IntervalCount задают расписание списаний; по умолчанию раз в месяц",
+                    "type": "string",
+                    "enum": [
+                        "weekly",
+                        "monthly",
+                        "quarterly",
+                        "yearly"
+                    ],
+                    "example": "monthly"
+                },
+                "category": {
+                    "description": "Category — категория подписки; по умолчанию категория сервиса из каталога",
+                    "type": "string",
+                    "example": "streaming"
+                },
+                "currency": {
+                    "description": "ISO 4217, по умолчанию RUB",
+                    "type": "string",
+                    "example": "RUB"
+                },
                 "end_date": {
+                    "description": "включительно; месяц MM-YYYY — целиком",
                     "type": "string",
                     "example": "12-2024"
                 },
+                "interval_count": {
+                    "type": "integer",
+                    "example": 1
+                },
                 "price": {
+                    "description": "в минимальных единицах валюты; по умолчанию цена из каталога",
                     "type": "integer",
-                    "example": 999
+                    "example": 99900
+                },
+                "service_id": {
+                    "description": "ServiceID — сервис из каталога; без него service_name ищется в каталоге по названию и синонимам",
+                    "type": "string",
+                    "example": "7a1f3c2e-5b4d-4e8f-9a6b-1c2d3e4f5a6b"
                 },
                 "service_name": {
                     "type": "string",
                     "example": "Netflix"
                 },
                 "start_date": {
+                    "description": "MM-YYYY или YYYY-MM-DD",
                     "type": "string",
                     "example": "01-2024"
                 },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "family",
+                        "work"
+                    ]
+                },
                 "user_id": {
                     "type": "string",
-                    "example": "user123"
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
                 }
             }
         },
-        "handler.ErrorResponse": {
+        "model.APIKey": {
+            "description": "API-ключ (без секрета)",
             "type": "object",
             "properties": {
-                "error": {
+                "created_at": {
                     "type": "string",
-                    "example": "Invalid request"
+                    "example": "2025-01-01T12:00:00Z"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2026-01-01T00:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "3c1e2f4a-8b7d-4c6e-9f0a-1b2c3d4e5f60"
+                },
+                "last_used_at": {
+                    "type": "string",
+                    "example": "2025-03-01T08:30:00Z"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "billing-export"
+                },
+                "organization_id": {
+                    "description": "пусто — ключ работает с любой организацией",
+                    "type": "string",
+                    "example": "0b5d8f3e-2c4a-4e6b-9d1f-3a5c7e9b1d2f"
+                },
+                "prefix": {
+                    "description": "начало ключа, чтобы его можно было узнать",
+                    "type": "string",
+                    "example": "ssk_Ab3dE9"
+                },
+                "revoked_at": {
+                    "type": "string",
+                    "example": "2025-06-01T00:00:00Z"
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "admin"
+                    ],
+                    "example": "user"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
                 }
             }
         },
-        "handler.TotalCostResponse": {
+        "model.AuditEntry": {
+            "description": "Запись журнала аудита: кто, когда и как изменил подписку",
             "type": "object",
             "properties": {
-                "end_date": {
+                "action": {
                     "type": "string",
-                    "example": "12-2024"
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete",
+                        "restore",
+                        "pause",
+                        "resume",
+                        "cancel",
+                        "set_price",
+                        "delete_price"
+                    ],
+                    "example": "update"
                 },
-                "service_name": {
+                "actor": {
+                    "description": "пусто, если аутентификация отключена",
                     "type": "string",
-                    "example": "Netflix"
+                    "example": "00000000-0000-0000-0000-0000000000aa"
                 },
-                "start_date": {
+                "actor_role": {
                     "type": "string",
-                    "example": "01-2024"
+                    "example": "admin"
                 },
-                "total_cost": {
+                "auth_method": {
+                    "type": "string",
+                    "example": "api_key"
+                },
+                "changes": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/model.FieldChange"
+                    }
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-01-01T12:00:00Z"
+                },
+                "id": {
                     "type": "integer",
-                    "example": 2997
+                    "example": 42
+                },
+                "organization_id": {
+                    "type": "string",
+                    "example": "0b5d8f3e-2c4a-4e6b-9d1f-3a5c7e9b1d2f"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "900d311d-45cc-4eb2-a4ae-367975170cb5"
+                },
+                "subscription_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
                 },
                 "user_id": {
+                    "description": "владелец подписки",
                     "type": "string",
-                    "example": "user123"
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
                 }
             }
         },
-        "handler.UpdateSubscriptionRequest": {
+        "model.ExchangeRate": {
+            "description": "Курс обмена валют на дату",
             "type": "object",
-            "required": [
-                "price",
-                "service_name",
-                "start_date",
-                "user_id"
-            ],
             "properties": {
-                "end_date": {
+                "base_currency": {
                     "type": "string",
-                    "example": "12-2024"
+                    "example": "USD"
+                },
+                "date": {
+                    "type": "string",
+                    "example": "2025-01-01T00:00:00Z"
+                },
+                "quote_currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "rate": {
+                    "type": "string",
+                    "example": "92.45"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2025-01-01T12:00:00Z"
+                }
+            }
+        },
+        "model.FieldChange": {
+            "type": "object",
+            "properties": {
+                "new": {},
+                "old": {}
+            }
+        },
+        "model.ImportResult": {
+            "description": "Итог импорта подписок",
+            "type": "object",
+            "properties": {
+                "abort_reason": {
+                    "type": "string",
+                    "example": "service temporarily unavailable"
+                },
+                "aborted": {
+                    "description": "Aborted — импорт прерван сбоем чтения файла или хранилища после того, как часть строк\nуже сохранена; AbortReason описывает сбой",
+                    "type": "boolean",
+                    "example": false
+                },
+                "committed_through_row": {
+                    "description": "CommittedThroughRow — при прерванном импорте строки до этой включительно обработаны\nи сохранены, а следующие не импортированы",
+                    "type": "integer",
+                    "example": 1001
+                },
+                "dry_run": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "duplicates": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "errors": {
+                    "description": "Errors — ошибки по строкам; номер строки считается как в таблице, заголовок — строка 1",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ImportRowError"
+                    }
+                },
+                "errors_truncated": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "imported": {
+                    "type": "integer",
+                    "example": 117
+                },
+                "invalid": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "rows": {
+                    "description": "Rows — число непустых строк данных (без заголовка)",
+                    "type": "integer",
+                    "example": 120
+                },
+                "valid": {
+                    "description": "Valid — строки, прошедшие проверку; при dry_run=false, если импорт не прерван, все они импортированы",
+                    "type": "integer",
+                    "example": 117
+                }
+            }
+        },
+        "model.ImportRowError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "start_date"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "invalid start_date format, expected MM-YYYY"
+                },
+                "row": {
+                    "type": "integer",
+                    "example": 7
+                }
+            }
+        },
+        "model.PriceChange": {
+            "description": "Изменение цены подписки с даты",
+            "type": "object",
+            "properties": {
+                "effective_from": {
+                    "type": "string",
+                    "example": "2025-03-01T00:00:00Z"
                 },
                 "price": {
+                    "description": "в минимальных единицах валюты подписки",
                     "type": "integer",
-                    "example": 999
+                    "example": 119900
                 },
-                "service_name": {
+                "subscription_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2025-02-20T12:00:00Z"
+                }
+            }
+        },
+        "model.Service": {
+            "description": "Сервис из каталога",
+            "type": "object",
+            "properties": {
+                "aliases": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "netflix.com",
+                        "Netflix Premium"
+                    ]
+                },
+                "category": {
+                    "type": "string",
+                    "example": "streaming"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-01-01T00:00:00Z"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "default_price": {
+                    "description": "в минимальных единицах Currency",
+                    "type": "integer",
+                    "example": 99900
+                },
+                "id": {
+                    "type": "string",
+                    "example": "7a1f3c2e-5b4d-4e8f-9a6b-1c2d3e4f5a6b"
+                },
+                "name": {
                     "type": "string",
                     "example": "Netflix"
                 },
-                "start_date": {
+                "updated_at": {
                     "type": "string",
-                    "example": "01-2024"
+                    "example": "2024-01-01T00:00:00Z"
+                },
+                "vendor_url": {
+                    "type": "string",
+                    "example": "https://www.netflix.com"
+                }
+            }
+        },
+        "model.SpendRow": {
+            "description": "Расходы группы подписок",
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string",
+                    "example": "streaming"
+                },
+                "month": {
+                    "type": "string",
+                    "example": "01-2025"
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "Netflix"
+                },
+                "spend": {
+                    "description": "в валюте отчёта",
+                    "type": "integer",
+                    "example": 99900
+                },
+                "subscriptions": {
+                    "description": "подписки, давшие вклад в группу",
+                    "type": "integer",
+                    "example": 1
                 },
                 "user_id": {
                     "type": "string",
-                    "example": "user123"
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
                 }
             }
         },
@@ -373,11 +4208,34 @@ const docTemplate = `{
             "description": "Модель подписки пользователя",
             "type": "object",
             "properties": {
+                "billing_period": {
+                    "type": "string",
+                    "enum": [
+                        "weekly",
+                        "monthly",
+                        "quarterly",
+                        "yearly"
+                    ],
+                    "example": "monthly"
+                },
+                "category": {
+                    "type": "string",
+                    "example": "streaming"
+                },
                 "created_at": {
                     "type": "string",
                     "example": "2024-01-01T00:00:00Z"
                 },
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "deleted_at": {
+                    "type": "string",
+                    "example": "2024-06-01T00:00:00Z"
+                },
                 "end_date": {
+                    "description": "последний день действия, включительно",
                     "type": "string",
                     "example": "2024-12-31T00:00:00Z"
                 },
@@ -385,9 +4243,25 @@ const docTemplate = `{
                     "type": "string",
                     "example": "550e8400-e29b-41d4-a716-446655440000"
                 },
+                "interval_count": {
+                    "description": "Price списывается раз в IntervalCount периодов BillingPeriod",
+                    "type": "integer",
+                    "example": 1
+                },
+                "organization_id": {
+                    "description": "организация (клиент); задаётся при создании и не меняется",
+                    "type": "string",
+                    "example": "0b5d8f3e-2c4a-4e6b-9d1f-3a5c7e9b1d2f"
+                },
                 "price": {
+                    "description": "в минимальных единицах валюты (копейках, центах)",
                     "type": "integer",
-                    "example": 999
+                    "example": 99900
+                },
+                "service_id": {
+                    "description": "сервис из каталога",
+                    "type": "string",
+                    "example": "7a1f3c2e-5b4d-4e8f-9a6b-1c2d3e4f5a6b"
                 },
                 "service_name": {
                     "type": "string",
@@ -397,19 +4271,171 @@ const docTemplate = `{
                     "type": "string",
                     "example": "2024-01-01T00:00:00Z"
                 },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "paused",
+                        "cancelled",
+                        "expired"
+                    ],
+                    "example": "active"
+                },
+                "tags": {
+                    "description": "в нижнем регистре, упорядочены",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "family",
+                        "work"
+                    ]
+                },
                 "updated_at": {
                     "type": "string",
                     "example": "2024-01-01T00:00:00Z"
                 },
                 "user_id": {
                     "type": "string",
-                    "example": "user123"
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "model.SubscriptionChange": {
+            "description": "Событие изменения подписки. Для удаления subscription не заполняется (tombstone).",
+            "type": "object",
+            "properties": {
+                "changed_at": {
+                    "type": "string",
+                    "example": "2024-01-01T00:00:00Z"
+                },
+                "subscription": {
+                    "$ref": "#/definitions/model.Subscription"
+                },
+                "subscription_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "created",
+                        "updated",
+                        "deleted",
+                        "restored"
+                    ],
+                    "example": "updated"
+                }
+            }
+        },
+        "model.SubscriptionCost": {
+            "description": "Стоимость подписки за запрошенный период",
+            "type": "object",
+            "properties": {
+                "billing_period": {
+                    "type": "string",
+                    "example": "monthly"
+                },
+                "cost": {
+                    "description": "в валюте отчёта, помесячно по курсу месяца",
+                    "type": "integer",
+                    "example": 1198800
+                },
+                "cost_currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "from": {
+                    "type": "string",
+                    "example": "01-2024"
+                },
+                "interval_count": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "months": {
+                    "description": "месяцы с долей оплаченных циклов",
+                    "type": "integer",
+                    "example": 12
+                },
+                "paused_months": {
+                    "description": "месяцы, циклы которых пропущены из-за паузы",
+                    "type": "integer",
+                    "example": 0
+                },
+                "price": {
+                    "description": "начальная цена в валюте подписки; изменения цен учтены в cost",
+                    "type": "integer",
+                    "example": 99900
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "Netflix"
+                },
+                "subscription_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "12-2024"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "model.TagSpendRow": {
+            "description": "Расходы подписок с тегом",
+            "type": "object",
+            "properties": {
+                "month": {
+                    "type": "string",
+                    "example": "01-2025"
+                },
+                "spend": {
+                    "description": "в валюте отчёта",
+                    "type": "integer",
+                    "example": 99900
+                },
+                "subscriptions": {
+                    "description": "подписки с тегом, давшие вклад в строку",
+                    "type": "integer",
+                    "example": 1
+                },
+                "tag": {
+                    "type": "string",
+                    "example": "streaming"
+                }
+            }
+        },
+        "service.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "price"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "price must be positive"
                 }
             }
         }
     },
     "securityDefinitions": {
         "ApiKeyAuth": {
+            "description": "API-ключ или JWT в формате \"Bearer \u003ctoken\u003e\"; API-ключ можно передать и в заголовке X-API-Key",
             "type": "apiKey",
             "name": "Authorization",
             "in": "header"
@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+)
+
+const (
+	// APIKeyPrefix отличает API-ключи от JWT в заголовке Authorization
+	APIKeyPrefix = "ssk_"
+	// apiKeyHeader — альтернативный заголовок для API-ключа
+	apiKeyHeader = "X-API-Key"
+	// apiKeyBytes — случайная часть ключа; 256 бит делают перебор бессмысленным,
+	// поэтому для хранения достаточно SHA-256 без соли
+	apiKeyBytes = 32
+	// apiKeyVisible — сколько символов ключа сохраняется открыто, чтобы его можно было узнать в списке
+	apiKeyVisible = len(APIKeyPrefix) + 6
+)
+
+// GenerateAPIKey создаёт новый ключ и возвращает его вместе с открытым префиксом и хешем для хранения
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	buf := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", err
+	}
+	key = APIKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	return key, key[:apiKeyVisible], HashAPIKey(key), nil
+}
+
+// HashAPIKey возвращает хеш ключа, под которым он хранится
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyAuthenticator проверяет API-ключ из заголовка X-API-Key или Authorization: Bearer ssk_...
+type APIKeyAuthenticator struct {
+	// Verify находит действующий ключ и возвращает его владельца;
+	// для неизвестного, отозванного или просроченного ключа — ErrInvalidCredentials
+	Verify func(ctx context.Context, key string) (*model.Principal, error)
+}
+
+// NewAPIKeyAuthenticator создаёт аутентификатор, проверяющий ключи функцией verify
+func NewAPIKeyAuthenticator(verify func(ctx context.Context, key string) (*model.Principal, error)) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{Verify: verify}
+}
+
+func (a *APIKeyAuthenticator) Authenticate(ctx context.Context, r *http.Request) (*model.Principal, error) {
+	key := strings.TrimSpace(r.Header.Get(apiKeyHeader))
+	if key == "" {
+		key = bearerToken(r)
+		if !strings.HasPrefix(key, APIKeyPrefix) {
+			return nil, ErrNoCredentials
+		}
+	}
+	return a.Verify(ctx, key)
+}
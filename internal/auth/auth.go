@@ -0,0 +1,53 @@
+// Package auth проверяет учётные данные запросов: статические API-ключи и JWT,
+// подписанные ключами из локального файла JWKS
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+)
+
+// Ошибки аутентификации
+var (
+	// ErrNoCredentials — в запросе нет учётных данных, которые понимает аутентификатор
+	ErrNoCredentials = errors.New("authentication required")
+	// ErrInvalidCredentials — учётные данные есть, но они неверны, отозваны или просрочены
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Authenticator проверяет учётные данные запроса. Если в запросе нет учётных данных
+// подходящего вида, возвращает ErrNoCredentials, чтобы их мог проверить следующий аутентификатор.
+type Authenticator interface {
+	Authenticate(ctx context.Context, r *http.Request) (*model.Principal, error)
+}
+
+// invalidCredentials уточняет ErrInvalidCredentials причиной; errors.Is(err, ErrInvalidCredentials) == true
+type invalidCredentials struct {
+	reason string
+}
+
+func (e *invalidCredentials) Error() string {
+	return "invalid credentials: " + e.reason
+}
+
+func (e *invalidCredentials) Is(target error) bool {
+	return target == ErrInvalidCredentials
+}
+
+func invalid(reason string) error {
+	return &invalidCredentials{reason: reason}
+}
+
+// bearerToken возвращает токен из заголовка Authorization: Bearer <token>
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
@@ -0,0 +1,352 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/utils"
+)
+
+// Поддерживаемые алгоритмы подписи JWT
+const (
+	algHS256 = "HS256"
+	algRS256 = "RS256"
+)
+
+const (
+	// defaultLeeway — допустимое расхождение часов при проверке exp и nbf
+	defaultLeeway = time.Minute
+	// jwksReloadInterval — как часто можно перечитывать JWKS, встретив неизвестный kid
+	jwksReloadInterval = 30 * time.Second
+)
+
+// JWTConfig — настройки проверки JWT
+type JWTConfig struct {
+	// JWKSFile — путь к файлу JWKS с ключами oct (HS256) и RSA (RS256)
+	JWKSFile string
+	// Issuer и Audience, если заданы, должны совпадать с iss и одним из aud токена
+	Issuer   string
+	Audience string
+	// Leeway — допустимое расхождение часов; по умолчанию минута
+	Leeway time.Duration
+}
+
+// jwk — ключ из файла JWKS (RFC 7517)
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	K   string `json:"k"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// verificationKey — ключ проверки подписи: секрет HMAC для HS256 или открытый ключ RSA для RS256
+type verificationKey struct {
+	kid    string
+	alg    string
+	secret []byte
+	public *rsa.PublicKey
+}
+
+// verify проверяет подпись signingInput
+func (k verificationKey) verify(signingInput string, signature []byte) bool {
+	switch k.alg {
+	case algHS256:
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write([]byte(signingInput))
+		return hmac.Equal(mac.Sum(nil), signature)
+	case algRS256:
+		sum := sha256.Sum256([]byte(signingInput))
+		return rsa.VerifyPKCS1v15(k.public, crypto.SHA256, sum[:], signature) == nil
+	}
+	return false
+}
+
+// parseJWKS разбирает набор ключей. Ключи не для подписи (use != "sig") и неподдерживаемых типов
+// пропускаются; ключ oct годится только для HS256, RSA — только для RS256.
+func parseJWKS(data []byte) ([]verificationKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	var keys []verificationKey
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch k.Kty {
+		case "oct":
+			if k.Alg != "" && k.Alg != algHS256 {
+				continue
+			}
+			secret, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(k.K, "="))
+			if err != nil || len(secret) == 0 {
+				return nil, fmt.Errorf("invalid JWKS: key %d has invalid k", i)
+			}
+			keys = append(keys, verificationKey{kid: k.Kid, alg: algHS256, secret: secret})
+		case "RSA":
+			if k.Alg != "" && k.Alg != algRS256 {
+				continue
+			}
+			n, errN := base64.RawURLEncoding.DecodeString(strings.TrimRight(k.N, "="))
+			e, errE := base64.RawURLEncoding.DecodeString(strings.TrimRight(k.E, "="))
+			if errN != nil || errE != nil || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+				return nil, fmt.Errorf("invalid JWKS: key %d has invalid n or e", i)
+			}
+			public := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+			keys = append(keys, verificationKey{kid: k.Kid, alg: algRS256, public: public})
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("invalid JWKS: no usable signing keys")
+	}
+	return keys, nil
+}
+
+// JWTAuthenticator проверяет JWT из заголовка Authorization: Bearer по ключам из файла JWKS.
+// Файл перечитывается, когда встречается токен с неизвестным kid, поэтому новый ключ можно
+// добавить без перезапуска.
+type JWTAuthenticator struct {
+	cfg JWTConfig
+	now func() time.Time
+
+	mu       sync.RWMutex
+	keys     []verificationKey
+	loadedAt time.Time
+}
+
+// NewJWTAuthenticator загружает ключи из cfg.JWKSFile
+func NewJWTAuthenticator(cfg JWTConfig) (*JWTAuthenticator, error) {
+	if cfg.Leeway == 0 {
+		cfg.Leeway = defaultLeeway
+	}
+	a := &JWTAuthenticator{cfg: cfg, now: time.Now}
+	if err := a.load(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// load читает файл JWKS и заменяет набор ключей
+func (a *JWTAuthenticator) load() error {
+	data, err := os.ReadFile(a.cfg.JWKSFile)
+	if err != nil {
+		return err
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	a.keys, a.loadedAt = keys, a.now()
+	a.mu.Unlock()
+	return nil
+}
+
+// candidates возвращает ключи с алгоритмом alg и, если kid задан, с этим kid
+func (a *JWTAuthenticator) candidates(kid, alg string) []verificationKey {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var keys []verificationKey
+	for _, k := range a.keys {
+		if k.alg == alg && (kid == "" || k.kid == kid) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// reloadIfStale перечитывает JWKS, если с прошлой загрузки прошло больше jwksReloadInterval
+func (a *JWTAuthenticator) reloadIfStale() {
+	a.mu.RLock()
+	stale := a.now().Sub(a.loadedAt) > jwksReloadInterval
+	a.mu.RUnlock()
+	if !stale {
+		return
+	}
+	if err := a.load(); err != nil {
+		log.Printf("[AUTH] Failed to reload JWKS from %s: %v", a.cfg.JWKSFile, err)
+		// Не пытаемся перечитать испорченный файл на каждый запрос
+		a.mu.Lock()
+		a.loadedAt = a.now()
+		a.mu.Unlock()
+	}
+}
+
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, r *http.Request) (*model.Principal, error) {
+	token := bearerToken(r)
+	if token == "" || strings.Count(token, ".") != 2 {
+		return nil, ErrNoCredentials
+	}
+	return a.Verify(token)
+}
+
+// audience — claim aud: строка или массив строк
+type audience []string
+
+func (aud *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*aud = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*aud = list
+	return nil
+}
+
+// claims — проверяемые поля токена
+type claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *float64 `json:"exp"`
+	NotBefore *float64 `json:"nbf"`
+	// Role или Roles определяют роль: admin, если среди них есть admin
+	Role  string   `json:"role"`
+	Roles []string `json:"roles"`
+	// OrgID привязывает токен к организации; без него токен работает с любой организацией
+	OrgID string `json:"org_id"`
+}
+
+// Verify проверяет подпись и срок действия токена и возвращает его субъекта
+func (a *JWTAuthenticator) Verify(token string) (*model.Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, invalid("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, invalid("malformed token header")
+	}
+	// Алгоритм из заголовка должен совпадать с типом ключа: none и подмена RS256 на HS256 не проходят
+	if header.Alg != algHS256 && header.Alg != algRS256 {
+		return nil, invalid("unsupported signing algorithm")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, invalid("malformed token signature")
+	}
+
+	keys := a.candidates(header.Kid, header.Alg)
+	if len(keys) == 0 && header.Kid != "" {
+		a.reloadIfStale()
+		keys = a.candidates(header.Kid, header.Alg)
+	}
+	if len(keys) == 0 {
+		return nil, invalid("unknown signing key")
+	}
+	var key *verificationKey
+	signingInput := parts[0] + "." + parts[1]
+	for i := range keys {
+		if keys[i].verify(signingInput, signature) {
+			key = &keys[i]
+			break
+		}
+	}
+	if key == nil {
+		return nil, invalid("signature verification failed")
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return nil, invalid("malformed token claims")
+	}
+	if err := a.validateClaims(c); err != nil {
+		return nil, err
+	}
+
+	role := model.RoleUser
+	if c.Role == model.RoleAdmin {
+		role = model.RoleAdmin
+	}
+	for _, r := range c.Roles {
+		if r == model.RoleAdmin {
+			role = model.RoleAdmin
+		}
+	}
+	return &model.Principal{Subject: strings.ToLower(c.Subject), Role: role, Method: model.AuthMethodJWT, KeyID: key.kid, OrganizationID: strings.ToLower(c.OrgID)}, nil
+}
+
+// validateClaims проверяет субъекта, срок действия, издателя и аудиторию токена
+func (a *JWTAuthenticator) validateClaims(c claims) error {
+	now := a.now()
+	if c.Subject == "" {
+		return invalid("token has no subject")
+	}
+	// sub — это user_id подписок, с которым хранилище сравнивает колонку типа UUID
+	if !utils.IsValidUUID(c.Subject) {
+		return invalid("token subject is not a valid UUID")
+	}
+	if c.OrgID != "" && !utils.IsValidUUID(c.OrgID) {
+		return invalid("token org_id is not a valid UUID")
+	}
+	if c.ExpiresAt == nil {
+		return invalid("token has no expiration time")
+	}
+	if now.After(unixTime(*c.ExpiresAt).Add(a.cfg.Leeway)) {
+		return invalid("token has expired")
+	}
+	if c.NotBefore != nil && now.Add(a.cfg.Leeway).Before(unixTime(*c.NotBefore)) {
+		return invalid("token is not valid yet")
+	}
+	if a.cfg.Issuer != "" && c.Issuer != a.cfg.Issuer {
+		return invalid("unexpected token issuer")
+	}
+	if a.cfg.Audience != "" {
+		found := false
+		for _, aud := range c.Audience {
+			if aud == a.cfg.Audience {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return invalid("unexpected token audience")
+		}
+	}
+	return nil
+}
+
+// decodeSegment декодирует часть токена base64url в JSON
+func decodeSegment(segment string, dest interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dest)
+}
+
+// unixTime переводит NumericDate (секунды, возможно дробные) во время
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
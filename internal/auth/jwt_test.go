@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// newTestJWTAuthenticator создаёт аутентификатор с одним ключом HS256 "k1"
+func newTestJWTAuthenticator(t *testing.T) *JWTAuthenticator {
+	t.Helper()
+	jwks := `{"keys":[{"kty":"oct","kid":"k1","k":"` + base64.RawURLEncoding.EncodeToString(testSecret) + `"}]}`
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, []byte(jwks), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	a, err := NewJWTAuthenticator(JWTConfig{JWKSFile: path})
+	if err != nil {
+		t.Fatalf("NewJWTAuthenticator: %v", err)
+	}
+	return a
+}
+
+// signHS256 подписывает claims ключом "k1"
+func signHS256(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "kid": "k1"})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, testSecret)
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestJWTVerifyClaims(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Unix()
+	const userID = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+
+	tests := []struct {
+		name   string
+		claims map[string]interface{}
+		valid  bool
+	}{
+		{"valid user", map[string]interface{}{"sub": userID, "exp": exp}, true},
+		{"subject in upper case", map[string]interface{}{"sub": "60601FEE-2BF1-4721-AE6F-7636E79A0CBA", "exp": exp}, true},
+		{"no subject", map[string]interface{}{"exp": exp}, false},
+		{"subject is not a UUID", map[string]interface{}{"sub": "alice", "exp": exp}, false},
+		{"admin subject is not a UUID", map[string]interface{}{"sub": "alice", "exp": exp, "role": "admin"}, false},
+		{"org_id is not a UUID", map[string]interface{}{"sub": userID, "exp": exp, "org_id": "acme"}, false},
+		{"no expiration", map[string]interface{}{"sub": userID}, false},
+		{"expired", map[string]interface{}{"sub": userID, "exp": time.Now().Add(-time.Hour).Unix()}, false},
+	}
+
+	a := newTestJWTAuthenticator(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := a.Verify(signHS256(t, tt.claims))
+			if !tt.valid {
+				if !errors.Is(err, ErrInvalidCredentials) {
+					t.Errorf("expected invalid credentials, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if principal.Subject != userID || principal.Method != model.AuthMethodJWT {
+				t.Errorf("unexpected principal %+v", principal)
+			}
+		})
+	}
+}
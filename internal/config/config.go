@@ -3,7 +3,10 @@ package config
 import (
 	"log"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/Headliner38/Subscription_Service/internal/ratelimit"
 	"github.com/joho/godotenv"
 )
 
@@ -14,6 +17,33 @@ type Config struct {
 	DBUser     string
 	DBPassword string
 	DBName     string
+	// AutoMigrate включает применение миграций при старте сервиса
+	AutoMigrate bool
+	// DeletedRetention — сколько хранить мягко удалённые подписки; 0 отключает очистку
+	DeletedRetention time.Duration
+	// PurgeInterval — как часто запускать очистку удалённых подписок
+	PurgeInterval time.Duration
+	// RequireIfMatch обязывает передавать If-Match в PUT, PATCH и DELETE; по умолчанию выключено
+	RequireIfMatch bool
+	// AuthEnabled требует API-ключ или JWT во всех запросах к API; по умолчанию выключено
+	AuthEnabled bool
+	// JWKSFile — файл JWKS с ключами проверки JWT; без него принимаются только API-ключи
+	JWKSFile string
+	// JWTIssuer и JWTAudience, если заданы, должны совпадать с iss и aud токенов
+	JWTIssuer   string
+	JWTAudience string
+	// TenantRowSecurity передаёт организацию запроса в PostgreSQL, чтобы политики построчной
+	// безопасности скрывали данные других организаций
+	TenantRowSecurity bool
+	// RateLimit — лимит запросов к группе маршрутов для одного API-ключа, пользователя или IP
+	RateLimit ratelimit.Limit
+	// RateLimitGroups переопределяют RateLimit для отдельных групп маршрутов
+	RateLimitGroups map[string]ratelimit.Limit
+	// IPRateLimit — лимит запросов с одного IP-адреса, проверяемый до аутентификации
+	IPRateLimit ratelimit.Limit
+	// TrustedProxies — адреса прокси, которым можно доверить X-Forwarded-For при определении
+	// IP клиента; пусто — IP берётся из соединения
+	TrustedProxies []string
 }
 
 func LoadConfig() *Config {
@@ -22,11 +52,85 @@ func LoadConfig() *Config {
 	}
 
 	return &Config{
-		AppPort:    os.Getenv("APP_PORT"),
-		DBHost:     os.Getenv("DB_HOST"),
-		DBPort:     os.Getenv("DB_PORT"),
-		DBUser:     os.Getenv("DB_USER"),
-		DBPassword: os.Getenv("DB_PASSWORD"),
-		DBName:     os.Getenv("DB_NAME"),
+		AppPort:           os.Getenv("APP_PORT"),
+		DBHost:            os.Getenv("DB_HOST"),
+		DBPort:            os.Getenv("DB_PORT"),
+		DBUser:            os.Getenv("DB_USER"),
+		DBPassword:        os.Getenv("DB_PASSWORD"),
+		DBName:            os.Getenv("DB_NAME"),
+		AutoMigrate:       os.Getenv("AUTO_MIGRATE") == "true",
+		DeletedRetention:  getDuration("DELETED_RETENTION", 30*24*time.Hour),
+		PurgeInterval:     getDuration("PURGE_INTERVAL", time.Hour),
+		RequireIfMatch:    os.Getenv("REQUIRE_IF_MATCH") == "true",
+		AuthEnabled:       os.Getenv("AUTH_ENABLED") == "true",
+		JWKSFile:          os.Getenv("JWKS_FILE"),
+		JWTIssuer:         os.Getenv("JWT_ISSUER"),
+		JWTAudience:       os.Getenv("JWT_AUDIENCE"),
+		TenantRowSecurity: os.Getenv("TENANT_ROW_SECURITY") == "true",
+		RateLimit:         getLimit("RATE_LIMIT", ratelimit.Limit{Rate: 10, Burst: 20}),
+		RateLimitGroups:   getGroupLimits("RATE_LIMIT_GROUPS"),
+		IPRateLimit:       getLimit("RATE_LIMIT_IP", ratelimit.Limit{Rate: 50, Burst: 100}),
+		TrustedProxies:    getList("TRUSTED_PROXIES"),
 	}
 }
+
+// getDuration читает длительность вида "720h" из переменной окружения
+func getDuration(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("[CONFIG] Invalid %s=%q, using default %s", key, value, def)
+		return def
+	}
+	return d
+}
+
+// getLimit читает лимит запросов вида "10:20" (запросов в секунду и ёмкость корзины)
+func getLimit(key string, def ratelimit.Limit) ratelimit.Limit {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	limit, err := ratelimit.ParseLimit(value)
+	if err != nil {
+		log.Printf("[CONFIG] Invalid %s=%q, using default %s: %v", key, value, def, err)
+		return def
+	}
+	return limit
+}
+
+// getGroupLimits читает лимиты групп маршрутов вида "subscriptions=5:10,audit=1:5";
+// неверные записи пропускаются
+func getGroupLimits(key string) map[string]ratelimit.Limit {
+	limits := make(map[string]ratelimit.Limit)
+	for _, entry := range getList(key) {
+		group, value, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(group) == "" {
+			log.Printf("[CONFIG] Invalid %s entry %q, expected group=rate:burst", key, entry)
+			continue
+		}
+		limit, err := ratelimit.ParseLimit(value)
+		if err != nil {
+			log.Printf("[CONFIG] Invalid %s entry %q: %v", key, entry, err)
+			continue
+		}
+		limits[strings.TrimSpace(group)] = limit
+	}
+	return limits
+}
+
+// getList читает список значений через запятую
+func getList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
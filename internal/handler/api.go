@@ -1,21 +1,124 @@
 package handler
 
 import (
+	"github.com/Headliner38/Subscription_Service/internal/auth"
+	"github.com/Headliner38/Subscription_Service/internal/ratelimit"
 	"github.com/Headliner38/Subscription_Service/internal/service"
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(r *gin.Engine, subscriptionService *service.SubscriptionService) {
-	subscriptionHandler := &SubscriptionHandler{Service: subscriptionService}
+// Options — настройки HTTP API
+type Options struct {
+	// RequireIfMatch обязывает клиентов передавать If-Match при изменении и удалении подписки
+	RequireIfMatch bool
+	// Authenticators проверяют учётные данные всех запросов к API по очереди;
+	// если список пуст, аутентификация отключена
+	Authenticators []auth.Authenticator
+	// RateLimitStore хранит корзины ограничителя частоты запросов; если не задано,
+	// частота запросов не ограничивается
+	RateLimitStore ratelimit.Store
+	// RateLimit — лимит запросов к группе маршрутов для одного ключа, пользователя или IP
+	RateLimit ratelimit.Limit
+	// RateLimits переопределяют RateLimit для отдельных групп: subscriptions, services,
+	// exchange-rates, api-keys и audit
+	RateLimits map[string]ratelimit.Limit
+	// IPRateLimit — общий для всех групп лимит запросов с одного IP-адреса, который
+	// проверяется до аутентификации; действует, только если аутентификация включена
+	IPRateLimit ratelimit.Limit
+}
+
+// rateLimit возвращает лимит запросов к группе маршрутов
+func (o Options) rateLimit(group string) ratelimit.Limit {
+	if limit, ok := o.RateLimits[group]; ok {
+		return limit
+	}
+	return o.RateLimit
+}
+
+func SetupRoutes(r *gin.Engine, subscriptionService *service.SubscriptionService, opts Options) {
+	subscriptionHandler := &SubscriptionHandler{
+		Service:        subscriptionService,
+		RequireIfMatch: opts.RequireIfMatch,
+	}
 
-	// Группа маршрутов для подписок
-	subscriptions := r.Group("/subscriptions")
+	var middleware []gin.HandlerFunc
+	if len(opts.Authenticators) > 0 {
+		// Лимит по IP ставится до аутентификации, чтобы неверные ключи и токены тоже его расходовали
+		if opts.RateLimitStore != nil {
+			middleware = append(middleware, IPRateLimitMiddleware(opts.RateLimitStore, opts.IPRateLimit))
+		}
+		middleware = append(middleware, AuthMiddleware(opts.Authenticators...))
+	}
+	// group собирает middleware группы маршрутов: лимит по IP и аутентификация, ограничение
+	// частоты запросов группы (после аутентификации, чтобы считать запросы по ключу, а не по IP)
+	// и проверки группы
+	group := func(name string, checks ...gin.HandlerFunc) []gin.HandlerFunc {
+		handlers := append([]gin.HandlerFunc{}, middleware...)
+		if opts.RateLimitStore != nil {
+			handlers = append(handlers, RateLimitMiddleware(opts.RateLimitStore, name, opts.rateLimit(name)))
+		}
+		return append(handlers, checks...)
+	}
+
+	// Группа маршрутов для подписок; подписки и API-ключи принадлежат организациям,
+	// каталог и курсы валют общие
+	subscriptions := r.Group("/subscriptions", group("subscriptions", TenantMiddleware())...)
 	{
 		subscriptions.POST("/", subscriptionHandler.CreateSubscription)
 		subscriptions.GET("/", subscriptionHandler.ListSubscriptions)
+		subscriptions.POST("/batch", subscriptionHandler.BatchSubscriptions)
+		subscriptions.POST("/import", subscriptionHandler.ImportSubscriptions)
 		subscriptions.GET("/:id", subscriptionHandler.GetSubscription)
 		subscriptions.PUT("/:id", subscriptionHandler.UpdateSubscription)
+		subscriptions.PATCH("/:id", subscriptionHandler.PatchSubscription)
 		subscriptions.DELETE("/:id", subscriptionHandler.DeleteSubscription)
+		subscriptions.POST("/:id/restore", RequireAdmin(), subscriptionHandler.RestoreSubscription)
+		subscriptions.POST("/:id/cancel", subscriptionHandler.CancelSubscription)
+		subscriptions.POST("/:id/pause", subscriptionHandler.PauseSubscription)
+		subscriptions.POST("/:id/resume", subscriptionHandler.ResumeSubscription)
+		subscriptions.GET("/:id/prices", subscriptionHandler.ListPriceChanges)
+		subscriptions.PUT("/:id/prices/:date", subscriptionHandler.SetPrice)
+		subscriptions.DELETE("/:id/prices/:date", subscriptionHandler.DeletePriceChange)
+		subscriptions.GET("/:id/history", subscriptionHandler.SubscriptionHistory)
 		subscriptions.GET("/total", subscriptionHandler.CalculateTotalCost)
+		subscriptions.GET("/changes", subscriptionHandler.ListChanges)
+		subscriptions.GET("/reports/spend", subscriptionHandler.SpendReport)
+		subscriptions.GET("/reports/by-tag", subscriptionHandler.TagReport)
+	}
+
+	// Каталог сервисов и курсы валют общие для всех пользователей и организаций, поэтому
+	// меняют их только администраторы, не привязанные к организации
+	admin := RequireGlobalAdmin()
+
+	// Каталог сервисов
+	services := r.Group("/services", group("services")...)
+	{
+		services.POST("/", admin, subscriptionHandler.CreateService)
+		services.GET("/", subscriptionHandler.ListServices)
+		services.GET("/:id", subscriptionHandler.GetService)
+		services.PUT("/:id", admin, subscriptionHandler.UpdateService)
+		services.DELETE("/:id", admin, subscriptionHandler.DeleteService)
+	}
+
+	// Курсы валют для пересчёта стоимости
+	rates := r.Group("/exchange-rates", group("exchange-rates")...)
+	{
+		rates.GET("/", subscriptionHandler.ListExchangeRates)
+		rates.PUT("/:base/:quote/:date", admin, subscriptionHandler.SetExchangeRate)
+		rates.DELETE("/:base/:quote/:date", admin, subscriptionHandler.DeleteExchangeRate)
+	}
+
+	// API-ключи и журнал аудита доступны только администраторам, в пределах организации запроса
+	apiKeys := r.Group("/api-keys", group("api-keys", RequireAdmin(), TenantMiddleware())...)
+	{
+		apiKeys.POST("/", subscriptionHandler.CreateAPIKey)
+		apiKeys.GET("/", subscriptionHandler.ListAPIKeys)
+		apiKeys.DELETE("/:id", subscriptionHandler.RevokeAPIKey)
+	}
+
+	// Журнал аудита изменений подписок
+	audit := r.Group("/audit", group("audit", RequireAdmin(), TenantMiddleware())...)
+	{
+		audit.GET("/", subscriptionHandler.ListAudit)
 	}
 }
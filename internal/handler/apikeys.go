@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateAPIKeyRequest — данные нового API-ключа
+type CreateAPIKeyRequest struct {
+	Name      string  `json:"name" example:"billing-export" binding:"required"`
+	UserID    string  `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba" binding:"required"`
+	Role      string  `json:"role,omitempty" example:"user" enums:"user,admin"`    // по умолчанию user
+	ExpiresAt *string `json:"expires_at,omitempty" example:"2026-01-01T00:00:00Z"` // RFC 3339 или YYYY-MM-DD; без него ключ бессрочный
+	// OrganizationID привязывает ключ к организации; по умолчанию организация запроса, без неё ключ работает с любой организацией
+	OrganizationID string `json:"organization_id,omitempty" example:"0b5d8f3e-2c4a-4e6b-9d1f-3a5c7e9b1d2f"`
+}
+
+// CreateAPIKeyResponse — выпущенный ключ; секрет key показывается только в этом ответе
+type CreateAPIKeyResponse struct {
+	model.APIKey
+	Key string `json:"key" example:"ssk_Ab3dE9fGh1jKl2mNo3pQr4sTu5vWx6yZ7aBc8dEf9gH"`
+}
+
+type APIKeysResponse struct {
+	Items []model.APIKey `json:"items"`
+}
+
+// CreateAPIKey godoc
+// @Summary Выпустить API-ключ
+// @Description Выпускает API-ключ для пользователя. Ключ, привязанный к организации, работает только с её данными. Секрет возвращается в поле key только один раз: хранится лишь его хеш. Ключ передаётся в заголовке X-API-Key или Authorization: Bearer. Доступно только администраторам.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param key body CreateAPIKeyRequest true "Данные ключа"
+// @Param X-Tenant-ID header string false "ID организации запроса; по умолчанию организация ключа или токена"
+// @Success 201 {object} CreateAPIKeyResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api-keys [post]
+func (h *SubscriptionHandler) CreateAPIKey(c *gin.Context) {
+	log.Printf("[HANDLER] Creating API key")
+
+	var req CreateAPIKeyRequest
+	if err := bindJSON(c, &req); err != nil {
+		log.Printf("[ERROR] Invalid API key request: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	key, secret, err := h.scopedService(c).CreateAPIKey(c.Request.Context(), service.APIKeyInput{
+		Name:           req.Name,
+		UserID:         req.UserID,
+		Role:           req.Role,
+		ExpiresAt:      req.ExpiresAt,
+		OrganizationID: req.OrganizationID,
+	})
+	if err != nil {
+		log.Printf("[ERROR] Failed to create API key: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	log.Printf("[SUCCESS] API key created with ID: %s", key.ID)
+	c.JSON(http.StatusCreated, CreateAPIKeyResponse{APIKey: *key, Key: secret})
+}
+
+// ListAPIKeys godoc
+// @Summary Список API-ключей
+// @Description Возвращает API-ключи без секретов, включая отозванные и просроченные; с организацией запроса — только ключи этой организации. Доступно только администраторам.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param user_id query string false "ID владельца ключей"
+// @Param X-Tenant-ID header string false "ID организации запроса; по умолчанию организация ключа или токена"
+// @Success 200 {object} APIKeysResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api-keys [get]
+func (h *SubscriptionHandler) ListAPIKeys(c *gin.Context) {
+	userID := c.Query("user_id")
+	log.Printf("[HANDLER] Listing API keys, user: %q", userID)
+
+	keys, err := h.scopedService(c).ListAPIKeys(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("[ERROR] Failed to list API keys: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	log.Printf("[SUCCESS] Retrieved %d API keys", len(keys))
+	c.JSON(http.StatusOK, APIKeysResponse{Items: keys})
+}
+
+// RevokeAPIKey godoc
+// @Summary Отозвать API-ключ
+// @Description Отзывает API-ключ: запросы с ним сразу получают 401. Отозванный ключ остаётся в списке. Доступно только администраторам.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param id path string true "ID ключа"
+// @Param X-Tenant-ID header string false "ID организации запроса; по умолчанию организация ключа или токена"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api-keys/{id} [delete]
+func (h *SubscriptionHandler) RevokeAPIKey(c *gin.Context) {
+	id := c.Param("id")
+	log.Printf("[HANDLER] Revoking API key with ID: %s", id)
+
+	if _, err := h.scopedService(c).RevokeAPIKey(c.Request.Context(), id); err != nil {
+		log.Printf("[ERROR] Failed to revoke API key %s: %v", id, err)
+		_ = c.Error(err)
+		return
+	}
+
+	log.Printf("[SUCCESS] Revoked API key with ID: %s", id)
+	c.Status(http.StatusNoContent)
+}
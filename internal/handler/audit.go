@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type AuditResponse struct {
+	Items      []model.AuditEntry `json:"items"`
+	NextCursor string             `json:"next_cursor,omitempty" example:"YXVkOjQy"`
+}
+
+// SubscriptionHistory godoc
+// @Summary История изменений подписки
+// @Description Возвращает записи журнала аудита подписки от новых к старым: кто, когда и в каком запросе её изменил, со значениями изменённых полей до и после. История удалённой подписки тоже доступна.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Param limit query int false "Размер страницы (1-500)" default(50)
+// @Param cursor query string false "Курсор следующей страницы (next_cursor из предыдущего ответа)"
+// @Param X-Tenant-ID header string false "ID организации запроса; по умолчанию организация ключа или токена"
+// @Success 200 {object} AuditResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /subscriptions/{id}/history [get]
+func (h *SubscriptionHandler) SubscriptionHistory(c *gin.Context) {
+	id := c.Param("id")
+	log.Printf("[HANDLER] Getting history of subscription with ID: %s", id)
+
+	page, err := h.scopedService(c).SubscriptionHistory(c.Request.Context(), id, service.AuditParams{
+		Limit:  c.Query("limit"),
+		Cursor: c.Query("cursor"),
+	})
+	if err != nil {
+		log.Printf("[ERROR] Failed to get history of subscription %s: %v", id, err)
+		_ = c.Error(err)
+		return
+	}
+
+	log.Printf("[SUCCESS] Retrieved %d history entries of subscription %s", len(page.Items), id)
+	c.JSON(http.StatusOK, AuditResponse{Items: page.Items, NextCursor: page.NextCursor})
+}
+
+// ListAudit godoc
+// @Summary Журнал аудита
+// @Description Возвращает записи журнала аудита изменений подписок от новых к старым. С организацией запроса — только записи этой организации. Доступно только администраторам.
+// @Tags audit
+// @Accept json
+// @Produce json
+// @Param subscription_id query string false "ID подписки"
+// @Param user_id query string false "ID владельца подписки"
+// @Param actor query string false "ID того, кто выполнил изменение"
+// @Param action query string false "Действие" Enums(create, update, delete, restore, pause, resume, cancel, set_price, delete_price)
+// @Param request_id query string false "ID запроса"
+// @Param from query string false "Не раньше (RFC 3339 или YYYY-MM-DD)"
+// @Param to query string false "Не позже (RFC 3339 или YYYY-MM-DD — день включительно)"
+// @Param limit query int false "Размер страницы (1-500)" default(50)
+// @Param cursor query string false "Курсор следующей страницы (next_cursor из предыдущего ответа)"
+// @Param X-Tenant-ID header string false "ID организации запроса; по умолчанию организация ключа или токена"
+// @Success 200 {object} AuditResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /audit [get]
+func (h *SubscriptionHandler) ListAudit(c *gin.Context) {
+	log.Printf("[HANDLER] Listing audit log")
+
+	page, err := h.scopedService(c).ListAudit(c.Request.Context(), service.AuditParams{
+		SubscriptionID: c.Query("subscription_id"),
+		UserID:         c.Query("user_id"),
+		Actor:          c.Query("actor"),
+		Action:         c.Query("action"),
+		RequestID:      c.Query("request_id"),
+		From:           c.Query("from"),
+		To:             c.Query("to"),
+		Limit:          c.Query("limit"),
+		Cursor:         c.Query("cursor"),
+	})
+	if err != nil {
+		log.Printf("[ERROR] Failed to list audit log: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	log.Printf("[SUCCESS] Retrieved %d audit entries", len(page.Items))
+	c.JSON(http.StatusOK, AuditResponse{Items: page.Items, NextCursor: page.NextCursor})
+}
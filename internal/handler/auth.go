@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Headliner38/Subscription_Service/internal/auth"
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/service"
+	"github.com/Headliner38/Subscription_Service/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// principalKey — ключ контекста gin, под которым AuthMiddleware сохраняет вызывающего
+const principalKey = "principal"
+
+// scopeKey — ключ контекста gin, под которым TenantMiddleware сохраняет область данных запроса
+const scopeKey = "scope"
+
+// tenantHeader — заголовок, в котором клиент указывает организацию запроса
+const tenantHeader = "X-Tenant-ID"
+
+// errForbidden — вызывающий аутентифицирован, но его роли не хватает для операции
+var errForbidden = errors.New("insufficient permissions")
+
+// AuthMiddleware проверяет учётные данные запроса аутентификаторами по очереди и сохраняет
+// вызывающего в контексте. Аутентификатор, не нашедший в запросе своих учётных данных,
+// уступает следующему; неверные учётные данные сразу завершают запрос с 401.
+// Вызывающий, чей ID не UUID, тоже отклоняется: область данных сравнивает его с user_id подписок.
+func AuthMiddleware(authenticators ...auth.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var principal *model.Principal
+		err := auth.ErrNoCredentials
+		for _, a := range authenticators {
+			principal, err = a.Authenticate(c.Request.Context(), c.Request)
+			if !errors.Is(err, auth.ErrNoCredentials) {
+				break
+			}
+		}
+		if err == nil && !utils.IsValidUUID(principal.Subject) {
+			err = fmt.Errorf("%w: subject is not a valid UUID", auth.ErrInvalidCredentials)
+		}
+		if err != nil {
+			log.Printf("[AUTH] Request %s rejected: %v", c.GetString(requestIDKey), err)
+			if errors.Is(err, auth.ErrNoCredentials) || errors.Is(err, auth.ErrInvalidCredentials) {
+				c.Header("WWW-Authenticate", `Bearer realm="subscription-service"`)
+			}
+			_ = c.Error(err)
+			c.Abort()
+			return
+		}
+
+		c.Set(principalKey, principal)
+		c.Next()
+	}
+}
+
+// CurrentPrincipal возвращает вызывающего, сохранённого AuthMiddleware
+func CurrentPrincipal(c *gin.Context) (*model.Principal, bool) {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return nil, false
+	}
+	principal, ok := value.(*model.Principal)
+	return principal, ok
+}
+
+// queryIncludeDeleted читает параметр include_deleted: удалённые подписки видят только
+// администраторы, остальным параметр отвечает 403. Без аутентификации ограничений нет.
+func queryIncludeDeleted(c *gin.Context) (bool, error) {
+	includeDeleted, err := queryBool(c, "include_deleted")
+	if err != nil || !includeDeleted {
+		return false, err
+	}
+	if principal, ok := CurrentPrincipal(c); ok && !principal.IsAdmin() {
+		log.Printf("[AUTH] Request %s forbidden: include_deleted for %s with role %s", c.GetString(requestIDKey), principal.Subject, principal.Role)
+		return false, errForbidden
+	}
+	return true, nil
+}
+
+// RequireAdmin пропускает только администраторов. Без AuthMiddleware (аутентификация
+// отключена) вызывающего в контексте нет, и запрос пропускается.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := CurrentPrincipal(c)
+		if ok && !principal.IsAdmin() {
+			log.Printf("[AUTH] Request %s forbidden for %s with role %s", c.GetString(requestIDKey), principal.Subject, principal.Role)
+			_ = c.Error(errForbidden)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireGlobalAdmin пропускает только администраторов, не привязанных к организации:
+// общие для всех организаций данные не может менять администратор одной из них
+func RequireGlobalAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := CurrentPrincipal(c)
+		if ok && (!principal.IsAdmin() || principal.OrganizationID != "") {
+			log.Printf("[AUTH] Request %s forbidden for %s with role %s, organization %q", c.GetString(requestIDKey), principal.Subject, principal.Role, principal.OrganizationID)
+			_ = c.Error(errForbidden)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// TenantMiddleware определяет организацию запроса и сохраняет область данных в контексте.
+// Организация берётся из ключа или токена вызывающего, а если он ни к одной не привязан —
+// из заголовка X-Tenant-ID. Заголовок с чужой организацией отклоняется с 403.
+// Без организации запрос работает с данными всех организаций.
+func TenantMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, _ := CurrentPrincipal(c)
+		scope := principal.Scope()
+
+		if tenant := strings.TrimSpace(c.GetHeader(tenantHeader)); tenant != "" {
+			if !utils.IsValidUUID(tenant) {
+				_ = c.Error(service.NewValidationError(tenantHeader, tenantHeader+" must be a valid UUID"))
+				c.Abort()
+				return
+			}
+			tenant = strings.ToLower(tenant)
+			if scope.OrganizationID != "" && scope.OrganizationID != tenant {
+				log.Printf("[AUTH] Request %s forbidden: organization %s requested, credentials bound to %s", c.GetString(requestIDKey), tenant, scope.OrganizationID)
+				_ = c.Error(errForbidden)
+				c.Abort()
+				return
+			}
+			scope.OrganizationID = tenant
+		}
+
+		c.Set(scopeKey, scope)
+		c.Next()
+	}
+}
+
+// requestScope возвращает область данных запроса, сохранённую TenantMiddleware
+func requestScope(c *gin.Context) model.Scope {
+	if value, ok := c.Get(scopeKey); ok {
+		if scope, ok := value.(model.Scope); ok {
+			return scope
+		}
+	}
+	principal, _ := CurrentPrincipal(c)
+	return principal.Scope()
+}
+
+// requestActor возвращает вызывающего и ID запроса для журнала аудита
+func requestActor(c *gin.Context) model.Actor {
+	actor := model.Actor{RequestID: c.GetString(requestIDKey)}
+	if principal, ok := CurrentPrincipal(c); ok {
+		actor.Subject, actor.Role, actor.Method = principal.Subject, principal.Role, principal.Method
+	}
+	return actor
+}
+
+// scopedService возвращает сервис, действующий от имени вызывающего: изменения записываются
+// в журнал аудита с его ID и ID запроса, а данные ограничены областью запроса — организацией
+// и, для пользователя, его собственными подписками. Администратору доступны все подписки
+// организации, а без организации и при отключённой аутентификации — все подписки.
+// Чужие подписки для вызывающего не существуют, поэтому обращение к ним даёт 404, а не 403.
+func (h *SubscriptionHandler) scopedService(c *gin.Context) *service.SubscriptionService {
+	svc := h.Service.WithActor(requestActor(c))
+	if scope := requestScope(c); scope != (model.Scope{}) {
+		svc = svc.WithScope(scope)
+	}
+	return svc
+}
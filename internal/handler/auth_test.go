@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Headliner38/Subscription_Service/internal/auth"
+	"github.com/Headliner38/Subscription_Service/internal/model"
+)
+
+// staticAuthenticator пропускает любой запрос от имени заданного вызывающего
+type staticAuthenticator struct {
+	principal model.Principal
+}
+
+func (a staticAuthenticator) Authenticate(context.Context, *http.Request) (*model.Principal, error) {
+	principal := a.principal
+	return &principal, nil
+}
+
+func TestAuthMiddlewareRejectsNonUUIDSubject(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal model.Principal
+		status    int
+	}{
+		{"user with UUID", model.Principal{Subject: testUserID, Role: model.RoleUser}, http.StatusOK},
+		{"user with name", model.Principal{Subject: "alice", Role: model.RoleUser}, http.StatusUnauthorized},
+		{"admin with name", model.Principal{Subject: "alice", Role: model.RoleAdmin}, http.StatusUnauthorized},
+		{"empty subject", model.Principal{Role: model.RoleUser}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(Options{Authenticators: []auth.Authenticator{staticAuthenticator{tt.principal}}}, false)
+
+			// Без проверки пользователь "alice" получил бы область user_id = 'alice',
+			// а PostgreSQL — ошибку сравнения с колонкой UUID
+			w := api.do(http.MethodGet, "/subscriptions/", "", nil)
+			if w.Code != tt.status {
+				t.Fatalf("expected %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.status == http.StatusUnauthorized {
+				if code := errorCode(t, w); code != "unauthorized" {
+					t.Errorf("expected code unauthorized, got %s", code)
+				}
+			}
+		})
+	}
+}
+
+func TestDeletedSubscriptionsAdminOnly(t *testing.T) {
+	api := newTestAPI(Options{}, true)
+	admin := map[string]string{"X-API-Key": api.issueKey(t, adminUserID, model.RoleAdmin)}
+	user := map[string]string{"X-API-Key": api.issueKey(t, testUserID, model.RoleUser)}
+
+	// Собственная подписка пользователя, удалённая им самим
+	created := api.do(http.MethodPost, "/subscriptions/", subscriptionBody(10000), user)
+	if created.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", created.Code, created.Body.String())
+	}
+	var sub model.Subscription
+	if err := json.Unmarshal(created.Body.Bytes(), &sub); err != nil {
+		t.Fatalf("create: invalid body: %v", err)
+	}
+	if w := api.do(http.MethodDelete, "/subscriptions/"+sub.ID, "", user); w.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+
+	period := "start_date=01-2024&end_date=03-2024"
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		status  int
+	}{
+		{"user reads deleted", http.MethodGet, "/subscriptions/" + sub.ID + "?include_deleted=true", user, http.StatusForbidden},
+		{"user lists deleted", http.MethodGet, "/subscriptions/?include_deleted=true", user, http.StatusForbidden},
+		{"user totals deleted", http.MethodGet, "/subscriptions/total?include_deleted=true&" + period, user, http.StatusForbidden},
+		{"user spend report with deleted", http.MethodGet, "/subscriptions/reports/spend?include_deleted=true&" + period, user, http.StatusForbidden},
+		{"user tag report with deleted", http.MethodGet, "/subscriptions/reports/by-tag?include_deleted=true&" + period, user, http.StatusForbidden},
+		{"user restores own", http.MethodPost, "/subscriptions/" + sub.ID + "/restore", user, http.StatusForbidden},
+		{"user lists without deleted", http.MethodGet, "/subscriptions/?include_deleted=false", user, http.StatusOK},
+		{"admin reads deleted", http.MethodGet, "/subscriptions/" + sub.ID + "?include_deleted=true", admin, http.StatusOK},
+		{"admin totals deleted", http.MethodGet, "/subscriptions/total?include_deleted=true&" + period, admin, http.StatusOK},
+		{"admin restores", http.MethodPost, "/subscriptions/" + sub.ID + "/restore", admin, http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := api.do(tt.method, tt.path, "", tt.headers)
+		if w.Code != tt.status {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.status, w.Code, w.Body.String())
+			continue
+		}
+		if tt.status == http.StatusForbidden {
+			if code := errorCode(t, w); code != "forbidden" {
+				t.Errorf("%s: expected code forbidden, got %s", tt.name, code)
+			}
+		}
+	}
+}
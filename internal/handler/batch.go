@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// BatchSubscriptionData — данные подписки для операций create и update.
+// Проверяются теми же правилами, что и в POST и PUT.
+type BatchSubscriptionData struct {
+	ServiceID     string   `json:"service_id,omitempty" example:"7a1f3c2e-5b4d-4e8f-9a6b-1c2d3e4f5a6b"`
+	ServiceName   string   `json:"service_name" example:"Netflix"`
+	Price         int      `json:"price" example:"99900"`
+	Currency      string   `json:"currency,omitempty" example:"RUB"`
+	BillingPeriod string   `json:"billing_period,omitempty" example:"monthly" enums:"weekly,monthly,quarterly,yearly"`
+	IntervalCount int      `json:"interval_count,omitempty" example:"1"`
+	Category      string   `json:"category,omitempty" example:"streaming"`
+	Tags          []string `json:"tags,omitempty" example:"family,work"`
+	UserID        string   `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	// OrganizationID учитывается только в create
+	OrganizationID string `json:"organization_id,omitempty" example:"0b5d8f3e-2c4a-4e6b-9d1f-3a5c7e9b1d2f"`
+	StartDate      string `json:"start_date" example:"01-2024"`
+	EndDate        string `json:"end_date,omitempty" example:"12-2024"`
+}
+
+type BatchOperationRequest struct {
+	Op string `json:"op" example:"create" enums:"create,update,delete"`
+	// ID подписки для update и delete
+	ID string `json:"id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
+	// Version — ожидаемая версия (ETag) для update и delete
+	Version      int64                  `json:"version,omitempty" example:"3"`
+	Subscription *BatchSubscriptionData `json:"subscription,omitempty"`
+}
+
+type BatchRequest struct {
+	// Atomic — выполнить все операции в одной транзакции: при первой ошибке пакет откатывается целиком
+	Atomic     bool                    `json:"atomic" example:"false"`
+	Operations []BatchOperationRequest `json:"operations" binding:"required"`
+}
+
+type BatchItemResult struct {
+	Index        int                 `json:"index" example:"0"`
+	Op           string              `json:"op" example:"create"`
+	Status       int                 `json:"status" example:"201"`
+	ID           string              `json:"id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Subscription *model.Subscription `json:"subscription,omitempty"`
+	Error        *ErrorResponse      `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Atomic    bool              `json:"atomic" example:"false"`
+	Succeeded int               `json:"succeeded" example:"2"`
+	Failed    int               `json:"failed" example:"0"`
+	Results   []BatchItemResult `json:"results"`
+}
+
+// BatchSubscriptions godoc
+// @Summary Пакетные операции с подписками
+// @Description Выполняет по порядку операции create, update и delete. В атомарном режиме (atomic=true) все операции выполняются в одной транзакции: при первой ошибке изменения откатываются, а ответ содержит ошибку с индексом операции. Иначе операции выполняются независимо, и для каждой возвращается свой статус. Если требуется If-Match, для update и delete обязательно поле version.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param batch body BatchRequest true "Операции"
+// @Param X-Tenant-ID header string false "ID организации запроса; по умолчанию организация ключа или токена"
+// @Success 200 {object} BatchResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} model.Subscription "Атомарный пакет: версия не совпала"
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /subscriptions/batch [post]
+func (h *SubscriptionHandler) BatchSubscriptions(c *gin.Context) {
+	log.Printf("[HANDLER] Executing subscriptions batch")
+
+	var req BatchRequest
+	if err := bindJSON(c, &req); err != nil {
+		log.Printf("[ERROR] Invalid batch request body: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	ops := make([]service.BatchOperation, 0, len(req.Operations))
+	for _, item := range req.Operations {
+		op := service.BatchOperation{Op: item.Op, ID: item.ID, Version: item.Version}
+		if data := item.Subscription; data != nil {
+			op.ServiceID = data.ServiceID
+			op.ServiceName = data.ServiceName
+			op.Price = data.Price
+			op.Currency = data.Currency
+			op.BillingPeriod = data.BillingPeriod
+			op.IntervalCount = data.IntervalCount
+			op.Category = data.Category
+			op.Tags = data.Tags
+			op.UserID = data.UserID
+			op.OrganizationID = data.OrganizationID
+			op.StartDate = data.StartDate
+			if data.EndDate != "" {
+				op.EndDate = &data.EndDate
+			}
+		}
+		ops = append(ops, op)
+	}
+
+	results, err := h.scopedService(c).ExecuteBatch(c.Request.Context(), ops, req.Atomic, h.RequireIfMatch)
+	if err != nil {
+		log.Printf("[ERROR] Batch failed: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	resp := BatchResponse{Atomic: req.Atomic, Results: make([]BatchItemResult, 0, len(results))}
+	for _, result := range results {
+		item := BatchItemResult{
+			Index:        result.Index,
+			Op:           result.Op,
+			ID:           result.ID,
+			Subscription: result.Subscription,
+		}
+
+		switch {
+		case result.Err != nil:
+			status, errResp := translateError(result.Err)
+			errResp.RequestID = c.GetString(requestIDKey)
+			item.Status = status
+			item.Error = &errResp
+			resp.Failed++
+		case result.Op == service.BatchCreate:
+			item.Status = http.StatusCreated
+			resp.Succeeded++
+		case result.Op == service.BatchDelete:
+			item.Status = http.StatusNoContent
+			resp.Succeeded++
+		default:
+			item.Status = http.StatusOK
+			resp.Succeeded++
+		}
+		resp.Results = append(resp.Results, item)
+	}
+
+	log.Printf("[SUCCESS] Batch executed: %d succeeded, %d failed", resp.Succeeded, resp.Failed)
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Headliner38/Subscription_Service/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// bindingError — тело запроса не удалось разобрать как JSON
+type bindingError struct {
+	err error
+}
+
+func (e *bindingError) Error() string {
+	return "invalid request body: " + e.err.Error()
+}
+
+func (e *bindingError) Unwrap() error {
+	return e.err
+}
+
+// bindJSON разбирает тело запроса в req. Ошибки правил binding превращаются
+// в service.ValidationError с именами полей из json-тегов.
+func bindJSON(c *gin.Context, req interface{}) error {
+	err := c.ShouldBindJSON(req)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return &bindingError{err: err}
+	}
+
+	result := &service.ValidationError{}
+	for _, fe := range validationErrs {
+		field := jsonFieldName(req, fe.StructField())
+		result.Fields = append(result.Fields, service.FieldError{
+			Field:   field,
+			Message: validationMessage(field, fe),
+		})
+	}
+	return result
+}
+
+// jsonFieldName возвращает имя поля структуры из её json-тега
+func jsonFieldName(req interface{}, structField string) string {
+	t := reflect.TypeOf(req)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if f, ok := t.FieldByName(structField); ok {
+		if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return structField
+}
+
+// validationMessage формирует сообщение для нарушенного правила binding
+func validationMessage(field string, fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return field + " is required"
+	case "gt":
+		return field + " must be greater than " + fe.Param()
+	default:
+		return field + " is invalid"
+	}
+}
+
+// queryBool читает булев query-параметр; отсутствующий параметр означает false
+func queryBool(c *gin.Context, name string) (bool, error) {
+	value := c.Query(name)
+	if value == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, service.NewValidationError(name, name+" must be a boolean")
+	}
+	return b, nil
+}
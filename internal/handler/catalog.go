@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ServiceRequest — данные сервиса каталога в теле POST и PUT
+type ServiceRequest struct {
+	Name         string   `json:"name" example:"Netflix" binding:"required"`
+	Aliases      []string `json:"aliases,omitempty" example:"NFLX,Нетфликс"`
+	Category     string   `json:"category,omitempty" example:"streaming"`
+	DefaultPrice *int     `json:"default_price,omitempty" example:"99900" binding:"omitempty,gt=0"` // в минимальных единицах currency
+	Currency     string   `json:"currency,omitempty" example:"RUB"`                                 // по умолчанию RUB
+	VendorURL    string   `json:"vendor_url,omitempty" example:"https://www.netflix.com"`
+}
+
+func (r ServiceRequest) toInput() service.ServiceInput {
+	return service.ServiceInput{
+		Name:         r.Name,
+		Aliases:      r.Aliases,
+		Category:     r.Category,
+		DefaultPrice: r.DefaultPrice,
+		Currency:     r.Currency,
+		VendorURL:    r.VendorURL,
+	}
+}
+
+type ServicesResponse struct {
+	Items []model.Service `json:"items"`
+}
+
+// CreateService godoc
+// @Summary Добавить сервис в каталог
+// @Description Добавляет сервис в каталог. Название и синонимы не зависят от регистра и должны быть уникальны среди всех сервисов каталога. Доступно только администраторам.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param service body ServiceRequest true "Данные сервиса"
+// @Success 201 {object} model.Service
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /services [post]
+func (h *SubscriptionHandler) CreateService(c *gin.Context) {
+	log.Printf("[HANDLER] Creating catalog service")
+
+	var req ServiceRequest
+	if err := bindJSON(c, &req); err != nil {
+		log.Printf("[ERROR] Invalid service request: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	svc, err := h.Service.CreateService(c.Request.Context(), req.toInput())
+	if err != nil {
+		log.Printf("[ERROR] Failed to create catalog service: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	log.Printf("[SUCCESS] Catalog service created with ID: %s", svc.ID)
+	c.JSON(http.StatusCreated, svc)
+}
+
+// ListServices godoc
+// @Summary Список сервисов каталога
+// @Description Возвращает сервисы каталога, упорядоченные по названию
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param category query string false "Категория сервиса"
+// @Success 200 {object} ServicesResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /services [get]
+func (h *SubscriptionHandler) ListServices(c *gin.Context) {
+	category := c.Query("category")
+	log.Printf("[HANDLER] Listing catalog services, category: %q", category)
+
+	services, err := h.Service.ListServices(c.Request.Context(), category)
+	if err != nil {
+		log.Printf("[ERROR] Failed to list catalog services: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	log.Printf("[SUCCESS] Retrieved %d catalog services", len(services))
+	c.JSON(http.StatusOK, ServicesResponse{Items: services})
+}
+
+// GetService godoc
+// @Summary Получить сервис каталога
+// @Description Возвращает сервис каталога по ID
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сервиса"
+// @Success 200 {object} model.Service
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /services/{id} [get]
+func (h *SubscriptionHandler) GetService(c *gin.Context) {
+	id := c.Param("id")
+	log.Printf("[HANDLER] Getting catalog service with ID: %s", id)
+
+	svc, err := h.Service.GetService(c.Request.Context(), id)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get catalog service %s: %v", id, err)
+		_ = c.Error(err)
+		return
+	}
+
+	log.Printf("[SUCCESS] Retrieved catalog service with ID: %s", id)
+	c.JSON(http.StatusOK, svc)
+}
+
+// UpdateService godoc
+// @Summary Обновить сервис каталога
+// @Description Полностью заменяет данные сервиса. Уже созданные подписки сохраняют свои service_name и цену. Доступно только администраторам.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сервиса"
+// @Param service body ServiceRequest true "Новые данные сервиса"
+// @Success 200 {object} model.Service
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /services/{id} [put]
+func (h *SubscriptionHandler) UpdateService(c *gin.Context) {
+	id := c.Param("id")
+	log.Printf("[HANDLER] Updating catalog service with ID: %s", id)
+
+	var req ServiceRequest
+	if err := bindJSON(c, &req); err != nil {
+		log.Printf("[ERROR] Invalid service request: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	svc, err := h.Service.UpdateService(c.Request.Context(), id, req.toInput())
+	if err != nil {
+		log.Printf("[ERROR] Failed to update catalog service %s: %v", id, err)
+		_ = c.Error(err)
+		return
+	}
+
+	log.Printf("[SUCCESS] Updated catalog service with ID: %s", id)
+	c.JSON(http.StatusOK, svc)
+}
+
+// DeleteService godoc
+// @Summary Удалить сервис из каталога
+// @Description Удаляет сервис из каталога. Подписки сервиса остаются и отвязываются от каталога: service_id становится пустым, service_name сохраняется. Доступно только администраторам.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path string true "ID сервиса"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /services/{id} [delete]
+func (h *SubscriptionHandler) DeleteService(c *gin.Context) {
+	id := c.Param("id")
+	log.Printf("[HANDLER] Deleting catalog service with ID: %s", id)
+
+	if err := h.Service.DeleteService(c.Request.Context(), id); err != nil {
+		log.Printf("[ERROR] Failed to delete catalog service %s: %v", id, err)
+		_ = c.Error(err)
+		return
+	}
+
+	log.Printf("[SUCCESS] Deleted catalog service with ID: %s", id)
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// errIfMatchRequired — изменяющий запрос пришёл без заголовка If-Match, хотя он обязателен
+var errIfMatchRequired = errors.New("If-Match header is required")
+
+// formatETag представляет версию подписки как сильный ETag
+func formatETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// setETag возвращает версию подписки в заголовке ETag
+func setETag(c *gin.Context, sub *model.Subscription) {
+	c.Header("ETag", formatETag(sub.Version))
+}
+
+// ifMatchVersion читает ожидаемую версию из If-Match.
+// 0 означает «любая версия»: заголовок равен * или отсутствует, когда он не обязателен.
+func (h *SubscriptionHandler) ifMatchVersion(c *gin.Context) (int64, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	switch header {
+	case "":
+		if h.RequireIfMatch {
+			return 0, errIfMatchRequired
+		}
+		return 0, nil
+	case "*":
+		return 0, nil
+	}
+
+	// Слабые ETag для If-Match не подходят, принимаем только "N"
+	version, err := strconv.ParseInt(strings.Trim(header, `"`), 10, 64)
+	if err != nil || version <= 0 || !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) {
+		return 0, service.NewValidationError("If-Match", `If-Match must be an ETag like "3" or *`)
+	}
+	return version, nil
+}
@@ -0,0 +1,288 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/service"
+	"github.com/Headliner38/Subscription_Service/internal/xlsx"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Форматы ответа списка и отчёта о стоимости
+const (
+	formatJSON   = "json"
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+	formatXLSX   = "xlsx"
+
+	csvContentType    = "text/csv"
+	ndjsonContentType = "application/x-ndjson"
+
+	// exportFlushRows — через сколько строк отправлять накопленный ответ клиенту
+	exportFlushRows = 100
+)
+
+// Колонки выгрузок в фиксированном порядке
+var (
+	subscriptionExportColumns = []string{"id", "service_id", "service_name", "price", "currency", "billing_period", "interval_count", "category", "tags", "user_id", "organization_id", "start_date", "end_date", "status", "version", "created_at", "updated_at", "deleted_at"}
+	costExportColumns         = []string{"subscription_id", "service_name", "user_id", "price", "currency", "billing_period", "interval_count", "from", "to", "months", "paused_months", "cost", "cost_currency"}
+)
+
+// exportFormat выбирает формат ответа: параметр format важнее заголовка Accept.
+// Если Accept не подходит ни к одному формату, отвечаем JSON, как и раньше.
+func exportFormat(c *gin.Context) (string, error) {
+	if format := strings.ToLower(c.Query("format")); format != "" {
+		switch format {
+		case formatJSON, formatCSV, formatNDJSON, formatXLSX:
+			return format, nil
+		default:
+			return "", service.NewValidationError("format", "format must be one of: json, csv, ndjson, xlsx")
+		}
+	}
+
+	switch c.NegotiateFormat(binding.MIMEJSON, csvContentType, ndjsonContentType, xlsx.ContentType) {
+	case csvContentType:
+		return formatCSV, nil
+	case ndjsonContentType:
+		return formatNDJSON, nil
+	case xlsx.ContentType:
+		return formatXLSX, nil
+	default:
+		return formatJSON, nil
+	}
+}
+
+// tableWriter пишет строки таблицы в CSV или XLSX
+type tableWriter interface {
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+// csvTableWriter пишет CSV по RFC 4180: строки разделяются CRLF, поля при необходимости берутся в кавычки
+type csvTableWriter struct {
+	writer *csv.Writer
+	record []string
+}
+
+func newCSVTableWriter(w http.ResponseWriter) *csvTableWriter {
+	writer := csv.NewWriter(w)
+	writer.UseCRLF = true
+	return &csvTableWriter{writer: writer}
+}
+
+func (w *csvTableWriter) WriteRow(values []interface{}) error {
+	w.record = w.record[:0]
+	for _, value := range values {
+		switch v := value.(type) {
+		case nil:
+			w.record = append(w.record, "")
+		case string:
+			w.record = append(w.record, v)
+		case int:
+			w.record = append(w.record, strconv.Itoa(v))
+		case int64:
+			w.record = append(w.record, strconv.FormatInt(v, 10))
+		default:
+			w.record = append(w.record, fmt.Sprint(v))
+		}
+	}
+	return w.writer.Write(w.record)
+}
+
+func (w *csvTableWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// exporter отдаёт строки выгрузки клиенту по мере их получения.
+// Заголовки ответа отправляются с первой строкой, поэтому ошибку, случившуюся до неё,
+// ещё можно вернуть обычным ErrorResponse.
+type exporter struct {
+	c        *gin.Context
+	format   string
+	filename string
+	columns  []string
+
+	started bool
+	rows    int
+	table   tableWriter
+	encoder *json.Encoder
+}
+
+func newExporter(c *gin.Context, format, name string, columns []string) *exporter {
+	return &exporter{c: c, format: format, filename: name + "." + format, columns: columns}
+}
+
+// start отправляет заголовки ответа и строку с названиями колонок
+func (e *exporter) start() error {
+	e.started = true
+	header := e.c.Writer.Header()
+	header.Set("Content-Disposition", `attachment; filename="`+e.filename+`"`)
+
+	switch e.format {
+	case formatNDJSON:
+		header.Set("Content-Type", ndjsonContentType)
+		e.c.Status(http.StatusOK)
+		e.encoder = json.NewEncoder(e.c.Writer)
+		return nil
+	case formatXLSX:
+		header.Set("Content-Type", xlsx.ContentType)
+		e.c.Status(http.StatusOK)
+		writer, err := xlsx.NewWriter(e.c.Writer)
+		if err != nil {
+			return err
+		}
+		e.table = writer
+	default:
+		header.Set("Content-Type", csvContentType+"; charset=utf-8")
+		e.c.Status(http.StatusOK)
+		e.table = newCSVTableWriter(e.c.Writer)
+	}
+
+	columns := make([]interface{}, len(e.columns))
+	for i, column := range e.columns {
+		columns[i] = column
+	}
+	return e.table.WriteRow(columns)
+}
+
+// write отдаёт одну строку: объект целиком для NDJSON или значения колонок для CSV и XLSX
+func (e *exporter) write(item interface{}, row []interface{}) error {
+	if !e.started {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if e.encoder != nil {
+		err = e.encoder.Encode(item)
+	} else {
+		err = e.table.WriteRow(row)
+	}
+	if err != nil {
+		return err
+	}
+
+	e.rows++
+	if e.rows%exportFlushRows == 0 && e.format != formatXLSX {
+		if csvWriter, ok := e.table.(*csvTableWriter); ok {
+			csvWriter.writer.Flush()
+		}
+		e.c.Writer.Flush()
+	}
+	return nil
+}
+
+// finish завершает выгрузку. Ошибку до первой строки возвращает клиенту как обычно;
+// после начала ответа статус уже не изменить, поэтому выгрузка просто обрывается.
+func (e *exporter) finish(err error) {
+	if err != nil {
+		if !e.started {
+			_ = e.c.Error(err)
+			return
+		}
+		log.Printf("[ERROR] Export of %s interrupted after %d rows: %v", e.filename, e.rows, err)
+		e.c.Abort()
+		return
+	}
+
+	if !e.started {
+		if err := e.start(); err != nil {
+			log.Printf("[ERROR] Failed to start export of %s: %v", e.filename, err)
+			return
+		}
+	}
+	if e.table != nil {
+		if err := e.table.Close(); err != nil {
+			log.Printf("[ERROR] Failed to finish export of %s: %v", e.filename, err)
+			return
+		}
+	}
+	log.Printf("[SUCCESS] Exported %d rows to %s", e.rows, e.filename)
+}
+
+// exportSubscriptions отдаёт подписки по параметрам списка в формате format
+func (h *SubscriptionHandler) exportSubscriptions(c *gin.Context, params service.ListSubscriptionsParams, format string) {
+	exp := newExporter(c, format, "subscriptions", subscriptionExportColumns)
+	err := h.scopedService(c).ExportSubscriptions(c.Request.Context(), params, func(sub model.Subscription) error {
+		return exp.write(sub, subscriptionExportRow(sub))
+	})
+	exp.finish(err)
+}
+
+// exportCosts отдаёт стоимость подписок за период в формате format
+func (h *SubscriptionHandler) exportCosts(c *gin.Context, params service.TotalCostParams, format string) {
+	exp := newExporter(c, format, "subscription-costs", costExportColumns)
+	err := h.scopedService(c).ExportCosts(c.Request.Context(), params, func(item model.SubscriptionCost) error {
+		return exp.write(item, costExportRow(item))
+	})
+	exp.finish(err)
+}
+
+// subscriptionExportRow возвращает значения колонок subscriptionExportColumns.
+// Даты начала и окончания — в формате YYYY-MM-DD, который принимают создание и импорт.
+func subscriptionExportRow(sub model.Subscription) []interface{} {
+	return []interface{}{
+		sub.ID,
+		formatOptionalString(sub.ServiceID),
+		sub.ServiceName,
+		sub.Price,
+		sub.Currency,
+		sub.BillingPeriod,
+		sub.IntervalCount,
+		sub.Category,
+		strings.Join(sub.Tags, ","),
+		sub.UserID,
+		sub.OrganizationID,
+		sub.StartDate.Format("2006-01-02"),
+		formatOptionalTime(sub.EndDate, "2006-01-02"),
+		sub.Status,
+		sub.Version,
+		sub.CreatedAt.Format(time.RFC3339),
+		sub.UpdatedAt.Format(time.RFC3339),
+		formatOptionalTime(sub.DeletedAt, time.RFC3339),
+	}
+}
+
+// costExportRow возвращает значения колонок costExportColumns
+func costExportRow(item model.SubscriptionCost) []interface{} {
+	return []interface{}{
+		item.SubscriptionID,
+		item.ServiceName,
+		item.UserID,
+		item.Price,
+		item.Currency,
+		item.BillingPeriod,
+		item.IntervalCount,
+		item.From,
+		item.To,
+		item.Months,
+		item.PausedMonths,
+		item.Cost,
+		item.CostCurrency,
+	}
+}
+
+func formatOptionalString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func formatOptionalTime(t *time.Time, layout string) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(layout)
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCSVTableWriterEscaping(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []interface{}
+		want   string
+	}{
+		{"plain", []interface{}{"Netflix", 10000, int64(3), nil}, "Netflix,10000,3,\r\n"},
+		{"comma", []interface{}{"Netflix, Premium"}, "\"Netflix, Premium\"\r\n"},
+		{"quote", []interface{}{`Netflix "HD"`}, "\"Netflix \"\"HD\"\"\"\r\n"},
+		// С UseCRLF перевод строки внутри поля тоже записывается как CRLF
+		{"line break", []interface{}{"Netflix\nPremium", "x"}, "\"Netflix\r\nPremium\",x\r\n"},
+		{"leading space", []interface{}{" Netflix"}, "\" Netflix\"\r\n"},
+		{"empty string", []interface{}{"", ""}, ",\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			writer := newCSVTableWriter(w)
+			if err := writer.WriteRow(tt.values); err != nil {
+				t.Fatalf("WriteRow: %v", err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestExportSubscriptionsCSVRoundTrip(t *testing.T) {
+	api := newTestAPI(Options{}, false)
+	serviceName := "Netflix, \"Premium\"\nFamily"
+	body := `{"service_name":"Netflix, \"Premium\"\nFamily","price":10000,"user_id":"` + testUserID + `","start_date":"01-2024","tags":["family","video"]}`
+	if w := api.do(http.MethodPost, "/subscriptions/", body, nil); w.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := api.do(http.MethodGet, "/subscriptions/?format=csv", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("export: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), csvContentType) {
+		t.Errorf("expected CSV content type, got %q", w.Header().Get("Content-Type"))
+	}
+
+	// Перевод строки внутри поля остаётся в кавычках, а записи разделяются CRLF
+	records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v\n%s", err, w.Body.String())
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected header and one row, got %d records", len(records))
+	}
+	if !reflect.DeepEqual(records[0], subscriptionExportColumns) {
+		t.Errorf("expected columns %v, got %v", subscriptionExportColumns, records[0])
+	}
+	row := map[string]string{}
+	for i, column := range records[0] {
+		row[column] = records[1][i]
+	}
+	if row["service_name"] != serviceName || row["tags"] != "family,video" || row["price"] != "10000" {
+		t.Errorf("unexpected row %v", row)
+	}
+	if !strings.HasPrefix(w.Body.String(), strings.Join(subscriptionExportColumns, ",")+"\r\n") || !strings.HasSuffix(w.Body.String(), "\r\n") {
+		t.Errorf("expected CRLF after each record, got %q", w.Body.String())
+	}
+}
@@ -0,0 +1,249 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Headliner38/Subscription_Service/internal/service"
+	"github.com/Headliner38/Subscription_Service/internal/xlsx"
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportSize ограничивает размер импортируемого файла
+const maxImportSize = 100 << 20
+
+var (
+	// errUnsupportedImportFormat — формат файла не удалось определить или он не поддерживается
+	errUnsupportedImportFormat = errors.New("file format must be csv or xlsx")
+	// errPayloadTooLarge — тело запроса больше допустимого
+	errPayloadTooLarge = errors.New("file is too large")
+)
+
+// ImportSubscriptions godoc
+// @Summary Импорт подписок из CSV или XLSX
+// @Description Импортирует подписки из файла с заголовком service_name, price, currency, user_id, start_date, end_date (порядок колонок любой, лишние колонки игнорируются). Файл передаётся телом запроса (text/csv или XLSX) либо полем file в multipart/form-data. Строки проверяются по тем же правилам, что и при создании; дубликаты (тот же пользователь, сервис и дата начала; 01-2024 равно 2024-01-01, а 2024-01-15 — другая дата) пропускаются. С dry_run=true ничего не сохраняется, а ответ содержит ошибки по строкам. Строки сохраняются пачками: если чтение файла или запись прервались после сохранения части строк, ответ с кодом ошибки (413, 503, ...) содержит итог импорта с aborted=true и committed_through_row — номером последней сохранённой строки; повторять импорт нужно со следующей строки.
+// @Tags subscriptions
+// @Accept text/csv
+// @Accept application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Accept multipart/form-data
+// @Produce json
+// @Param format query string false "Формат файла, если его нельзя определить по Content-Type или имени файла" Enums(csv, xlsx)
+// @Param dry_run query bool false "Только проверить файл, ничего не сохраняя"
+// @Param X-Tenant-ID header string false "ID организации запроса; по умолчанию организация ключа или токена"
+// @Success 200 {object} model.ImportResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /subscriptions/import [post]
+func (h *SubscriptionHandler) ImportSubscriptions(c *gin.Context) {
+	log.Printf("[HANDLER] Importing subscriptions")
+
+	dryRun, err := queryBool(c, "dry_run")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	body, format, err := importSource(c)
+	if err != nil {
+		log.Printf("[ERROR] Invalid import request: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	var rows service.RowReader
+	switch format {
+	case formatCSV:
+		rows = newCSVRowReader(body)
+	case formatXLSX:
+		sheet, cleanup, err := openXLSX(body)
+		if err != nil {
+			log.Printf("[ERROR] Failed to open XLSX for import: %v", err)
+			_ = c.Error(importError(err))
+			return
+		}
+		defer cleanup()
+		rows = sheet
+	}
+
+	result, err := h.scopedService(c).ImportSubscriptions(c.Request.Context(), rows, dryRun)
+	if err != nil {
+		log.Printf("[ERROR] Import failed: %v", err)
+		_ = c.Error(importError(err))
+		return
+	}
+
+	log.Printf("[SUCCESS] Imported %d of %d rows (dry run: %t)", result.Imported, result.Rows, dryRun)
+	c.JSON(http.StatusOK, result)
+}
+
+// importSource возвращает поток с содержимым файла и его формат.
+// Для multipart/form-data файл берётся из поля file без буферизации всего запроса.
+func importSource(c *gin.Context) (io.Reader, string, error) {
+	format := strings.ToLower(c.Query("format"))
+	if format != "" && format != formatCSV && format != formatXLSX {
+		return nil, "", service.NewValidationError("format", "format must be one of: csv, xlsx")
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+	if mediaType != "multipart/form-data" {
+		if format == "" {
+			format = formatByContentType(mediaType)
+		}
+		if format == "" {
+			return nil, "", errUnsupportedImportFormat
+		}
+		return c.Request.Body, format, nil
+	}
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, "", &bindingError{err: err}
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, "", service.NewValidationError("file", "file is required")
+		}
+		if err != nil {
+			return nil, "", importError(&bindingError{err: err})
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+
+		if format == "" {
+			switch strings.ToLower(filepath.Ext(part.FileName())) {
+			case ".csv":
+				format = formatCSV
+			case ".xlsx":
+				format = formatXLSX
+			default:
+				partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+				format = formatByContentType(partType)
+			}
+		}
+		if format == "" {
+			return nil, "", errUnsupportedImportFormat
+		}
+		return part, format, nil
+	}
+}
+
+// formatByContentType определяет формат файла по MIME-типу
+func formatByContentType(mediaType string) string {
+	switch mediaType {
+	case csvContentType, "application/csv":
+		return formatCSV
+	case xlsx.ContentType:
+		return formatXLSX
+	default:
+		return ""
+	}
+}
+
+// importError заменяет превышение размера тела на errPayloadTooLarge; у прерванного импорта
+// заменяется причина, а итог по сохранённым строкам остаётся
+func importError(err error) error {
+	var abortedErr *service.ImportAbortedError
+	if errors.As(err, &abortedErr) {
+		abortedErr.Err = importError(abortedErr.Err)
+		return abortedErr
+	}
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return errPayloadTooLarge
+	}
+	return err
+}
+
+// csvRowReader читает CSV построчно; испорченные строки превращаются в ошибки валидации
+type csvRowReader struct {
+	reader *csv.Reader
+}
+
+// newCSVRowReader создаёт читатель CSV. Разделитель (запятая или точка с запятой,
+// как в выгрузках Excel с русской локалью) определяется по первой строке.
+func newCSVRowReader(r io.Reader) *csvRowReader {
+	buffered := bufio.NewReader(r)
+	firstLine, _ := buffered.Peek(4096)
+	if i := bytes.IndexByte(firstLine, '\n'); i >= 0 {
+		firstLine = firstLine[:i]
+	}
+
+	reader := csv.NewReader(buffered)
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	return &csvRowReader{reader: reader}
+}
+
+func (r *csvRowReader) Read() ([]string, error) {
+	record, err := r.reader.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return nil, service.NewValidationError("file", parseErr.Err.Error())
+	}
+	return record, err
+}
+
+// xlsxRowReader читает лист XLSX; ошибки разбора превращаются в ошибки валидации
+type xlsxRowReader struct {
+	reader *xlsx.Reader
+}
+
+func (r *xlsxRowReader) Read() ([]string, error) {
+	record, err := r.reader.Read()
+	if err != nil && err != io.EOF {
+		return nil, service.NewValidationError("file", err.Error())
+	}
+	return record, err
+}
+
+// openXLSX сохраняет книгу во временный файл (ZIP требует произвольного доступа)
+// и открывает её первый лист. cleanup закрывает и удаляет файл.
+func openXLSX(body io.Reader) (*xlsxRowReader, func(), error) {
+	tmp, err := os.CreateTemp("", "subscriptions-import-*.xlsx")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+
+	size, err := io.Copy(tmp, body)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	reader, err := xlsx.NewReader(tmp, size)
+	if err != nil {
+		cleanup()
+		return nil, nil, service.NewValidationError("file", "file is not a valid XLSX workbook")
+	}
+
+	return &xlsxRowReader{reader: reader}, func() {
+		reader.Close()
+		cleanup()
+	}, nil
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+func TestImportAbortedResponse(t *testing.T) {
+	tests := []struct {
+		name   string
+		cause  error
+		status int
+	}{
+		{"body too large", &http.MaxBytesError{Limit: maxImportSize}, http.StatusRequestEntityTooLarge},
+		{"storage unavailable", service.ErrUnavailable, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(ErrorMiddleware())
+			router.POST("/import", func(c *gin.Context) {
+				result := &model.ImportResult{Rows: 600, Valid: 600, Imported: 500, Aborted: true, CommittedThroughRow: 501}
+				_ = c.Error(importError(&service.ImportAbortedError{Result: result, Err: tt.cause}))
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/import", nil))
+			if w.Code != tt.status {
+				t.Fatalf("expected %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+
+			// Клиент получает итог по сохранённым строкам, а не только ошибку
+			var result model.ImportResult
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !result.Aborted || result.Imported != 500 || result.CommittedThroughRow != 501 || result.AbortReason == "" {
+				t.Errorf("expected partial import result with abort reason, got %+v", result)
+			}
+		})
+	}
+}
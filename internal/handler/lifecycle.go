@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CancelSubscription godoc
+// @Summary Отменить подписку
+// @Description Переводит активную или приостановленную подписку в статус cancelled. Если end_date не задан или позже текущего месяца, он сдвигается на текущий месяц.
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Param X-Tenant-ID header string false "ID организации запроса; по умолчанию организация ключа или токена"
+// @Success 200 {object} model.Subscription
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /subscriptions/{id}/cancel [post]
+func (h *SubscriptionHandler) CancelSubscription(c *gin.Context) {
+	id := c.Param("id")
+	log.Printf("[HANDLER] Cancelling subscription with ID: %s", id)
+
+	sub, err := h.scopedService(c).CancelSubscription(c.Request.Context(), id)
+	if err != nil {
+		log.Printf("[ERROR] Failed to cancel subscription %s: %v", id, err)
+		_ = c.Error(err)
+		return
+	}
+
+	log.Printf("[SUCCESS] Cancelled subscription with ID: %s", id)
+	setETag(c, sub)
+	c.JSON(http.StatusOK, sub)
+}
+
+// PauseSubscription godoc
+// @Summary Приостановить подписку
+// @Description Переводит активную подписку в статус paused. Циклы оплаты, в день списания которых подписка на паузе, не учитываются в стоимости.
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Param X-Tenant-ID header string false "ID организации запроса; по умолчанию организация ключа или токена"
+// @Success 200 {object} model.Subscription
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /subscriptions/{id}/pause [post]
+func (h *SubscriptionHandler) PauseSubscription(c *gin.Context) {
+	id := c.Param("id")
+	log.Printf("[HANDLER] Pausing subscription with ID: %s", id)
+
+	sub, err := h.scopedService(c).PauseSubscription(c.Request.Context(), id)
+	if err != nil {
+		log.Printf("[ERROR] Failed to pause subscription %s: %v", id, err)
+		_ = c.Error(err)
+		return
+	}
+
+	log.Printf("[SUCCESS] Paused subscription with ID: %s", id)
+	setETag(c, sub)
+	c.JSON(http.StatusOK, sub)
+}
+
+// ResumeSubscription godoc
+// @Summary Возобновить подписку
+// @Description Возвращает приостановленную подписку в статус active
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Param X-Tenant-ID header string false "ID организации запроса; по умолчанию организация ключа или токена"
+// @Success 200 {object} model.Subscription
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /subscriptions/{id}/resume [post]
+func (h *SubscriptionHandler) ResumeSubscription(c *gin.Context) {
+	id := c.Param("id")
+	log.Printf("[HANDLER] Resuming subscription with ID: %s", id)
+
+	sub, err := h.scopedService(c).ResumeSubscription(c.Request.Context(), id)
+	if err != nil {
+		log.Printf("[ERROR] Failed to resume subscription %s: %v", id, err)
+		_ = c.Error(err)
+		return
+	}
+
+	log.Printf("[SUCCESS] Resumed subscription with ID: %s", id)
+	setETag(c, sub)
+	c.JSON(http.StatusOK, sub)
+}
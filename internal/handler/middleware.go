@@ -1,23 +1,145 @@
 package handler
 
 import (
+	"errors"
 	"log"
+	"net/http"
 
+	"github.com/Headliner38/Subscription_Service/internal/auth"
+	"github.com/Headliner38/Subscription_Service/internal/service"
+	"github.com/Headliner38/Subscription_Service/internal/utils"
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	requestIDHeader = "X-Request-ID"
+	requestIDKey    = "request_id"
+	// maxRequestIDLength ограничивает длину ID запроса, принятого от клиента
+	maxRequestIDLength = 128
+)
+
 // LoggerMiddleware логирует все HTTP-запросы
 func LoggerMiddleware() gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
 		// Логируем запрос с временем выполнения
-		log.Printf("[HTTP] %s | %s | %d | %v | %s | %s",
+		log.Printf("[HTTP] %s | %s | %d | %v | %s | %v | %s",
 			param.Method,
 			param.Path,
 			param.StatusCode,
 			param.Latency,
 			param.ClientIP,
+			param.Keys[requestIDKey],
 			param.ErrorMessage,
 		)
 		return ""
 	})
 }
+
+// RequestIDMiddleware присваивает запросу ID (или берёт его из заголовка X-Request-ID)
+// и возвращает его в ответе. Пустой, слишком длинный или содержащий непечатные символы
+// ID клиента заменяется сгенерированным: он попадает в логи и журнал аудита.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = utils.GenerateUUID()
+		}
+		c.Set(requestIDKey, requestID)
+		c.Header(requestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// validRequestID проверяет, что ID запроса непустой, не длиннее maxRequestIDLength
+// и состоит только из печатных ASCII-символов
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x20 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// ErrorMiddleware превращает ошибку, добавленную обработчиком через c.Error,
+// в ErrorResponse с подходящим статус-кодом. Детали ошибок хранилища клиенту не отдаются.
+func ErrorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+
+		// При несовпадении версии клиент получает актуальное состояние подписки
+		var preconditionErr *service.PreconditionFailedError
+		if errors.As(err, &preconditionErr) {
+			setETag(c, preconditionErr.Current)
+			c.JSON(http.StatusPreconditionFailed, preconditionErr.Current)
+			return
+		}
+
+		// Прерванный импорт отвечает статусом причины сбоя и итогом по уже сохранённым строкам
+		var abortedErr *service.ImportAbortedError
+		if errors.As(err, &abortedErr) {
+			status, resp := translateError(abortedErr.Err)
+			if status == http.StatusInternalServerError || status == http.StatusServiceUnavailable {
+				log.Printf("[ERROR] Request %s failed: %v", c.GetString(requestIDKey), err)
+			}
+			abortedErr.Result.AbortReason = resp.Error
+			c.JSON(status, abortedErr.Result)
+			return
+		}
+
+		status, resp := translateError(err)
+		resp.RequestID = c.GetString(requestIDKey)
+		if status == http.StatusInternalServerError || status == http.StatusServiceUnavailable {
+			log.Printf("[ERROR] Request %s failed: %v", resp.RequestID, err)
+		}
+		c.JSON(status, resp)
+	}
+}
+
+// translateError сопоставляет ошибку сервиса со статус-кодом и телом ответа
+func translateError(err error) (int, ErrorResponse) {
+	var validationErr *service.ValidationError
+	var bindErr *bindingError
+
+	switch {
+	case errors.As(err, &validationErr):
+		return http.StatusBadRequest, ErrorResponse{
+			Error:   validationErr.Error(),
+			Code:    "validation_error",
+			Details: validationErr.Fields,
+		}
+	case errors.As(err, &bindErr):
+		return http.StatusBadRequest, ErrorResponse{Error: "invalid request body", Code: "bad_request"}
+	case errors.Is(err, auth.ErrNoCredentials), errors.Is(err, auth.ErrInvalidCredentials):
+		return http.StatusUnauthorized, ErrorResponse{Error: err.Error(), Code: "unauthorized"}
+	case errors.Is(err, errForbidden):
+		return http.StatusForbidden, ErrorResponse{Error: err.Error(), Code: "forbidden"}
+	case errors.Is(err, service.ErrNotFound):
+		return http.StatusNotFound, ErrorResponse{Error: err.Error(), Code: "not_found"}
+	case errors.Is(err, service.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed, ErrorResponse{Error: err.Error(), Code: "precondition_failed"}
+	case errors.Is(err, errUnsupportedMediaType), errors.Is(err, errUnsupportedImportFormat):
+		return http.StatusUnsupportedMediaType, ErrorResponse{Error: err.Error(), Code: "unsupported_media_type"}
+	case errors.Is(err, errPayloadTooLarge):
+		return http.StatusRequestEntityTooLarge, ErrorResponse{Error: err.Error(), Code: "payload_too_large"}
+	case errors.Is(err, errIfMatchRequired):
+		return http.StatusPreconditionRequired, ErrorResponse{Error: err.Error(), Code: "precondition_required"}
+	case errors.Is(err, errRateLimited):
+		return http.StatusTooManyRequests, ErrorResponse{Error: err.Error(), Code: "rate_limited"}
+	case errors.Is(err, service.ErrConflict):
+		return http.StatusConflict, ErrorResponse{Error: err.Error(), Code: "conflict"}
+	case errors.Is(err, service.ErrUnavailable):
+		return http.StatusServiceUnavailable, ErrorResponse{Error: "service temporarily unavailable", Code: "unavailable"}
+	default:
+		return http.StatusInternalServerError, ErrorResponse{Error: "internal server error", Code: "internal_error"}
+	}
+}
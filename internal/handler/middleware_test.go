@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Headliner38/Subscription_Service/internal/utils"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		keep      bool
+	}{
+		{"client ID", "trace-42", true},
+		{"longest allowed", strings.Repeat("a", maxRequestIDLength), true},
+		{"missing", "", false},
+		{"too long", strings.Repeat("a", maxRequestIDLength+1), false},
+		{"control character", "trace\x0142", false},
+		{"non-ASCII", "трасса", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(Options{}, false)
+			var headers map[string]string
+			if tt.requestID != "" {
+				headers = map[string]string{requestIDHeader: tt.requestID}
+			}
+
+			got := api.do(http.MethodGet, "/subscriptions/", "", headers).Header().Get(requestIDHeader)
+			if tt.keep && got != tt.requestID {
+				t.Errorf("expected request ID %q, got %q", tt.requestID, got)
+			}
+			if !tt.keep && !utils.IsValidUUID(got) {
+				t.Errorf("expected generated UUID, got %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"mime"
+	"net/http"
+	"sort"
+
+	"github.com/Headliner38/Subscription_Service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+const mergePatchContentType = "application/merge-patch+json"
+
+// errUnsupportedMediaType — тело PATCH передано не как JSON Merge Patch
+var errUnsupportedMediaType = errors.New("content type must be " + mergePatchContentType)
+
+// PatchSubscriptionRequest описывает документ JSON Merge Patch (RFC 7396).
+// Отсутствующие поля не меняются, null в end_date убирает дату окончания, в category и tags — категорию и теги.
+type PatchSubscriptionRequest struct {
+	ServiceID     *string   `json:"service_id,omitempty" example:"7a1f3c2e-5b4d-4e8f-9a6b-1c2d3e4f5a6b"`
+	ServiceName   *string   `json:"service_name,omitempty" example:"Netflix"`
+	Price         *int      `json:"price,omitempty" example:"99900"`
+	Currency      *string   `json:"currency,omitempty" example:"USD"`
+	BillingPeriod *string   `json:"billing_period,omitempty" example:"yearly" enums:"weekly,monthly,quarterly,yearly"`
+	IntervalCount *int      `json:"interval_count,omitempty" example:"1"`
+	Category      *string   `json:"category,omitempty" example:"streaming"`
+	Tags          *[]string `json:"tags,omitempty" example:"family,work"`
+	UserID        *string   `json:"user_id,omitempty" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	StartDate     *string   `json:"start_date,omitempty" example:"01-2024"`
+	EndDate       *string   `json:"end_date,omitempty" example:"12-2024"`
+}
+
+// PatchSubscription godoc
+// @Summary Частично обновить подписку
+// @Description Применяет JSON Merge Patch (RFC 7396): отсутствующие поля не меняются, "end_date": null убирает дату окончания, "category": null и "tags": null — категорию и теги. tags заменяет список тегов целиком. Результат проверяется по тем же правилам, что и при PUT.
+// @Tags subscriptions
+// @Accept application/merge-patch+json
+// @Accept json
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Param If-Match header string false "ETag подписки из предыдущего ответа, например \"3\", или *"
+// @Param patch body PatchSubscriptionRequest true "Изменяемые поля"
+// @Param X-Tenant-ID header string false "ID организации запроса; по умолчанию организация ключа или токена"
+// @Success 200 {object} model.Subscription
+// @Header 200 {string} ETag "Версия подписки"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} model.Subscription "Версия не совпала; в теле актуальное состояние"
+// @Failure 415 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /subscriptions/{id} [patch]
+func (h *SubscriptionHandler) PatchSubscription(c *gin.Context) {
+	id := c.Param("id")
+	log.Printf("[HANDLER] Patching subscription with ID: %s", id)
+
+	if mediaType, _, _ := mime.ParseMediaType(c.ContentType()); mediaType != mergePatchContentType && mediaType != "application/json" {
+		log.Printf("[ERROR] Unsupported content type for patch: %s", c.ContentType())
+		_ = c.Error(errUnsupportedMediaType)
+		return
+	}
+
+	version, err := h.ifMatchVersion(c)
+	if err != nil {
+		log.Printf("[ERROR] Invalid If-Match for patch: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	patch, err := decodeMergePatch(c)
+	if err != nil {
+		log.Printf("[ERROR] Invalid patch document: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	sub, err := h.scopedService(c).PatchSubscription(c.Request.Context(), id, patch, version)
+	if err != nil {
+		log.Printf("[ERROR] Failed to patch subscription %s: %v", id, err)
+		_ = c.Error(err)
+		return
+	}
+
+	log.Printf("[SUCCESS] Patched subscription with ID: %s", id)
+	setETag(c, sub)
+	c.JSON(http.StatusOK, sub)
+}
+
+// decodeMergePatch разбирает тело запроса в service.SubscriptionPatch.
+// Документ должен быть JSON-объектом; неизвестные поля и null в обязательных полях отклоняются.
+func decodeMergePatch(c *gin.Context) (service.SubscriptionPatch, error) {
+	var patch service.SubscriptionPatch
+
+	var doc map[string]json.RawMessage
+	if err := json.NewDecoder(c.Request.Body).Decode(&doc); err != nil {
+		return patch, &bindingError{err: err}
+	}
+	if doc == nil {
+		return patch, &bindingError{err: errors.New("merge patch must be a JSON object")}
+	}
+
+	// Обходим поля в фиксированном порядке, чтобы ошибки выводились стабильно
+	fields := make([]string, 0, len(doc))
+	for field := range doc {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	result := &service.ValidationError{}
+	for _, field := range fields {
+		raw := doc[field]
+		var err error
+
+		switch field {
+		case "service_id":
+			patch.ServiceID = new(string)
+			err = decodePatchValue(field, raw, patch.ServiceID)
+		case "service_name":
+			patch.ServiceName = new(string)
+			err = decodePatchValue(field, raw, patch.ServiceName)
+		case "price":
+			patch.Price = new(int)
+			err = decodePatchValue(field, raw, patch.Price)
+		case "currency":
+			patch.Currency = new(string)
+			err = decodePatchValue(field, raw, patch.Currency)
+		case "billing_period":
+			patch.BillingPeriod = new(string)
+			err = decodePatchValue(field, raw, patch.BillingPeriod)
+		case "interval_count":
+			patch.IntervalCount = new(int)
+			err = decodePatchValue(field, raw, patch.IntervalCount)
+		case "category":
+			patch.Category = new(string)
+			if string(raw) != "null" {
+				err = decodePatchValue(field, raw, patch.Category)
+			}
+		case "tags":
+			patch.Tags = &[]string{}
+			if string(raw) != "null" {
+				err = decodePatchValue(field, raw, patch.Tags)
+			}
+		case "user_id":
+			patch.UserID = new(string)
+			err = decodePatchValue(field, raw, patch.UserID)
+		case "start_date":
+			patch.StartDate = new(string)
+			err = decodePatchValue(field, raw, patch.StartDate)
+		case "end_date":
+			patch.EndDateSet = true
+			if string(raw) != "null" {
+				patch.EndDate = new(string)
+				err = decodePatchValue(field, raw, patch.EndDate)
+			}
+		default:
+			err = errors.New(field + " cannot be changed")
+		}
+
+		if err != nil {
+			result.Fields = append(result.Fields, service.FieldError{Field: field, Message: err.Error()})
+		}
+	}
+
+	if len(result.Fields) > 0 {
+		return patch, result
+	}
+	return patch, nil
+}
+
+// decodePatchValue читает значение поля патча в dest; null допустим только для end_date, category и tags
+func decodePatchValue(field string, raw json.RawMessage, dest interface{}) error {
+	if string(raw) == "null" {
+		return errors.New(field + " cannot be null")
+	}
+	if err := json.Unmarshal(raw, dest); err != nil {
+		return errors.New(field + " has invalid type")
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// PriceChangeRequest — новая цена в теле PUT
+type PriceChangeRequest struct {
+	Price int `json:"price" example:"119900" binding:"required,gt=0"` // в минимальных единицах валюты подписки
+}
+
+// PriceHistoryResponse — начальная цена подписки и её изменения
+type PriceHistoryResponse struct {
+	SubscriptionID string              `json:"subscription_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Currency       string              `json:"currency" example:"RUB"`
+	InitialPrice   int                 `json:"initial_price" example:"99900"` // действует со start_date до первого изменения
+	Items          []model.PriceChange `json:"items"`
+}
+
+// ListPriceChanges godoc
+// @Summary История цен подписки
+// @Description Возвращает начальную цену подписки и её изменения, упорядоченные по дате. Каждая цена действует со своей даты до следующего изменения.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Param X-Tenant-ID header string false "ID организации запроса; по умолчанию организация ключа или токена"
+// @Success 200 {object} PriceHistoryResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /subscriptions/{id}/prices [get]
+func (h *SubscriptionHandler) ListPriceChanges(c *gin.Context) {
+	id := c.Param("id")
+	log.Printf("[HANDLER] Listing price changes of subscription %s", id)
+
+	sub, changes, err := h.scopedService(c).ListPriceChanges(c.Request.Context(), id)
+	if err != nil {
+		log.Printf("[ERROR] Failed to list price changes of %s: %v", id, err)
+		_ = c.Error(err)
+		return
+	}
+
+	log.Printf("[SUCCESS] Retrieved %d price changes of subscription %s", len(changes), id)
+	c.JSON(http.StatusOK, PriceHistoryResponse{
+		SubscriptionID: sub.ID,
+		Currency:       sub.Currency,
+		InitialPrice:   sub.Price,
+		Items:          changes,
+	})
+}
+
+// SetPrice godoc
+// @Summary Изменить цену подписки с даты
+// @Description Сохраняет цену, действующую с даты date (в прошлом или будущем) до следующего изменения. Стоимость и отчёты считают каждый цикл оплаты по цене на день списания.
+// @Description Цена на ту же дату заменяется. Дата должна быть позже start_date и не позже end_date.
+// @Description Изменение цены повышает версию подписки и попадает в ленту изменений как updated; заголовок ETag ответа содержит новую версию.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Param date path string true "Дата начала действия цены (YYYY-MM-DD или MM-YYYY)"
+// @Param price body PriceChangeRequest true "Новая цена"
+// @Param If-Match header string false "ETag подписки из предыдущего ответа, например \"3\", или *"
+// @Param X-Tenant-ID header string false "ID организации запроса; по умолчанию организация ключа или токена"
+// @Success 200 {object} model.PriceChange
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} model.Subscription "Версия не совпала; в теле актуальное состояние"
+// @Failure 428 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /subscriptions/{id}/prices/{date} [put]
+func (h *SubscriptionHandler) SetPrice(c *gin.Context) {
+	id, date := c.Param("id"), c.Param("date")
+	log.Printf("[HANDLER] Setting price of subscription %s from %s", id, date)
+
+	version, err := h.ifMatchVersion(c)
+	if err != nil {
+		log.Printf("[ERROR] Invalid If-Match for price change: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	var req PriceChangeRequest
+	if err := bindJSON(c, &req); err != nil {
+		log.Printf("[ERROR] Invalid price change request: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	change, sub, err := h.scopedService(c).SetPrice(c.Request.Context(), id, date, req.Price, version)
+	if err != nil {
+		log.Printf("[ERROR] Failed to set price of %s: %v", id, err)
+		_ = c.Error(err)
+		return
+	}
+
+	log.Printf("[SUCCESS] Price of subscription %s from %s set", id, date)
+	setETag(c, sub)
+	c.JSON(http.StatusOK, change)
+}
+
+// DeletePriceChange godoc
+// @Summary Удалить изменение цены
+// @Description Удаляет изменение цены подписки на дату; с этой даты снова действует предыдущая цена.
+// @Description Версия подписки повышается, как при изменении цены; заголовок ETag ответа содержит новую версию.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Param date path string true "Дата начала действия цены (YYYY-MM-DD или MM-YYYY)"
+// @Param If-Match header string false "ETag подписки из предыдущего ответа, например \"3\", или *"
+// @Param X-Tenant-ID header string false "ID организации запроса; по умолчанию организация ключа или токена"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} model.Subscription "Версия не совпала; в теле актуальное состояние"
+// @Failure 428 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /subscriptions/{id}/prices/{date} [delete]
+func (h *SubscriptionHandler) DeletePriceChange(c *gin.Context) {
+	id, date := c.Param("id"), c.Param("date")
+	log.Printf("[HANDLER] Deleting price change of subscription %s from %s", id, date)
+
+	version, err := h.ifMatchVersion(c)
+	if err != nil {
+		log.Printf("[ERROR] Invalid If-Match for price change deletion: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	sub, err := h.scopedService(c).DeletePriceChange(c.Request.Context(), id, date, version)
+	if err != nil {
+		log.Printf("[ERROR] Failed to delete price change of %s: %v", id, err)
+		_ = c.Error(err)
+		return
+	}
+
+	log.Printf("[SUCCESS] Deleted price change of subscription %s from %s", id, date)
+	setETag(c, sub)
+	c.Status(http.StatusNoContent)
+}
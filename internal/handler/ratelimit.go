@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// errRateLimited — клиент исчерпал лимит запросов к группе маршрутов
+var errRateLimited = errors.New("rate limit exceeded")
+
+// RateLimitMiddleware ограничивает частоту запросов к группе маршрутов. Корзина своя у
+// каждого API-ключа, у каждого субъекта JWT, а без аутентификации — у каждого IP-адреса.
+// Ответ содержит заголовки RateLimit-Limit, RateLimit-Remaining и RateLimit-Reset;
+// при исчерпании лимита запрос завершается с 429 и заголовком Retry-After.
+// Если хранилище корзин недоступно, запрос пропускается.
+func RateLimitMiddleware(store ratelimit.Store, group string, limit ratelimit.Limit) gin.HandlerFunc {
+	return rateLimiter(store, "group "+group, limit, func(c *gin.Context) string {
+		return group + "|" + rateLimitKey(c)
+	})
+}
+
+// IPRateLimitMiddleware ограничивает частоту запросов с одного IP-адреса ко всем группам
+// маршрутов. Он стоит перед аутентификацией, поэтому расходуют лимит и запросы с неверными
+// ключами и токенами: перебор учётных данных упирается в 429, не доходя до их проверки.
+func IPRateLimitMiddleware(store ratelimit.Store, limit ratelimit.Limit) gin.HandlerFunc {
+	return rateLimiter(store, "per IP", limit, func(c *gin.Context) string {
+		return "ip|" + c.ClientIP()
+	})
+}
+
+// rateLimiter списывает запрос из корзины с ключом keyOf(c) и отклоняет его при исчерпании лимита;
+// scope называет ограничитель в логах
+func rateLimiter(store ratelimit.Store, scope string, limit ratelimit.Limit, keyOf func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !limit.Enabled() {
+			c.Next()
+			return
+		}
+
+		key := keyOf(c)
+		result, err := store.Take(c.Request.Context(), key, limit)
+		if err != nil {
+			log.Printf("[ERROR] Rate limit store failed for request %s: %v", c.GetString(requestIDKey), err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		if !result.Allowed {
+			log.Printf("[RATELIMIT] Request %s rejected: limit %s (%s) exceeded by %s", c.GetString(requestIDKey), limit, scope, key)
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			_ = c.Error(errRateLimited)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimitKey определяет, чей лимит расходует запрос: API-ключа, субъекта JWT
+// или, если вызывающий не аутентифицирован, IP-адреса клиента
+func rateLimitKey(c *gin.Context) string {
+	principal, ok := CurrentPrincipal(c)
+	switch {
+	case ok && principal.Method == model.AuthMethodAPIKey && principal.KeyID != "":
+		return "key:" + principal.KeyID
+	case ok && principal.Subject != "":
+		// kid токена общий для всех, кому выдан токен этим ключом, поэтому считаем по sub
+		return principal.Method + ":" + principal.Subject
+	default:
+		return "ip:" + c.ClientIP()
+	}
+}
+
+// ceilSeconds округляет длительность вверх до целых секунд, как того требуют
+// Retry-After и RateLimit-Reset
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/ratelimit"
+)
+
+func TestIPRateLimitCountsFailedAuthentication(t *testing.T) {
+	api := newTestAPI(Options{
+		RateLimitStore: ratelimit.NewMemoryStore(),
+		IPRateLimit:    ratelimit.Limit{Rate: 0.001, Burst: 3},
+	}, true)
+	key := api.issueKey(t, testUserID, model.RoleUser)
+
+	tests := []struct {
+		name   string
+		key    string
+		status int
+	}{
+		{"invalid key", "sk_invalid", http.StatusUnauthorized},
+		{"missing key", "", http.StatusUnauthorized},
+		{"invalid key again", "sk_invalid", http.StatusUnauthorized},
+		// Корзина IP исчерпана неудачными попытками: дальше не проверяются ни неверные, ни верные ключи
+		{"invalid key over limit", "sk_invalid", http.StatusTooManyRequests},
+		{"valid key over limit", key, http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		headers := map[string]string{}
+		if tt.key != "" {
+			headers["X-API-Key"] = tt.key
+		}
+		w := api.do(http.MethodGet, "/subscriptions/", "", headers)
+		if w.Code != tt.status {
+			t.Fatalf("%s: expected %d, got %d: %s", tt.name, tt.status, w.Code, w.Body.String())
+		}
+		if tt.status == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+			t.Errorf("%s: expected Retry-After header", tt.name)
+		}
+	}
+}
+
+func TestIPRateLimitOnlyWithAuthentication(t *testing.T) {
+	api := newTestAPI(Options{
+		RateLimitStore: ratelimit.NewMemoryStore(),
+		IPRateLimit:    ratelimit.Limit{Rate: 0.001, Burst: 1},
+	}, false)
+
+	// Без аутентификации группы и так считают запросы по IP, отдельный лимит не нужен
+	for i := 0; i < 3; i++ {
+		if w := api.do(http.MethodGet, "/subscriptions/", "", nil); w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i+1, w.Code)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ExchangeRateRequest — курс в теле PUT. Допускается число или строка с десятичным числом;
+// строка сохраняет точность без округления до float.
+type ExchangeRateRequest struct {
+	Rate json.Number `json:"rate" swaggertype:"string" example:"92.45" binding:"required"`
+}
+
+type ExchangeRatesResponse struct {
+	Items []model.ExchangeRate `json:"items"`
+}
+
+// ListExchangeRates godoc
+// @Summary Список курсов валют
+// @Description Возвращает курсы валют, упорядоченные по паре и дате. Курс действует с указанной даты до следующего курса той же пары.
+// @Tags exchange-rates
+// @Accept json
+// @Produce json
+// @Param base_currency query string false "Базовая валюта (ISO 4217)"
+// @Param quote_currency query string false "Котируемая валюта (ISO 4217)"
+// @Param from query string false "Курсы с даты (YYYY-MM-DD)"
+// @Param to query string false "Курсы по дату (YYYY-MM-DD)"
+// @Success 200 {object} ExchangeRatesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /exchange-rates [get]
+func (h *SubscriptionHandler) ListExchangeRates(c *gin.Context) {
+	log.Printf("[HANDLER] Listing exchange rates")
+
+	rates, err := h.Service.ListExchangeRates(c.Request.Context(), service.ExchangeRateParams{
+		BaseCurrency:  c.Query("base_currency"),
+		QuoteCurrency: c.Query("quote_currency"),
+		From:          c.Query("from"),
+		To:            c.Query("to"),
+	})
+	if err != nil {
+		log.Printf("[ERROR] Failed to list exchange rates: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	log.Printf("[SUCCESS] Retrieved %d exchange rates", len(rates))
+	c.JSON(http.StatusOK, ExchangeRatesResponse{Items: rates})
+}
+
+// SetExchangeRate godoc
+// @Summary Установить курс валюты
+// @Description Сохраняет курс: одна единица base стоит rate единиц quote начиная с даты date. Курс той же пары на ту же дату заменяется. Доступно только администраторам.
+// @Tags exchange-rates
+// @Accept json
+// @Produce json
+// @Param base path string true "Базовая валюта (ISO 4217)"
+// @Param quote path string true "Котируемая валюта (ISO 4217)"
+// @Param date path string true "Дата начала действия курса (YYYY-MM-DD)"
+// @Param rate body ExchangeRateRequest true "Курс"
+// @Success 200 {object} model.ExchangeRate
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /exchange-rates/{base}/{quote}/{date} [put]
+func (h *SubscriptionHandler) SetExchangeRate(c *gin.Context) {
+	base, quote, date := c.Param("base"), c.Param("quote"), c.Param("date")
+	log.Printf("[HANDLER] Setting exchange rate %s/%s on %s", base, quote, date)
+
+	var req ExchangeRateRequest
+	if err := bindJSON(c, &req); err != nil {
+		log.Printf("[ERROR] Invalid exchange rate request: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	rate, err := h.Service.SetExchangeRate(c.Request.Context(), base, quote, date, req.Rate.String())
+	if err != nil {
+		log.Printf("[ERROR] Failed to set exchange rate: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	log.Printf("[SUCCESS] Exchange rate %s/%s on %s set", rate.BaseCurrency, rate.QuoteCurrency, date)
+	c.JSON(http.StatusOK, rate)
+}
+
+// DeleteExchangeRate godoc
+// @Summary Удалить курс валюты
+// @Description Удаляет курс пары валют на дату. Доступно только администраторам.
+// @Tags exchange-rates
+// @Accept json
+// @Produce json
+// @Param base path string true "Базовая валюта (ISO 4217)"
+// @Param quote path string true "Котируемая валюта (ISO 4217)"
+// @Param date path string true "Дата начала действия курса (YYYY-MM-DD)"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /exchange-rates/{base}/{quote}/{date} [delete]
+func (h *SubscriptionHandler) DeleteExchangeRate(c *gin.Context) {
+	base, quote, date := c.Param("base"), c.Param("quote"), c.Param("date")
+	log.Printf("[HANDLER] Deleting exchange rate %s/%s on %s", base, quote, date)
+
+	if err := h.Service.DeleteExchangeRate(c.Request.Context(), base, quote, date); err != nil {
+		log.Printf("[ERROR] Failed to delete exchange rate: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	log.Printf("[SUCCESS] Deleted exchange rate %s/%s on %s", base, quote, date)
+	c.Status(http.StatusNoContent)
+}
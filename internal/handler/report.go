@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type SpendReportResponse struct {
+	GroupBy     []string         `json:"group_by" example:"month,service_name"`
+	UserID      string           `json:"user_id,omitempty" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	ServiceName string           `json:"service_name,omitempty" example:"Netflix"`
+	ServiceID   string           `json:"service_id,omitempty" example:"7a1f3c2e-5b4d-4e8f-9a6b-1c2d3e4f5a6b"`
+	Category    string           `json:"category,omitempty" example:"streaming"`
+	Tags        string           `json:"tags,omitempty" example:"streaming,work"`
+	TagsMatch   string           `json:"tags_match,omitempty" example:"any"`
+	StartDate   string           `json:"start_date,omitempty" example:"01-2025"`
+	EndDate     string           `json:"end_date,omitempty" example:"12-2025"`
+	Months      []string         `json:"months" example:"01-2025,02-2025"`
+	Currency    string           `json:"currency" example:"RUB"`
+	Total       int              `json:"total" example:"1198800"`
+	Rows        []model.SpendRow `json:"rows"`
+}
+
+// SpendReport godoc
+// @Summary Отчёт о расходах по месяцам
+// @Description Раскладывает стоимость подписок по оплачиваемым месяцам периода и суммирует её по группам group_by. Пересечение с периодом, паузы и пересчёт валют учитываются так же, как в /subscriptions/total, поэтому total совпадает с total_cost. Строки отсортированы по месяцу, сервису, пользователю и категории; группы без расходов не выводятся, а поле months содержит все месяцы периода для построения графика.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param group_by query string false "Измерения группировки через запятую: month, service_name, user_id, category (по умолчанию month)"
+// @Param user_id query string false "ID пользователя (UUID)"
+// @Param service_name query string false "Название сервиса"
+// @Param service_id query string false "ID сервиса из каталога"
+// @Param category query string false "Категория подписки"
+// @Param tags query string false "Теги через запятую"
+// @Param tags_match query string false "Подписка должна иметь хотя бы один из тегов (any) или все теги (all)" Enums(any, all) default(any)
+// @Param start_date query string false "Начало периода (MM-YYYY или YYYY-MM-DD)"
+// @Param end_date query string false "Конец периода включительно (MM-YYYY — весь месяц, или YYYY-MM-DD)"
+// @Param currency query string false "Валюта отчёта (ISO 4217)" default(RUB)
+// @Param include_deleted query bool false "Учитывать удалённые подписки (для администраторов)"
+// @Param X-Tenant-ID header string false "ID организации запроса; по умолчанию организация ключа или токена"
+// @Success 200 {object} SpendReportResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /subscriptions/reports/spend [get]
+func (h *SubscriptionHandler) SpendReport(c *gin.Context) {
+	params := service.SpendReportParams{
+		UserID:      c.Query("user_id"),
+		ServiceName: c.Query("service_name"),
+		ServiceID:   c.Query("service_id"),
+		Category:    c.Query("category"),
+		Tags:        c.Query("tags"),
+		TagsMatch:   c.Query("tags_match"),
+		StartDate:   c.Query("start_date"),
+		EndDate:     c.Query("end_date"),
+		GroupBy:     c.Query("group_by"),
+		Currency:    c.Query("currency"),
+	}
+
+	log.Printf("[HANDLER] Building spend report grouped by %q, period: %s - %s", params.GroupBy, params.StartDate, params.EndDate)
+
+	includeDeleted, err := queryIncludeDeleted(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	params.IncludeDeleted = includeDeleted
+
+	report, err := h.scopedService(c).SpendReport(c.Request.Context(), params)
+	if err != nil {
+		log.Printf("[ERROR] Failed to build spend report: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SpendReportResponse{
+		GroupBy:     report.GroupBy,
+		UserID:      params.UserID,
+		ServiceName: params.ServiceName,
+		ServiceID:   params.ServiceID,
+		Category:    params.Category,
+		Tags:        params.Tags,
+		TagsMatch:   params.TagsMatch,
+		StartDate:   params.StartDate,
+		EndDate:     params.EndDate,
+		Months:      report.Months,
+		Currency:    report.Currency,
+		Total:       report.Total,
+		Rows:        report.Rows,
+	})
+}
+
+type TagReportResponse struct {
+	GroupBy     []string            `json:"group_by" example:"month"`
+	UserID      string              `json:"user_id,omitempty" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	ServiceName string              `json:"service_name,omitempty" example:"Netflix"`
+	ServiceID   string              `json:"service_id,omitempty" example:"7a1f3c2e-5b4d-4e8f-9a6b-1c2d3e4f5a6b"`
+	Category    string              `json:"category,omitempty" example:"streaming"`
+	Tags        string              `json:"tags,omitempty" example:"streaming,work"`
+	TagsMatch   string              `json:"tags_match,omitempty" example:"any"`
+	StartDate   string              `json:"start_date,omitempty" example:"01-2025"`
+	EndDate     string              `json:"end_date,omitempty" example:"12-2025"`
+	Months      []string            `json:"months" example:"01-2025,02-2025"`
+	Currency    string              `json:"currency" example:"RUB"`
+	Total       int                 `json:"total" example:"1198800"` // расходы на подписки отчёта, каждая учтена один раз
+	Rows        []model.TagSpendRow `json:"rows"`
+}
+
+// TagReport godoc
+// @Summary Отчёт о расходах по тегам
+// @Description Суммирует стоимость подписок за период по тегам. Стоимость считается так же, как в /subscriptions/total, с учётом пересечения с периодом, пауз, изменений цен и пересчёта валют.
+// @Description Подписка с несколькими тегами входит в строку каждого из них, поэтому сумма строк может превышать total. Подписки без тегов собираются в строку с пустым tag.
+// @Description Если задан tags, выводятся только строки перечисленных тегов. С group_by=month строки разбиты по месяцам.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param group_by query string false "Дополнительная группировка: month"
+// @Param tags query string false "Теги через запятую"
+// @Param tags_match query string false "Подписка должна иметь хотя бы один из тегов (any) или все теги (all)" Enums(any, all) default(any)
+// @Param user_id query string false "ID пользователя (UUID)"
+// @Param service_name query string false "Название сервиса"
+// @Param service_id query string false "ID сервиса из каталога"
+// @Param category query string false "Категория подписки"
+// @Param start_date query string false "Начало периода (MM-YYYY или YYYY-MM-DD)"
+// @Param end_date query string false "Конец периода включительно (MM-YYYY — весь месяц, или YYYY-MM-DD)"
+// @Param currency query string false "Валюта отчёта (ISO 4217)" default(RUB)
+// @Param include_deleted query bool false "Учитывать удалённые подписки (для администраторов)"
+// @Param X-Tenant-ID header string false "ID организации запроса; по умолчанию организация ключа или токена"
+// @Success 200 {object} TagReportResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /subscriptions/reports/by-tag [get]
+func (h *SubscriptionHandler) TagReport(c *gin.Context) {
+	params := service.SpendReportParams{
+		UserID:      c.Query("user_id"),
+		ServiceName: c.Query("service_name"),
+		ServiceID:   c.Query("service_id"),
+		Category:    c.Query("category"),
+		Tags:        c.Query("tags"),
+		TagsMatch:   c.Query("tags_match"),
+		StartDate:   c.Query("start_date"),
+		EndDate:     c.Query("end_date"),
+		GroupBy:     c.Query("group_by"),
+		Currency:    c.Query("currency"),
+	}
+
+	log.Printf("[HANDLER] Building tag report for tags %q, period: %s - %s", params.Tags, params.StartDate, params.EndDate)
+
+	includeDeleted, err := queryIncludeDeleted(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	params.IncludeDeleted = includeDeleted
+
+	report, err := h.scopedService(c).TagReport(c.Request.Context(), params)
+	if err != nil {
+		log.Printf("[ERROR] Failed to build tag report: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, TagReportResponse{
+		GroupBy:     report.GroupBy,
+		UserID:      params.UserID,
+		ServiceName: params.ServiceName,
+		ServiceID:   params.ServiceID,
+		Category:    params.Category,
+		Tags:        params.Tags,
+		TagsMatch:   params.TagsMatch,
+		StartDate:   params.StartDate,
+		EndDate:     params.EndDate,
+		Months:      report.Months,
+		Currency:    report.Currency,
+		Total:       report.Total,
+		Rows:        report.Rows,
+	})
+}
@@ -4,40 +4,83 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/Headliner38/Subscription_Service/internal/model"
 	"github.com/Headliner38/Subscription_Service/internal/service"
 	"github.com/gin-gonic/gin"
 )
 
 type CreateSubscriptionRequest struct {
-	ServiceName string `json:"service_name" example:"Netflix" binding:"required"`
-	Price       int    `json:"price" example:"999" binding:"required,gt=0"`
-	UserID      string `json:"user_id" example:"user123" binding:"required"`
-	StartDate   string `json:"start_date" example:"01-2024" binding:"required"`
-	EndDate     string `json:"end_date,omitempty" example:"12-2024"`
+	// ServiceID — сервис из каталога; без него service_name ищется в каталоге по названию и синонимам
+	ServiceID   string `json:"service_id,omitempty" example:"7a1f3c2e-5b4d-4e8f-9a6b-1c2d3e4f5a6b"`
+	ServiceName string `json:"service_name,omitempty" example:"Netflix"`
+	Price       int    `json:"price,omitempty" example:"99900" binding:"omitempty,gt=0"` // в минимальных единицах валюты; по умолчанию цена из каталога
+	Currency    string `json:"currency,omitempty" example:"RUB"`                         // ISO 4217, по умолчанию RUB
+	// BillingPeriod и IntervalCount задают расписание списаний; по умолчанию раз в месяц
+	BillingPeriod string `json:"billing_period,omitempty" example:"monthly" enums:"weekly,monthly,quarterly,yearly"`
+	IntervalCount int    `json:"interval_count,omitempty" example:"1"`
+	// Category — категория подписки; по умолчанию категория сервиса из каталога
+	Category string   `json:"category,omitempty" example:"streaming"`
+	Tags     []string `json:"tags,omitempty" example:"family,work"`
+	UserID   string   `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba" binding:"required"`
+	// OrganizationID — организация подписки; по умолчанию организация запроса (X-Tenant-ID или ключа)
+	OrganizationID string `json:"organization_id,omitempty" example:"0b5d8f3e-2c4a-4e6b-9d1f-3a5c7e9b1d2f"`
+	StartDate      string `json:"start_date" example:"01-2024" binding:"required"` // MM-YYYY или YYYY-MM-DD
+	EndDate        string `json:"end_date,omitempty" example:"12-2024"`            // включительно; месяц MM-YYYY — целиком
 }
 
 type UpdateSubscriptionRequest struct {
-	ServiceName string `json:"service_name" example:"Netflix" binding:"required"`
-	Price       int    `json:"price" example:"999" binding:"required,gt=0"`
-	UserID      string `json:"user_id" example:"user123" binding:"required"`
-	StartDate   string `json:"start_date" example:"01-2024" binding:"required"`
-	EndDate     string `json:"end_date,omitempty" example:"12-2024"`
+	// ServiceID — сервис из каталога; без него service_name ищется в каталоге по названию и синонимам
+	ServiceID   string `json:"service_id,omitempty" example:"7a1f3c2e-5b4d-4e8f-9a6b-1c2d3e4f5a6b"`
+	ServiceName string `json:"service_name,omitempty" example:"Netflix"`
+	Price       int    `json:"price,omitempty" example:"99900" binding:"omitempty,gt=0"` // в минимальных единицах валюты; по умолчанию цена из каталога
+	Currency    string `json:"currency,omitempty" example:"RUB"`                         // ISO 4217, по умолчанию RUB
+	// BillingPeriod и IntervalCount задают расписание списаний; по умолчанию раз в месяц
+	BillingPeriod string `json:"billing_period,omitempty" example:"monthly" enums:"weekly,monthly,quarterly,yearly"`
+	IntervalCount int    `json:"interval_count,omitempty" example:"1"`
+	// Category — категория подписки; по умолчанию категория сервиса из каталога
+	Category  string   `json:"category,omitempty" example:"streaming"`
+	Tags      []string `json:"tags,omitempty" example:"family,work"`
+	UserID    string   `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba" binding:"required"`
+	StartDate string   `json:"start_date" example:"01-2024" binding:"required"` // MM-YYYY или YYYY-MM-DD
+	EndDate   string   `json:"end_date,omitempty" example:"12-2024"`            // включительно; месяц MM-YYYY — целиком
 }
 
 type ErrorResponse struct {
-	Error string `json:"error" example:"Invalid request"`
+	Error     string               `json:"error" example:"Invalid request"`
+	Code      string               `json:"code" example:"validation_error"`
+	Details   []service.FieldError `json:"details,omitempty"`
+	RequestID string               `json:"request_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
 }
 
 type TotalCostResponse struct {
-	TotalCost   int    `json:"total_cost" example:"2997"`
-	UserID      string `json:"user_id" example:"user123"`
-	ServiceName string `json:"service_name" example:"Netflix"`
-	StartDate   string `json:"start_date" example:"01-2024"`
-	EndDate     string `json:"end_date" example:"12-2024"`
+	TotalCost   int                      `json:"total_cost" example:"299700"` // в минимальных единицах currency
+	Currency    string                   `json:"currency" example:"RUB"`
+	UserID      string                   `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	ServiceName string                   `json:"service_name" example:"Netflix"`
+	ServiceID   string                   `json:"service_id,omitempty" example:"7a1f3c2e-5b4d-4e8f-9a6b-1c2d3e4f5a6b"`
+	Category    string                   `json:"category,omitempty" example:"streaming"`
+	Tags        string                   `json:"tags,omitempty" example:"streaming,work"`
+	TagsMatch   string                   `json:"tags_match,omitempty" example:"any"`
+	StartDate   string                   `json:"start_date" example:"01-2024"`
+	EndDate     string                   `json:"end_date" example:"12-2024"`
+	Items       []model.SubscriptionCost `json:"items"`
+}
+
+type ListSubscriptionsResponse struct {
+	Items      []model.Subscription `json:"items"`
+	NextCursor string               `json:"next_cursor,omitempty" example:"eyJzIjoic3RhcnRfZGF0ZSJ9"`
+}
+
+type ChangesResponse struct {
+	Events    []model.SubscriptionChange `json:"events"`
+	NextToken string                     `json:"next_token" example:"Y2hnOjQy"`
+	HasMore   bool                       `json:"has_more" example:"false"`
 }
 
 type SubscriptionHandler struct {
 	Service *service.SubscriptionService
+	// RequireIfMatch обязывает передавать If-Match в PUT, PATCH и DELETE
+	RequireIfMatch bool
 }
 
 // CreateSubscription godoc
@@ -47,16 +90,23 @@ type SubscriptionHandler struct {
 // @Accept json
 // @Produce json
 // @Param subscription body CreateSubscriptionRequest true "Данные подписки"
+// @Param X-Tenant-ID header string false "ID организации запроса; по умолчанию организация ключа или токена"
 // @Success 201 {object} model.Subscription
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
 // @Router /subscriptions [post]
 func (h *SubscriptionHandler) CreateSubscription(c *gin.Context) {
 	log.Printf("[HANDLER] Creating subscription")
 
 	var req CreateSubscriptionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := bindJSON(c, &req); err != nil {
 		log.Printf("[ERROR] Invalid request body: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		_ = c.Error(err)
 		return
 	}
 
@@ -65,14 +115,28 @@ func (h *SubscriptionHandler) CreateSubscription(c *gin.Context) {
 		endDate = &req.EndDate
 	}
 
-	sub, err := h.Service.CreateSubscription(req.ServiceName, req.Price, req.UserID, req.StartDate, endDate)
+	sub, err := h.scopedService(c).CreateSubscription(c.Request.Context(), service.SubscriptionInput{
+		ServiceID:      req.ServiceID,
+		ServiceName:    req.ServiceName,
+		Price:          req.Price,
+		Currency:       req.Currency,
+		BillingPeriod:  req.BillingPeriod,
+		IntervalCount:  req.IntervalCount,
+		Category:       req.Category,
+		Tags:           req.Tags,
+		UserID:         req.UserID,
+		OrganizationID: req.OrganizationID,
+		StartDate:      req.StartDate,
+		EndDate:        endDate,
+	})
 	if err != nil {
 		log.Printf("[ERROR] Failed to create subscription: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
 	log.Printf("[SUCCESS] Created subscription with ID: %s", sub.ID)
+	setETag(c, sub)
 	c.JSON(http.StatusCreated, sub)
 }
 
@@ -83,45 +147,126 @@ func (h *SubscriptionHandler) CreateSubscription(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "ID подписки"
+// @Param include_deleted query bool false "Вернуть подписку, даже если она удалена (для администраторов)"
+// @Param X-Tenant-ID header string false "ID организации запроса; по умолчанию организация ключа или токена"
 // @Success 200 {object} model.Subscription
+// @Header 200 {string} ETag "Версия подписки"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
 // @Router /subscriptions/{id} [get]
 func (h *SubscriptionHandler) GetSubscription(c *gin.Context) {
 	id := c.Param("id")
 	log.Printf("[HANDLER] Getting subscription with ID: %s", id)
 
-	sub, err := h.Service.GetSubscription(id)
+	includeDeleted, err := queryIncludeDeleted(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	sub, err := h.scopedService(c).GetSubscription(c.Request.Context(), id, includeDeleted)
 	if err != nil {
 		log.Printf("[ERROR] Failed to get subscription %s: %v", id, err)
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
 	log.Printf("[SUCCESS] Retrieved subscription with ID: %s", id)
+	setETag(c, sub)
 	c.JSON(http.StatusOK, sub)
 }
 
 // ListSubscriptions godoc
 // @Summary Список подписок
-// @Description Получает список всех подписок
+// @Description Получает список подписок с фильтрацией, сортировкой и постраничной выдачей по курсору.
+// @Description В форматах csv, ndjson и xlsx (параметр format или заголовок Accept) выгружаются все подходящие подписки без постраничной разбивки.
 // @Tags subscriptions
 // @Accept json
 // @Produce json
-// @Success 200 {array} model.Subscription
-// @Failure 500 {object} ErrorResponse
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param user_id query string false "ID пользователя"
+// @Param service_name query string false "Название сервиса"
+// @Param currency query string false "Валюта подписки (ISO 4217)"
+// @Param category query string false "Категория подписки"
+// @Param tags query string false "Теги через запятую"
+// @Param tags_match query string false "Подписка должна иметь хотя бы один из тегов (any) или все теги (all)" Enums(any, all) default(any)
+// @Param min_price query int false "Минимальная цена в минимальных единицах валюты"
+// @Param max_price query int false "Максимальная цена в минимальных единицах валюты"
+// @Param active_at query string false "Подписка активна в указанном месяце (MM-YYYY) или в указанный день (YYYY-MM-DD)"
+// @Param updated_since query string false "Только подписки, изменённые после момента (RFC 3339)"
+// @Param status query string false "Статус жизненного цикла" Enums(active, paused, cancelled, expired)
+// @Param sort query string false "Поле сортировки" Enums(start_date, price, service_name, created_at, updated_at) default(start_date)
+// @Param order query string false "Направление сортировки" Enums(asc, desc) default(asc)
+// @Param limit query int false "Размер страницы (1-500)" default(50)
+// @Param cursor query string false "Курсор следующей страницы из next_cursor"
+// @Param include_deleted query bool false "Включить удалённые подписки (для администраторов)"
+// @Param format query string false "Формат ответа; по умолчанию определяется заголовком Accept" Enums(json, csv, ndjson, xlsx)
+// @Param X-Tenant-ID header string false "ID организации запроса; по умолчанию организация ключа или токена"
+// @Success 200 {object} ListSubscriptionsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
 // @Router /subscriptions [get]
 func (h *SubscriptionHandler) ListSubscriptions(c *gin.Context) {
-	log.Printf("[HANDLER] Listing all subscriptions")
+	log.Printf("[HANDLER] Listing subscriptions")
+
+	includeDeleted, err := queryIncludeDeleted(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	params := service.ListSubscriptionsParams{
+		UserID:         c.Query("user_id"),
+		ServiceName:    c.Query("service_name"),
+		Currency:       c.Query("currency"),
+		MinPrice:       c.Query("min_price"),
+		MaxPrice:       c.Query("max_price"),
+		Category:       c.Query("category"),
+		Tags:           c.Query("tags"),
+		TagsMatch:      c.Query("tags_match"),
+		ActiveAt:       c.Query("active_at"),
+		UpdatedSince:   c.Query("updated_since"),
+		Status:         c.Query("status"),
+		Sort:           c.Query("sort"),
+		Order:          c.Query("order"),
+		Limit:          c.Query("limit"),
+		Cursor:         c.Query("cursor"),
+		IncludeDeleted: includeDeleted,
+	}
 
-	subscriptions, err := h.Service.ListSubscriptions()
+	format, err := exportFormat(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if format != formatJSON {
+		h.exportSubscriptions(c, params, format)
+		return
+	}
+
+	page, err := h.scopedService(c).ListSubscriptions(c.Request.Context(), params)
 	if err != nil {
 		log.Printf("[ERROR] Failed to list subscriptions: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
-	log.Printf("[SUCCESS] Retrieved %d subscriptions", len(subscriptions))
-	c.JSON(http.StatusOK, subscriptions)
+	log.Printf("[SUCCESS] Retrieved %d subscriptions", len(page.Items))
+	c.JSON(http.StatusOK, ListSubscriptionsResponse{
+		Items:      page.Items,
+		NextCursor: page.NextCursor,
+	})
 }
 
 // UpdateSubscription godoc
@@ -131,19 +276,36 @@ func (h *SubscriptionHandler) ListSubscriptions(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "ID подписки"
+// @Param If-Match header string false "ETag подписки из предыдущего ответа, например \"3\", или *"
 // @Param subscription body UpdateSubscriptionRequest true "Новые данные подписки"
+// @Param X-Tenant-ID header string false "ID организации запроса; по умолчанию организация ключа или токена"
 // @Success 200 {object} model.Subscription
+// @Header 200 {string} ETag "Версия подписки"
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} model.Subscription "Версия не совпала; в теле актуальное состояние"
+// @Failure 428 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
 // @Router /subscriptions/{id} [put]
 func (h *SubscriptionHandler) UpdateSubscription(c *gin.Context) {
 	id := c.Param("id")
 	log.Printf("[HANDLER] Updating subscription with ID: %s", id)
 
 	var req UpdateSubscriptionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := bindJSON(c, &req); err != nil {
 		log.Printf("[ERROR] Invalid request body for update: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		_ = c.Error(err)
+		return
+	}
+
+	version, err := h.ifMatchVersion(c)
+	if err != nil {
+		log.Printf("[ERROR] Invalid If-Match for update: %v", err)
+		_ = c.Error(err)
 		return
 	}
 
@@ -152,35 +314,65 @@ func (h *SubscriptionHandler) UpdateSubscription(c *gin.Context) {
 		endDate = &req.EndDate
 	}
 
-	sub, err := h.Service.UpdateSubscription(id, req.ServiceName, req.Price, req.UserID, req.StartDate, endDate)
+	sub, err := h.scopedService(c).UpdateSubscription(c.Request.Context(), id, service.SubscriptionInput{
+		ServiceID:     req.ServiceID,
+		ServiceName:   req.ServiceName,
+		Price:         req.Price,
+		Currency:      req.Currency,
+		BillingPeriod: req.BillingPeriod,
+		IntervalCount: req.IntervalCount,
+		Category:      req.Category,
+		Tags:          req.Tags,
+		UserID:        req.UserID,
+		StartDate:     req.StartDate,
+		EndDate:       endDate,
+	}, version)
 	if err != nil {
 		log.Printf("[ERROR] Failed to update subscription %s: %v", id, err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
 	log.Printf("[SUCCESS] Updated subscription with ID: %s", id)
+	setETag(c, sub)
 	c.JSON(http.StatusOK, sub)
 }
 
 // DeleteSubscription godoc
 // @Summary Удалить подписку
-// @Description Удаляет подписку по ID
+// @Description Мягко удаляет подписку по ID: она скрывается из списков и расчётов, но может быть восстановлена до окончательной очистки
 // @Tags subscriptions
 // @Accept json
 // @Produce json
 // @Param id path string true "ID подписки"
+// @Param If-Match header string false "ETag подписки из предыдущего ответа, например \"3\", или *"
+// @Param X-Tenant-ID header string false "ID организации запроса; по умолчанию организация ключа или токена"
 // @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} model.Subscription "Версия не совпала; в теле актуальное состояние"
+// @Failure 428 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
 // @Router /subscriptions/{id} [delete]
 func (h *SubscriptionHandler) DeleteSubscription(c *gin.Context) {
 	id := c.Param("id")
 	log.Printf("[HANDLER] Deleting subscription with ID: %s", id)
 
-	err := h.Service.DeleteSubscription(id)
+	version, err := h.ifMatchVersion(c)
+	if err != nil {
+		log.Printf("[ERROR] Invalid If-Match for deletion: %v", err)
+		_ = c.Error(err)
+		return
+	}
+
+	err = h.scopedService(c).DeleteSubscription(c.Request.Context(), id, version)
 	if err != nil {
 		log.Printf("[ERROR] Failed to delete subscription %s: %v", id, err)
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
@@ -188,18 +380,70 @@ func (h *SubscriptionHandler) DeleteSubscription(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// RestoreSubscription godoc
+// @Summary Восстановить подписку
+// @Description Восстанавливает мягко удалённую подписку. Доступно только администраторам.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Param X-Tenant-ID header string false "ID организации запроса; по умолчанию организация ключа или токена"
+// @Success 200 {object} model.Subscription
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /subscriptions/{id}/restore [post]
+func (h *SubscriptionHandler) RestoreSubscription(c *gin.Context) {
+	id := c.Param("id")
+	log.Printf("[HANDLER] Restoring subscription with ID: %s", id)
+
+	sub, err := h.scopedService(c).RestoreSubscription(c.Request.Context(), id)
+	if err != nil {
+		log.Printf("[ERROR] Failed to restore subscription %s: %v", id, err)
+		_ = c.Error(err)
+		return
+	}
+
+	log.Printf("[SUCCESS] Restored subscription with ID: %s", id)
+	setETag(c, sub)
+	c.JSON(http.StatusOK, sub)
+}
+
 // CalculateTotalCost godoc
 // @Summary Подсчитать общую стоимость
-// @Description Подсчитывает общую стоимость подписок с фильтрацией
+// @Description Подсчитывает стоимость подписок за период. Цена списывается в начале каждого цикла оплаты (billing_period × interval_count)
+// @Description и распределяется по дням цикла, поэтому в период попадает только его доля: годовая подписка даёт в каждый месяц около 1/12 цены.
+// @Description Доля каждого месяца пересчитывается в валюту currency по курсу, действовавшему в последний день месяца; суммы — в минимальных единицах валюты.
+// @Description В форматах csv, ndjson и xlsx выгружается разбивка по подпискам (строки items); итог равен сумме колонки cost.
 // @Tags subscriptions
 // @Accept json
 // @Produce json
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
 // @Param user_id query string false "ID пользователя"
 // @Param service_name query string false "Название сервиса"
-// @Param start_date query string false "Начальная дата (MM-YYYY)"
-// @Param end_date query string false "Конечная дата (MM-YYYY)"
+// @Param service_id query string false "ID сервиса из каталога"
+// @Param category query string false "Категория подписки"
+// @Param tags query string false "Теги через запятую"
+// @Param tags_match query string false "Подписка должна иметь хотя бы один из тегов (any) или все теги (all)" Enums(any, all) default(any)
+// @Param start_date query string false "Начальная дата (MM-YYYY или YYYY-MM-DD)"
+// @Param end_date query string false "Конечная дата включительно (MM-YYYY — весь месяц, или YYYY-MM-DD)"
+// @Param currency query string false "Валюта итога (ISO 4217)" default(RUB)
+// @Param include_deleted query bool false "Учитывать удалённые подписки (для администраторов)"
+// @Param format query string false "Формат ответа; по умолчанию определяется заголовком Accept" Enums(json, csv, ndjson, xlsx)
+// @Param X-Tenant-ID header string false "ID организации запроса; по умолчанию организация ключа или токена"
 // @Success 200 {object} TotalCostResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
 // @Router /subscriptions/total [get]
 func (h *SubscriptionHandler) CalculateTotalCost(c *gin.Context) {
 	// Получаем параметры из query string
@@ -210,21 +454,92 @@ func (h *SubscriptionHandler) CalculateTotalCost(c *gin.Context) {
 
 	log.Printf("[HANDLER] Calculating total cost for user: %s, service: %s, period: %s - %s", userID, serviceName, startDate, endDate)
 
+	includeDeleted, err := queryIncludeDeleted(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	params := service.TotalCostParams{
+		UserID:         userID,
+		ServiceName:    serviceName,
+		ServiceID:      c.Query("service_id"),
+		Category:       c.Query("category"),
+		Tags:           c.Query("tags"),
+		TagsMatch:      c.Query("tags_match"),
+		StartDate:      startDate,
+		EndDate:        endDate,
+		Currency:       c.Query("currency"),
+		IncludeDeleted: includeDeleted,
+	}
+
+	format, err := exportFormat(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if format != formatJSON {
+		h.exportCosts(c, params, format)
+		return
+	}
+
 	// Вызываем сервис для подсчёта
-	totalCost, err := h.Service.CalculateTotalCost(userID, serviceName, startDate, endDate)
+	total, err := h.scopedService(c).CalculateTotalCost(c.Request.Context(), params)
 	if err != nil {
 		log.Printf("[ERROR] Failed to calculate total cost: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
+		return
+	}
+
+	log.Printf("[SUCCESS] Calculated total cost: %d %s", total.TotalCost, total.Currency)
+	// Возвращаем результат с разбивкой по подпискам
+	c.JSON(http.StatusOK, TotalCostResponse{
+		TotalCost:   total.TotalCost,
+		Currency:    total.Currency,
+		UserID:      userID,
+		ServiceName: serviceName,
+		ServiceID:   params.ServiceID,
+		Category:    params.Category,
+		Tags:        params.Tags,
+		TagsMatch:   params.TagsMatch,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		Items:       total.Items,
+	})
+}
+
+// ListChanges godoc
+// @Summary Лента изменений подписок
+// @Description Возвращает упорядоченные события создания, изменения и удаления подписок после токена since. Для продолжения чтения передайте next_token в since.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param since query string false "Токен продолжения (next_token из предыдущего ответа); пусто — с начала ленты"
+// @Param limit query int false "Максимальное число событий (1-1000)" default(100)
+// @Param X-Tenant-ID header string false "ID организации запроса; по умолчанию организация ключа или токена"
+// @Success 200 {object} ChangesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /subscriptions/changes [get]
+func (h *SubscriptionHandler) ListChanges(c *gin.Context) {
+	since := c.Query("since")
+	log.Printf("[HANDLER] Listing subscription changes since: %q", since)
+
+	page, err := h.scopedService(c).ListChanges(c.Request.Context(), since, c.Query("limit"))
+	if err != nil {
+		log.Printf("[ERROR] Failed to list subscription changes: %v", err)
+		_ = c.Error(err)
 		return
 	}
 
-	log.Printf("[SUCCESS] Calculated total cost: %d", totalCost)
-	// Возвращаем результат
-	c.JSON(http.StatusOK, gin.H{
-		"total_cost":   totalCost,
-		"user_id":      userID,
-		"service_name": serviceName,
-		"start_date":   startDate,
-		"end_date":     endDate,
+	log.Printf("[SUCCESS] Retrieved %d subscription changes", len(page.Events))
+	c.JSON(http.StatusOK, ChangesResponse{
+		Events:    page.Events,
+		NextToken: page.NextToken,
+		HasMore:   page.HasMore,
 	})
 }
@@ -0,0 +1,285 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Headliner38/Subscription_Service/internal/auth"
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/repository"
+	"github.com/Headliner38/Subscription_Service/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	testUserID  = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+	adminUserID = "00000000-0000-0000-0000-0000000000aa"
+	missingID   = "11111111-2222-3333-4444-555555555555"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// testAPI — маршруты API поверх хранилища в памяти
+type testAPI struct {
+	router  *gin.Engine
+	service *service.SubscriptionService
+}
+
+// newTestAPI собирает маршруты так же, как cmd/main.go, но поверх хранилища в памяти;
+// withAuth включает аутентификацию API-ключами из того же хранилища
+func newTestAPI(opts Options, withAuth bool) *testAPI {
+	api := &testAPI{
+		router:  gin.New(),
+		service: &service.SubscriptionService{Repo: repository.NewMemoryRepository()},
+	}
+	if withAuth {
+		opts.Authenticators = []auth.Authenticator{auth.NewAPIKeyAuthenticator(api.service.AuthenticateAPIKey)}
+	}
+	api.router.Use(RequestIDMiddleware(), ErrorMiddleware())
+	SetupRoutes(api.router, api.service, opts)
+	return api
+}
+
+// issueKey выпускает API-ключ и возвращает его секрет
+func (api *testAPI) issueKey(t *testing.T, userID, role string) string {
+	t.Helper()
+	_, secret, err := api.service.CreateAPIKey(context.Background(), service.APIKeyInput{Name: role, UserID: userID, Role: role})
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	return secret
+}
+
+// do выполняет запрос к API и возвращает ответ
+func (api *testAPI) do(method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	api.router.ServeHTTP(w, req)
+	return w
+}
+
+// errorCode возвращает поле code тела ошибки
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("response is not JSON: %s", w.Body.String())
+	}
+	return resp.Code
+}
+
+// subscriptionBody возвращает тело запроса на создание подписки
+func subscriptionBody(price int) string {
+	return fmt.Sprintf(`{"service_name":"Netflix","price":%d,"user_id":%q,"start_date":"01-2024"}`, price, testUserID)
+}
+
+func TestSubscriptionRoutes(t *testing.T) {
+	api := newTestAPI(Options{RequireIfMatch: true}, false)
+
+	created := api.do(http.MethodPost, "/subscriptions/", subscriptionBody(10000), nil)
+	if created.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", created.Code, created.Body.String())
+	}
+	var sub model.Subscription
+	if err := json.Unmarshal(created.Body.Bytes(), &sub); err != nil {
+		t.Fatalf("create: invalid body: %v", err)
+	}
+	etag := created.Header().Get("ETag")
+	if etag != formatETag(sub.Version) {
+		t.Fatalf("create: expected ETag %s, got %q", formatETag(sub.Version), etag)
+	}
+	path := "/subscriptions/" + sub.ID
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		headers map[string]string
+		status  int
+		code    string
+	}{
+		{"malformed JSON", http.MethodPost, "/subscriptions/", `{"price":`, nil, http.StatusBadRequest, "bad_request"},
+		{"missing required fields", http.MethodPost, "/subscriptions/", `{"service_name":"Netflix"}`, nil, http.StatusBadRequest, "validation_error"},
+		{"invalid price", http.MethodPost, "/subscriptions/", subscriptionBody(-5), nil, http.StatusBadRequest, "validation_error"},
+		{"invalid currency", http.MethodPost, "/subscriptions/", `{"service_name":"Netflix","price":1,"currency":"XYZ","user_id":"` + testUserID + `","start_date":"01-2024"}`, nil, http.StatusBadRequest, "validation_error"},
+		{"get existing", http.MethodGet, path, "", nil, http.StatusOK, ""},
+		{"get unknown", http.MethodGet, "/subscriptions/" + missingID, "", nil, http.StatusNotFound, "not_found"},
+		{"get malformed id", http.MethodGet, "/subscriptions/abc", "", nil, http.StatusNotFound, "not_found"},
+		{"list with bad limit", http.MethodGet, "/subscriptions/?limit=0", "", nil, http.StatusBadRequest, "validation_error"},
+		{"put without If-Match", http.MethodPut, path, subscriptionBody(20000), nil, http.StatusPreconditionRequired, "precondition_required"},
+		{"put with malformed If-Match", http.MethodPut, path, subscriptionBody(20000), map[string]string{"If-Match": "W/1"}, http.StatusBadRequest, "validation_error"},
+		{"put with stale If-Match", http.MethodPut, path, subscriptionBody(20000), map[string]string{"If-Match": `"999"`}, http.StatusPreconditionFailed, ""},
+		{"put with current If-Match", http.MethodPut, path, subscriptionBody(20000), map[string]string{"If-Match": etag}, http.StatusOK, ""},
+		{"patch as text", http.MethodPatch, path, "price=1", map[string]string{"Content-Type": "text/plain", "If-Match": "*"}, http.StatusUnsupportedMediaType, "unsupported_media_type"},
+		{"resume active", http.MethodPost, path + "/resume", "", nil, http.StatusConflict, "conflict"},
+		{"restore active", http.MethodPost, path + "/restore", "", nil, http.StatusConflict, "conflict"},
+		{"total with bad user", http.MethodGet, "/subscriptions/total?user_id=alice", "", nil, http.StatusBadRequest, "validation_error"},
+		{"delete without If-Match", http.MethodDelete, path, "", nil, http.StatusPreconditionRequired, "precondition_required"},
+		{"delete any version", http.MethodDelete, path, "", map[string]string{"If-Match": "*"}, http.StatusNoContent, ""},
+		{"get deleted", http.MethodGet, path, "", nil, http.StatusNotFound, "not_found"},
+		{"restore deleted", http.MethodPost, path + "/restore", "", nil, http.StatusOK, ""},
+	}
+	// Шаги выполняются по порядку: каждый следующий видит состояние после предыдущего
+	for _, tt := range tests {
+		w := api.do(tt.method, tt.path, tt.body, tt.headers)
+		if w.Code != tt.status {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.status, w.Code, w.Body.String())
+			continue
+		}
+		if tt.code != "" {
+			if code := errorCode(t, w); code != tt.code {
+				t.Errorf("%s: expected code %s, got %s", tt.name, tt.code, code)
+			}
+		}
+	}
+}
+
+func TestPreconditionFailedReturnsCurrentState(t *testing.T) {
+	api := newTestAPI(Options{RequireIfMatch: true}, false)
+	created := api.do(http.MethodPost, "/subscriptions/", subscriptionBody(10000), nil)
+	var sub model.Subscription
+	if err := json.Unmarshal(created.Body.Bytes(), &sub); err != nil {
+		t.Fatalf("create: invalid body: %v", err)
+	}
+
+	w := api.do(http.MethodPut, "/subscriptions/"+sub.ID, subscriptionBody(20000), map[string]string{"If-Match": `"42"`})
+	if w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412, got %d: %s", w.Code, w.Body.String())
+	}
+	var current model.Subscription
+	if err := json.Unmarshal(w.Body.Bytes(), &current); err != nil || current.ID != sub.ID || current.Price != 10000 {
+		t.Errorf("expected current subscription in body, got %s", w.Body.String())
+	}
+	if etag := w.Header().Get("ETag"); etag != formatETag(sub.Version) {
+		t.Errorf("expected ETag %s, got %q", formatETag(sub.Version), etag)
+	}
+}
+
+func TestTotalCostRoute(t *testing.T) {
+	api := newTestAPI(Options{}, false)
+	for _, price := range []int{10000, 5000} {
+		if w := api.do(http.MethodPost, "/subscriptions/", subscriptionBody(price), nil); w.Code != http.StatusCreated {
+			t.Fatalf("create: expected 201, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	w := api.do(http.MethodGet, "/subscriptions/total?user_id="+testUserID+"&start_date=01-2024&end_date=03-2024", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp TotalCostResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid body: %v", err)
+	}
+	if resp.TotalCost != 45000 || len(resp.Items) != 2 || resp.Currency != "RUB" {
+		t.Errorf("expected 45000 RUB over 2 items, got %d %s over %d items", resp.TotalCost, resp.Currency, len(resp.Items))
+	}
+}
+
+func TestAuthorizationRoutes(t *testing.T) {
+	api := newTestAPI(Options{}, true)
+	admin := api.issueKey(t, adminUserID, model.RoleAdmin)
+	user := api.issueKey(t, testUserID, model.RoleUser)
+
+	// Подписка другого пользователя, созданная администратором
+	otherBody := strings.Replace(subscriptionBody(10000), testUserID, "550e8400-e29b-41d4-a716-446655440000", 1)
+	created := api.do(http.MethodPost, "/subscriptions/", otherBody, map[string]string{"X-API-Key": admin})
+	if created.Code != http.StatusCreated {
+		t.Fatalf("create as admin: expected 201, got %d: %s", created.Code, created.Body.String())
+	}
+	var other model.Subscription
+	if err := json.Unmarshal(created.Body.Bytes(), &other); err != nil {
+		t.Fatalf("create as admin: invalid body: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		key    string
+		status int
+		code   string
+	}{
+		{"no credentials", http.MethodGet, "/subscriptions/", "", "", http.StatusUnauthorized, "unauthorized"},
+		{"unknown key", http.MethodGet, "/subscriptions/", "", "ssk_unknown", http.StatusUnauthorized, "unauthorized"},
+		{"user lists own", http.MethodGet, "/subscriptions/", "", user, http.StatusOK, ""},
+		{"user reads foreign", http.MethodGet, "/subscriptions/" + other.ID, "", user, http.StatusNotFound, "not_found"},
+		{"user creates foreign", http.MethodPost, "/subscriptions/", otherBody, user, http.StatusBadRequest, "validation_error"},
+		{"user creates own", http.MethodPost, "/subscriptions/", subscriptionBody(10000), user, http.StatusCreated, ""},
+		{"user lists keys", http.MethodGet, "/api-keys/", "", user, http.StatusForbidden, "forbidden"},
+		{"user reads audit", http.MethodGet, "/audit/", "", user, http.StatusForbidden, "forbidden"},
+		{"user changes catalog", http.MethodPost, "/services/", `{"name":"Netflix"}`, user, http.StatusForbidden, "forbidden"},
+		{"admin reads foreign", http.MethodGet, "/subscriptions/" + other.ID, "", admin, http.StatusOK, ""},
+		{"admin lists keys", http.MethodGet, "/api-keys/", "", admin, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		headers := map[string]string{}
+		if tt.key != "" {
+			headers["X-API-Key"] = tt.key
+		}
+		w := api.do(tt.method, tt.path, tt.body, headers)
+		if w.Code != tt.status {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.status, w.Code, w.Body.String())
+			continue
+		}
+		if tt.code != "" {
+			if code := errorCode(t, w); code != tt.code {
+				t.Errorf("%s: expected code %s, got %s", tt.name, tt.code, code)
+			}
+		}
+	}
+}
+
+func TestTranslateError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"validation", service.NewValidationError("price", "price must be positive"), http.StatusBadRequest, "validation_error"},
+		{"binding", &bindingError{err: errors.New("EOF")}, http.StatusBadRequest, "bad_request"},
+		{"no credentials", auth.ErrNoCredentials, http.StatusUnauthorized, "unauthorized"},
+		{"invalid credentials", auth.ErrInvalidCredentials, http.StatusUnauthorized, "unauthorized"},
+		{"forbidden", errForbidden, http.StatusForbidden, "forbidden"},
+		{"not found", service.ErrNotFound, http.StatusNotFound, "not_found"},
+		{"wrapped not found", fmt.Errorf("batch item 2: %w", service.ErrNotFound), http.StatusNotFound, "not_found"},
+		{"conflict", service.ErrConflict, http.StatusConflict, "conflict"},
+		{"precondition failed", service.ErrPreconditionFailed, http.StatusPreconditionFailed, "precondition_failed"},
+		{"payload too large", errPayloadTooLarge, http.StatusRequestEntityTooLarge, "payload_too_large"},
+		{"unsupported media type", errUnsupportedMediaType, http.StatusUnsupportedMediaType, "unsupported_media_type"},
+		{"If-Match required", errIfMatchRequired, http.StatusPreconditionRequired, "precondition_required"},
+		{"rate limited", errRateLimited, http.StatusTooManyRequests, "rate_limited"},
+		{"unavailable", service.ErrUnavailable, http.StatusServiceUnavailable, "unavailable"},
+		{"unexpected", errors.New("boom"), http.StatusInternalServerError, "internal_error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := translateError(tt.err)
+			if status != tt.status || resp.Code != tt.code {
+				t.Errorf("expected %d %s, got %d %s", tt.status, tt.code, status, resp.Code)
+			}
+		})
+	}
+}
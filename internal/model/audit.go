@@ -0,0 +1,68 @@
+package model
+
+import "time"
+
+// Действия журнала аудита
+const (
+	AuditCreate      = "create"
+	AuditUpdate      = "update"
+	AuditDelete      = "delete"
+	AuditRestore     = "restore"
+	AuditPause       = "pause"
+	AuditResume      = "resume"
+	AuditCancel      = "cancel"
+	AuditSetPrice    = "set_price"
+	AuditDeletePrice = "delete_price"
+)
+
+// Actor — тот, от чьего имени выполняется изменение, и запрос, в котором оно сделано
+type Actor struct {
+	// Subject — ID пользователя или sub токена; пусто, если аутентификация отключена
+	Subject   string
+	Role      string
+	Method    string
+	RequestID string
+}
+
+// FieldChange — значение поля до и после изменения; null — поля не было
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// AuditEntry — запись журнала аудита об изменении подписки
+// @Description Запись журнала аудита: кто, когда и как изменил подписку
+type AuditEntry struct {
+	ID             int64                  `json:"id" example:"42"`
+	SubscriptionID string                 `json:"subscription_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	OrganizationID string                 `json:"organization_id" example:"0b5d8f3e-2c4a-4e6b-9d1f-3a5c7e9b1d2f"`
+	UserID         string                 `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"` // владелец подписки
+	Action         string                 `json:"action" example:"update" enums:"create,update,delete,restore,pause,resume,cancel,set_price,delete_price"`
+	Actor          string                 `json:"actor" example:"00000000-0000-0000-0000-0000000000aa"` // пусто, если аутентификация отключена
+	ActorRole      string                 `json:"actor_role,omitempty" example:"admin"`
+	AuthMethod     string                 `json:"auth_method,omitempty" example:"api_key"`
+	RequestID      string                 `json:"request_id,omitempty" example:"900d311d-45cc-4eb2-a4ae-367975170cb5"`
+	Changes        map[string]FieldChange `json:"changes"`
+	CreatedAt      time.Time              `json:"created_at" example:"2025-01-01T12:00:00Z"`
+}
+
+// AuditFilter — условия выборки из журнала аудита. Записи возвращаются от новых к старым.
+type AuditFilter struct {
+	SubscriptionID string
+	UserID         string
+	Actor          string
+	Action         string
+	RequestID      string
+	// From и To ограничивают время записи включительно
+	From *time.Time
+	To   *time.Time
+	// BeforeID — продолжение выборки: только записи с меньшим ID
+	BeforeID int64
+	Limit    int
+}
+
+// AuditPage — страница журнала аудита и курсор следующей страницы
+type AuditPage struct {
+	Items      []AuditEntry
+	NextCursor string
+}
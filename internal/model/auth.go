@@ -0,0 +1,76 @@
+package model
+
+import "time"
+
+// Роли вызывающих API
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Способы аутентификации
+const (
+	AuthMethodAPIKey = "api_key"
+	AuthMethodJWT    = "jwt"
+)
+
+// Principal — аутентифицированный вызывающий: владелец API-ключа или субъект JWT
+type Principal struct {
+	// Subject — ID пользователя (user_id подписок) или sub токена; всегда UUID,
+	// иначе AuthMiddleware отклоняет запрос
+	Subject string
+	Role    string
+	Method  string
+	// KeyID — ID API-ключа или kid ключа, которым подписан токен
+	KeyID string
+	// OrganizationID — организация, к которой привязан ключ или токен (claim org_id);
+	// пусто, если вызывающий может работать с любой организацией
+	OrganizationID string
+}
+
+// IsAdmin сообщает, может ли вызывающий действовать от имени любого пользователя
+func (p *Principal) IsAdmin() bool {
+	return p != nil && p.Role == RoleAdmin
+}
+
+// Scope возвращает область данных, доступных вызывающему: администратору — все подписки
+// его организации, пользователю — только свои
+func (p *Principal) Scope() Scope {
+	if p == nil {
+		return Scope{}
+	}
+	scope := Scope{OrganizationID: p.OrganizationID}
+	if !p.IsAdmin() {
+		scope.UserID = p.Subject
+	}
+	return scope
+}
+
+// Scope ограничивает данные, с которыми работают сервис и хранилище; пустое поле не ограничивает
+type Scope struct {
+	// OrganizationID — организация (тенант): данные других организаций не видны, как будто их нет
+	OrganizationID string
+	// UserID — владелец подписок: чужие подписки не видны, как будто их нет
+	UserID string
+}
+
+// APIKey — статический ключ доступа к API. Сам ключ не хранится, только его хеш.
+// @Description API-ключ (без секрета)
+type APIKey struct {
+	ID             string     `json:"id" example:"3c1e2f4a-8b7d-4c6e-9f0a-1b2c3d4e5f60"`
+	Name           string     `json:"name" example:"billing-export"`
+	Prefix         string     `json:"prefix" example:"ssk_Ab3dE9"` // начало ключа, чтобы его можно было узнать
+	Hash           string     `json:"-"`
+	UserID         string     `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	Role           string     `json:"role" example:"user" enums:"user,admin"`
+	OrganizationID string     `json:"organization_id,omitempty" example:"0b5d8f3e-2c4a-4e6b-9d1f-3a5c7e9b1d2f"` // пусто — ключ работает с любой организацией
+	CreatedAt      time.Time  `json:"created_at" example:"2025-01-01T12:00:00Z"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty" example:"2026-01-01T00:00:00Z"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty" example:"2025-03-01T08:30:00Z"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty" example:"2025-06-01T00:00:00Z"`
+}
+
+// Active сообщает, действует ли ключ в момент now
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || k.ExpiresAt.After(now))
+}
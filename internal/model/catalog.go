@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// Service — сервис из каталога. Подписки ссылаются на него по ID, а при создании подписки
+// название сервиса сопоставляется с Name и Aliases без учёта регистра.
+// @Description Сервис из каталога
+type Service struct {
+	ID           string    `json:"id" example:"7a1f3c2e-5b4d-4e8f-9a6b-1c2d3e4f5a6b"`
+	Name         string    `json:"name" example:"Netflix"`
+	Aliases      []string  `json:"aliases" example:"netflix.com,Netflix Premium"`
+	Category     string    `json:"category" example:"streaming"`
+	DefaultPrice *int      `json:"default_price,omitempty" example:"99900"` // в минимальных единицах Currency
+	Currency     string    `json:"currency" example:"RUB"`
+	VendorURL    string    `json:"vendor_url" example:"https://www.netflix.com"`
+	CreatedAt    time.Time `json:"created_at" example:"2024-01-01T00:00:00Z"`
+	UpdatedAt    time.Time `json:"updated_at" example:"2024-01-01T00:00:00Z"`
+}
+
+// ServiceFilter выбирает сервисы каталога; пустые поля не ограничивают выборку
+type ServiceFilter struct {
+	Category string
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// Типы событий ленты изменений
+const (
+	ChangeCreated  = "created"
+	ChangeUpdated  = "updated"
+	ChangeDeleted  = "deleted"
+	ChangeRestored = "restored"
+)
+
+// SubscriptionChange — событие ленты изменений подписок
+// @Description Событие изменения подписки. Для удаления subscription не заполняется (tombstone).
+type SubscriptionChange struct {
+	Sequence       int64         `json:"-"`
+	Type           string        `json:"type" example:"updated" enums:"created,updated,deleted,restored"`
+	SubscriptionID string        `json:"subscription_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Subscription   *Subscription `json:"subscription,omitempty"`
+	ChangedAt      time.Time     `json:"changed_at" example:"2024-01-01T00:00:00Z"`
+	// Организация и владелец подписки на момент события; по ним лента ограничивается областью вызывающего
+	OrganizationID string `json:"-"`
+	UserID         string `json:"-"`
+}
+
+// ChangePage — порция событий ленты и токен для продолжения чтения
+type ChangePage struct {
+	Events    []SubscriptionChange
+	NextToken string
+	HasMore   bool
+}
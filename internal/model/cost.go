@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+// CostFilter выбирает подписки для подсчёта стоимости.
+// Нулевые StartDate и EndDate означают отсутствие ограничения с соответствующей стороны.
+type CostFilter struct {
+	UserID      string
+	ServiceName string
+	ServiceID   string
+	Category    string
+	// Tags выбирает подписки хотя бы с одним из тегов, а при MatchAllTags — со всеми
+	Tags           []string
+	MatchAllTags   bool
+	StartDate      time.Time
+	EndDate        time.Time
+	IncludeDeleted bool
+}
+
+// SubscriptionCost показывает вклад одной подписки в общую стоимость за период
+// @Description Стоимость подписки за запрошенный период
+type SubscriptionCost struct {
+	SubscriptionID string `json:"subscription_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	ServiceName    string `json:"service_name" example:"Netflix"`
+	UserID         string `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	Price          int    `json:"price" example:"99900"` // начальная цена в валюте подписки; изменения цен учтены в cost
+	Currency       string `json:"currency" example:"USD"`
+	BillingPeriod  string `json:"billing_period" example:"monthly"`
+	IntervalCount  int    `json:"interval_count" example:"1"`
+	From           string `json:"from" example:"01-2024"`
+	To             string `json:"to" example:"12-2024"`
+	Months         int    `json:"months" example:"12"`       // месяцы с долей оплаченных циклов
+	PausedMonths   int    `json:"paused_months" example:"0"` // месяцы, циклы которых пропущены из-за паузы
+	Cost           int    `json:"cost" example:"1198800"`    // в валюте отчёта, помесячно по курсу месяца
+	CostCurrency   string `json:"cost_currency" example:"RUB"`
+}
+
+// TotalCost содержит итоговую стоимость и её разбивку по подпискам
+type TotalCost struct {
+	Currency  string
+	TotalCost int
+	Items     []SubscriptionCost
+}
@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// SubscriptionKey определяет дубликаты при импорте: одна и та же подписка пользователя
+// на сервис с одной датой начала. StartDate — дата в UTC без времени.
+type SubscriptionKey struct {
+	UserID      string
+	ServiceName string
+	StartDate   time.Time
+}
+
+// ImportRowError описывает ошибку в строке импортируемого файла
+type ImportRowError struct {
+	Row     int    `json:"row" example:"7"`
+	Field   string `json:"field,omitempty" example:"start_date"`
+	Message string `json:"message" example:"invalid start_date format, expected MM-YYYY"`
+}
+
+// ImportResult — итог импорта или его пробного прогона
+// @Description Итог импорта подписок
+type ImportResult struct {
+	DryRun bool `json:"dry_run" example:"false"`
+	// Rows — число непустых строк данных (без заголовка)
+	Rows int `json:"rows" example:"120"`
+	// Valid — строки, прошедшие проверку; при dry_run=false, если импорт не прерван, все они импортированы
+	Valid      int `json:"valid" example:"117"`
+	Imported   int `json:"imported" example:"117"`
+	Duplicates int `json:"duplicates" example:"1"`
+	Invalid    int `json:"invalid" example:"2"`
+	// Errors — ошибки по строкам; номер строки считается как в таблице, заголовок — строка 1
+	Errors          []ImportRowError `json:"errors"`
+	ErrorsTruncated bool             `json:"errors_truncated,omitempty" example:"false"`
+	// Aborted — импорт прерван сбоем чтения файла или хранилища после того, как часть строк
+	// уже сохранена; AbortReason описывает сбой
+	Aborted     bool   `json:"aborted,omitempty" example:"false"`
+	AbortReason string `json:"abort_reason,omitempty" example:"service temporarily unavailable"`
+	// CommittedThroughRow — при прерванном импорте строки до этой включительно обработаны
+	// и сохранены, а следующие не импортированы
+	CommittedThroughRow int `json:"committed_through_row,omitempty" example:"1001"`
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// PriceChange — изменение цены подписки: с EffectiveFrom списания идут по цене Price
+// до следующего изменения. До первого изменения действует цена из самой подписки.
+// @Description Изменение цены подписки с даты
+type PriceChange struct {
+	SubscriptionID string    `json:"subscription_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	EffectiveFrom  time.Time `json:"effective_from" example:"2025-03-01T00:00:00Z"`
+	Price          int       `json:"price" example:"119900"` // в минимальных единицах валюты подписки
+	UpdatedAt      time.Time `json:"updated_at" example:"2025-02-20T12:00:00Z"`
+}
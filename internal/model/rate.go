@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// ExchangeRate — курс валюты на дату: одна единица BaseCurrency стоит Rate единиц QuoteCurrency.
+// Курс действует с Date до следующего курса той же пары.
+// @Description Курс обмена валют на дату
+type ExchangeRate struct {
+	BaseCurrency  string    `json:"base_currency" example:"USD"`
+	QuoteCurrency string    `json:"quote_currency" example:"RUB"`
+	Date          time.Time `json:"date" example:"2025-01-01T00:00:00Z"`
+	Rate          string    `json:"rate" example:"92.45"`
+	UpdatedAt     time.Time `json:"updated_at" example:"2025-01-01T12:00:00Z"`
+}
+
+// ExchangeRateFilter выбирает курсы; пустые поля не ограничивают выборку
+type ExchangeRateFilter struct {
+	BaseCurrency  string
+	QuoteCurrency string
+	From          *time.Time
+	To            *time.Time
+}
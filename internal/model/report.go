@@ -0,0 +1,50 @@
+package model
+
+// Измерения, по которым группируется отчёт о расходах
+const (
+	GroupByMonth       = "month"
+	GroupByServiceName = "service_name"
+	GroupByUserID      = "user_id"
+	GroupByCategory    = "category"
+)
+
+// SpendRow — расходы одной группы отчёта. Поля измерений, не входящих в группировку, пустые.
+// @Description Расходы группы подписок
+type SpendRow struct {
+	Month         string `json:"month,omitempty" example:"01-2025"`
+	ServiceName   string `json:"service_name,omitempty" example:"Netflix"`
+	UserID        string `json:"user_id,omitempty" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	Category      string `json:"category,omitempty" example:"streaming"`
+	Spend         int    `json:"spend" example:"99900"`     // в валюте отчёта
+	Subscriptions int    `json:"subscriptions" example:"1"` // подписки, давшие вклад в группу
+}
+
+// SpendReport — помесячные расходы, сгруппированные по измерениям GroupBy
+type SpendReport struct {
+	GroupBy  []string
+	Currency string
+	// Months — все месяцы периода отчёта по порядку, ось для временного ряда
+	Months []string
+	Total  int
+	Rows   []SpendRow
+}
+
+// TagSpendRow — расходы подписок с тегом Tag; пустой Tag объединяет подписки без тегов
+// @Description Расходы подписок с тегом
+type TagSpendRow struct {
+	Tag           string `json:"tag" example:"streaming"`
+	Month         string `json:"month,omitempty" example:"01-2025"`
+	Spend         int    `json:"spend" example:"99900"`     // в валюте отчёта
+	Subscriptions int    `json:"subscriptions" example:"1"` // подписки с тегом, давшие вклад в строку
+}
+
+// TagReport — расходы по тегам. Подписка с несколькими тегами входит в строку каждого из них,
+// поэтому сумма строк может быть больше Total.
+type TagReport struct {
+	GroupBy  []string
+	Currency string
+	Months   []string
+	// Total — расходы на все подписки отчёта, каждая учтена один раз
+	Total int
+	Rows  []TagSpendRow
+}
@@ -4,30 +4,186 @@ import (
 	"time"
 )
 
+// Статусы жизненного цикла подписки
+const (
+	StatusActive    = "active"
+	StatusPaused    = "paused"
+	StatusCancelled = "cancelled"
+	// StatusExpired не хранится: активная или приостановленная подписка считается истёкшей,
+	// на следующий день после end_date
+	StatusExpired = "expired"
+)
+
+// DefaultOrganizationID — организация подписок, созданных до разделения данных по организациям
+// или без указания организации
+const DefaultOrganizationID = "00000000-0000-0000-0000-000000000000"
+
+// Периоды оплаты подписки
+const (
+	PeriodWeekly    = "weekly"
+	PeriodMonthly   = "monthly"
+	PeriodQuarterly = "quarterly"
+	PeriodYearly    = "yearly"
+)
+
 // Subscription представляет подписку пользователя
 // @Description Модель подписки пользователя
 type Subscription struct {
-	ID          string     `json:"id" example:"550e8400-e29b-41d4-a716-446655440000" db:"id"`
-	ServiceName string     `json:"service_name" example:"Netflix" db:"service_name"`
-	Price       int        `json:"price" example:"999" db:"price"`
-	UserID      string     `json:"user_id" example:"user123" db:"user_id"`
-	StartDate   time.Time  `json:"start_date" example:"2024-01-01T00:00:00Z" db:"start_date"`
-	EndDate     *time.Time `json:"end_date,omitempty" example:"2024-12-31T00:00:00Z" db:"end_date"`
-	CreatedAt   time.Time  `json:"created_at" example:"2024-01-01T00:00:00Z" db:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at" example:"2024-01-01T00:00:00Z" db:"updated_at"`
-}
-
-// NewSubscription создаёт новую подписку
-func NewSubscription(id, serviceName string, price int, userID string, startDate time.Time, endDate *time.Time) *Subscription {
+	ID             string     `json:"id" example:"550e8400-e29b-41d4-a716-446655440000" db:"id"`
+	ServiceID      *string    `json:"service_id,omitempty" example:"7a1f3c2e-5b4d-4e8f-9a6b-1c2d3e4f5a6b" db:"service_id"` // сервис из каталога
+	ServiceName    string     `json:"service_name" example:"Netflix" db:"service_name"`
+	Price          int        `json:"price" example:"99900" db:"price"` // в минимальных единицах валюты (копейках, центах)
+	Currency       string     `json:"currency" example:"RUB" db:"currency"`
+	BillingPeriod  string     `json:"billing_period" example:"monthly" enums:"weekly,monthly,quarterly,yearly" db:"billing_period"`
+	IntervalCount  int        `json:"interval_count" example:"1" db:"interval_count"` // Price списывается раз в IntervalCount периодов BillingPeriod
+	Category       string     `json:"category" example:"streaming" db:"category"`
+	Tags           []string   `json:"tags" example:"family,work" db:"tags"` // в нижнем регистре, упорядочены
+	UserID         string     `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba" db:"user_id"`
+	OrganizationID string     `json:"organization_id" example:"0b5d8f3e-2c4a-4e6b-9d1f-3a5c7e9b1d2f" db:"organization_id"` // организация (клиент); задаётся при создании и не меняется
+	StartDate      time.Time  `json:"start_date" example:"2024-01-01T00:00:00Z" db:"start_date"`
+	EndDate        *time.Time `json:"end_date,omitempty" example:"2024-12-31T00:00:00Z" db:"end_date"` // последний день действия, включительно
+	Status         string     `json:"status" example:"active" enums:"active,paused,cancelled,expired" db:"status"`
+	Version        int64      `json:"version" example:"1" db:"version"`
+	CreatedAt      time.Time  `json:"created_at" example:"2024-01-01T00:00:00Z" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" example:"2024-01-01T00:00:00Z" db:"updated_at"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty" example:"2024-06-01T00:00:00Z" db:"deleted_at"`
+}
+
+// NewSubscription создаёт новую подписку с идентификатором id из проверенных данных data
+func NewSubscription(id string, data Subscription) *Subscription {
 	now := time.Now()
 	return &Subscription{
-		ID:          id,
-		ServiceName: serviceName,
-		Price:       price,
-		UserID:      userID,
-		StartDate:   startDate,
-		EndDate:     endDate,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+		ID:             id,
+		ServiceID:      data.ServiceID,
+		ServiceName:    data.ServiceName,
+		Price:          data.Price,
+		Currency:       data.Currency,
+		BillingPeriod:  data.BillingPeriod,
+		IntervalCount:  data.IntervalCount,
+		Category:       data.Category,
+		Tags:           data.Tags,
+		UserID:         data.UserID,
+		OrganizationID: data.OrganizationID,
+		StartDate:      data.StartDate,
+		EndDate:        data.EndDate,
+		Status:         StatusActive,
+		Version:        1,
+		CreatedAt:      now,
+		UpdatedAt:      now,
 	}
 }
+
+// EffectiveStatus возвращает статус с учётом автоматического истечения на момент now:
+// подписка истекает на следующий день после end_date
+func (s *Subscription) EffectiveStatus(now time.Time) string {
+	if s.Status != StatusActive && s.Status != StatusPaused {
+		return s.Status
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if s.EndDate != nil && s.EndDate.Before(today) {
+		return StatusExpired
+	}
+	return s.Status
+}
+
+// AddCycles сдвигает дату t на k периодов оплаты подписки. Если в целевом месяце нет
+// такого числа (подписка с 31 января), берётся последний день месяца.
+func (s *Subscription) AddCycles(t time.Time, k int) time.Time {
+	count := s.IntervalCount
+	if count < 1 {
+		count = 1
+	}
+
+	var months int
+	switch s.BillingPeriod {
+	case PeriodWeekly:
+		return t.AddDate(0, 0, 7*count*k)
+	case PeriodQuarterly:
+		months = 3 * count * k
+	case PeriodYearly:
+		months = 12 * count * k
+	default:
+		months = count * k
+	}
+
+	year, month := t.Year(), t.Month()+time.Month(months)
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, t.Location()).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(year, month, day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+// CycleStart возвращает дату k-го списания (с нуля); каждый цикл отсчитывается от StartDate,
+// поэтому короткие месяцы не сдвигают последующие даты
+func (s *Subscription) CycleStart(k int) time.Time {
+	return s.AddCycles(s.StartDate, k)
+}
+
+// HasTags сообщает, есть ли у подписки хотя бы один из тегов tags, а при all — все они
+func (s *Subscription) HasTags(tags []string, all bool) bool {
+	for _, tag := range tags {
+		found := false
+		for _, t := range s.Tags {
+			if t == tag {
+				found = true
+				break
+			}
+		}
+		if found && !all {
+			return true
+		}
+		if !found && all {
+			return false
+		}
+	}
+	return all
+}
+
+// Pause — период, в течение которого подписка была приостановлена.
+// Цикл оплаты не оплачивается, если в день списания подписка была на паузе.
+type Pause struct {
+	PausedAt  time.Time  `json:"paused_at" example:"2024-03-15T10:00:00Z"`
+	ResumedAt *time.Time `json:"resumed_at,omitempty" example:"2024-05-02T10:00:00Z"`
+}
+
+// Covers сообщает, была ли подписка на паузе в момент at
+func (p Pause) Covers(at time.Time) bool {
+	return !p.PausedAt.After(at) && (p.ResumedAt == nil || p.ResumedAt.After(at))
+}
+
+// SubscriptionFilter описывает фильтры, сортировку и пагинацию списка подписок
+type SubscriptionFilter struct {
+	UserID      string
+	ServiceName string
+	Currency    string
+	MinPrice    *int
+	MaxPrice    *int
+	Category    string
+	// Tags выбирает подписки хотя бы с одним из тегов, а при MatchAllTags — со всеми
+	Tags         []string
+	MatchAllTags bool
+	// ActiveFrom и ActiveTo — подписка действует хотя бы один день отрезка [ActiveFrom, ActiveTo]
+	ActiveFrom     *time.Time
+	ActiveTo       *time.Time
+	UpdatedSince   *time.Time
+	Status         string
+	IncludeDeleted bool
+	Sort           string
+	Order          string
+	Limit          int
+	After          *ListCursor
+}
+
+// ListCursor указывает позицию в отсортированном списке: значение поля сортировки и ID
+type ListCursor struct {
+	Value interface{}
+	ID    string
+}
+
+// SubscriptionPage — страница списка подписок
+type SubscriptionPage struct {
+	Items      []Subscription
+	NextCursor string
+}
@@ -0,0 +1,98 @@
+// Package money содержит справочник валют ISO 4217 и пересчёт сумм в минимальных единицах.
+//
+// Суммы хранятся целым числом минимальных единиц валюты (копеек, центов);
+// число знаков после запятой у каждой валюты своё: у JPY их нет, у KWD — три.
+package money
+
+import (
+	"errors"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// DefaultCurrency — валюта подписок, созданных до появления мультивалютности
+const DefaultCurrency = "RUB"
+
+// exponents — число знаков после запятой для действующих валют ISO 4217
+var exponents = map[string]int{}
+
+func init() {
+	// Валюты без дробной части и с тремя-четырьмя знаками; у остальных два знака
+	for _, code := range strings.Fields("BIF CLP DJF GNF ISK JPY KMF KRW PYG RWF UGX UYI VND VUV XAF XOF XPF") {
+		exponents[code] = 0
+	}
+	for _, code := range strings.Fields("BHD IQD JOD KWD LYD OMR TND") {
+		exponents[code] = 3
+	}
+	for _, code := range strings.Fields("CLF UYW") {
+		exponents[code] = 4
+	}
+	for _, code := range strings.Fields(`AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BMD BND BOB BOV BRL BSD
+		BTN BWP BYN BZD CAD CDF CHE CHF CHW CNY COP COU CRC CUP CVE CZK DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL
+		GHS GIP GMD GTQ GYD HKD HNL HTG HUF IDR ILS INR IRR JMD KES KGS KHR KPW KYD KZT LAK LBP LKR LRD LSL MAD MDL
+		MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MXV MYR MZN NAD NGN NIO NOK NPR NZD PAB PEN PGK PHP PKR PLN QAR RON
+		RSD RUB SAR SBD SCR SDG SEK SGD SHP SLE SOS SRD SSP STN SVC SYP SZL THB TJS TMT TOP TRY TTD TWD TZS UAH USD
+		USN UYU UZS VES VED WST XCD YER ZAR ZMW ZWG`) {
+		exponents[code] = 2
+	}
+}
+
+// IsValidCurrency сообщает, является ли code кодом действующей валюты ISO 4217 (в верхнем регистре)
+func IsValidCurrency(code string) bool {
+	_, ok := exponents[code]
+	return ok
+}
+
+// ErrInvalidRate — курс не является положительным десятичным числом
+var ErrInvalidRate = errors.New("rate must be a positive decimal number")
+
+var ratePattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// ParseRate разбирает курс, записанный десятичным числом с точкой, например "92.45"
+func ParseRate(s string) (*big.Rat, error) {
+	if !ratePattern.MatchString(s) {
+		return nil, ErrInvalidRate
+	}
+	rate, ok := new(big.Rat).SetString(s)
+	if !ok || rate.Sign() <= 0 {
+		return nil, ErrInvalidRate
+	}
+	return rate, nil
+}
+
+// FormatRate записывает курс десятичным числом без лишних нулей в конце
+func FormatRate(rate *big.Rat) string {
+	s := rate.FloatString(10)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// Convert пересчитывает amount минимальных единиц валюты from в минимальные единицы валюты to
+// по курсу rate (сколько единиц to стоит одна единица from). Результат округляется
+// до ближайшей минимальной единицы, половина — от нуля.
+func Convert(amount int, from, to string, rate *big.Rat) int {
+	value := new(big.Rat).SetInt64(int64(amount))
+	value.Mul(value, rate)
+	value.Mul(value, new(big.Rat).SetInt(pow10(exponents[to])))
+	value.Quo(value, new(big.Rat).SetInt(pow10(exponents[from])))
+	return roundHalfAway(value)
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// roundHalfAway округляет дробь до целого, половину — от нуля
+func roundHalfAway(value *big.Rat) int {
+	num := new(big.Int).Abs(value.Num())
+	den := value.Denom()
+	// (2*|num| + den) / (2*den)
+	num.Mul(num, big.NewInt(2))
+	num.Add(num, den)
+	result := num.Quo(num, new(big.Int).Mul(den, big.NewInt(2)))
+	if value.Sign() < 0 {
+		result.Neg(result)
+	}
+	return int(result.Int64())
+}
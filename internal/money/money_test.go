@@ -0,0 +1,76 @@
+package money
+
+import "testing"
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount int
+		from   string
+		to     string
+		rate   string
+		want   int
+	}{
+		{"same exponent", 1599, "USD", "RUB", "92.45", 147828},
+		{"same currency", 99900, "RUB", "RUB", "1", 99900},
+		// 1000 иен по 0.002 динара — 2 динара, то есть 2000 филсов
+		{"no decimals to three", 1000, "JPY", "KWD", "0.002", 2000},
+		{"half rounds away from zero", 1, "JPY", "KWD", "0.0025", 3},
+		{"below half rounds down", 1, "JPY", "KWD", "0.0024", 2},
+		// 1 филс по 490.5 иены — 0.4905 иены
+		{"three decimals to none, down", 1, "KWD", "JPY", "490.5", 0},
+		{"three decimals to none, up", 2, "KWD", "JPY", "490.5", 1},
+		{"four decimals", 10000, "CLF", "USD", "40", 4000},
+		{"negative half", -1, "JPY", "USD", "0.005", -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, err := ParseRate(tt.rate)
+			if err != nil {
+				t.Fatalf("ParseRate(%q): %v", tt.rate, err)
+			}
+			if got := Convert(tt.amount, tt.from, tt.to, rate); got != tt.want {
+				t.Errorf("Convert(%d %s -> %s at %s) = %d, expected %d", tt.amount, tt.from, tt.to, tt.rate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		valid bool
+	}{
+		{"92.45", "92.45", true},
+		{"0.0001", "0.0001", true},
+		{"100", "100", true},
+		{"1.500", "1.5", true},
+		{"0", "", false},
+		{"0.000", "", false},
+		{"-1", "", false},
+		{"1e3", "", false},
+		{"1,5", "", false},
+		{".5", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			rate, err := ParseRate(tt.value)
+			if (err == nil) != tt.valid {
+				t.Fatalf("expected valid %t, got %v", tt.valid, err)
+			}
+			if tt.valid && FormatRate(rate) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, FormatRate(rate))
+			}
+		})
+	}
+}
+
+func TestIsValidCurrency(t *testing.T) {
+	for code, valid := range map[string]bool{"RUB": true, "JPY": true, "KWD": true, "CLF": true, "rub": false, "XYZ": false, "": false} {
+		if IsValidCurrency(code) != valid {
+			t.Errorf("IsValidCurrency(%q): expected %t", code, valid)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval — как часто MemoryStore удаляет корзины, которые успели наполниться
+const sweepInterval = time.Minute
+
+// bucket — состояние корзины на момент updated
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	// full — когда корзина наполнится, если из неё больше не брать токены
+	full time.Time
+}
+
+// MemoryStore хранит корзины в памяти процесса. Подходит для одного экземпляра сервиса:
+// у каждого экземпляра свои корзины.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryStore создаёт пустое хранилище корзин в памяти
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Take пополняет корзину ключа за прошедшее время и забирает из неё токен, если он есть
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	if !limit.Enabled() {
+		return Result{Allowed: true}, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	capacity := float64(limit.Burst)
+	b, ok := s.buckets[key]
+	if !ok {
+		// Новая корзина полная
+		b = &bucket{tokens: capacity, updated: now}
+		s.buckets[key] = b
+	} else if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+elapsed*limit.Rate)
+		b.updated = now
+	}
+
+	result := Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - b.tokens) / limit.Rate)
+	}
+	result.Remaining = int(math.Floor(b.tokens))
+	result.Reset = secondsToDuration((capacity - b.tokens) / limit.Rate)
+	b.full = now.Add(result.Reset)
+	return result, nil
+}
+
+// sweep раз в sweepInterval удаляет наполнившиеся корзины: новая корзина для того же
+// ключа будет такой же полной, а память не растёт с числом разных клиентов
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// secondsToDuration переводит дробные секунды в time.Duration
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeClock — управляемое время для MemoryStore
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// newTestStore создаёт хранилище с управляемым временем
+func newTestStore() (*MemoryStore, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	store := NewMemoryStore()
+	store.now = func() time.Time { return clock.now }
+	return store, clock
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	store, clock := newTestStore()
+	limit := Limit{Rate: 2, Burst: 3}
+
+	steps := []struct {
+		name       string
+		advance    time.Duration
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+		reset      time.Duration
+	}{
+		{"new bucket is full", 0, true, 2, 0, 500 * time.Millisecond},
+		{"second token", 0, true, 1, 0, time.Second},
+		{"last token", 0, true, 0, 0, 1500 * time.Millisecond},
+		{"empty bucket", 0, false, 0, 500 * time.Millisecond, 1500 * time.Millisecond},
+		{"partly refilled", 250 * time.Millisecond, false, 0, 250 * time.Millisecond, 1250 * time.Millisecond},
+		{"refilled one token", 250 * time.Millisecond, true, 0, 0, 1500 * time.Millisecond},
+		// Пополнение не превышает ёмкость корзины
+		{"long idle", time.Hour, true, 2, 0, 500 * time.Millisecond},
+	}
+	for _, step := range steps {
+		clock.advance(step.advance)
+		result, err := store.Take(context.Background(), "key", limit)
+		if err != nil {
+			t.Fatalf("%s: Take: %v", step.name, err)
+		}
+		want := Result{Allowed: step.allowed, Limit: 3, Remaining: step.remaining, RetryAfter: step.retryAfter, Reset: step.reset}
+		if result != want {
+			t.Errorf("%s: expected %+v, got %+v", step.name, want, result)
+		}
+	}
+}
+
+func TestMemoryStoreKeysAreIndependent(t *testing.T) {
+	store, _ := newTestStore()
+	limit := Limit{Rate: 1, Burst: 1}
+
+	if result, _ := store.Take(context.Background(), "a", limit); !result.Allowed {
+		t.Fatal("expected first request of a to be allowed")
+	}
+	if result, _ := store.Take(context.Background(), "a", limit); result.Allowed {
+		t.Error("expected second request of a to be rejected")
+	}
+	if result, _ := store.Take(context.Background(), "b", limit); !result.Allowed {
+		t.Error("expected b to have its own bucket")
+	}
+}
+
+func TestMemoryStoreDisabledLimit(t *testing.T) {
+	store, _ := newTestStore()
+	for i := 0; i < 3; i++ {
+		if result, _ := store.Take(context.Background(), "key", Limit{}); !result.Allowed {
+			t.Fatal("expected zero limit to allow every request")
+		}
+	}
+	if len(store.buckets) != 0 {
+		t.Errorf("expected no buckets for disabled limit, got %d", len(store.buckets))
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	store, clock := newTestStore()
+	limit := Limit{Rate: 0.1, Burst: 10}
+
+	// Первый вызов сразу выполняет очистку, дальше она идёт не чаще раза в sweepInterval
+	store.Take(context.Background(), "idle", limit)
+	clock.advance(5 * time.Second)
+	for i := 0; i < 10; i++ {
+		store.Take(context.Background(), "busy", limit)
+	}
+
+	// К следующей очистке idle наполнилась, а busy ещё нет
+	clock.advance(sweepInterval - 5*time.Second)
+	store.Take(context.Background(), "other", limit)
+	if _, ok := store.buckets["idle"]; ok {
+		t.Error("expected full bucket to be swept")
+	}
+	if _, ok := store.buckets["busy"]; !ok {
+		t.Error("expected bucket that is still refilling to be kept")
+	}
+
+	// Удалённая корзина создаётся заново полной
+	result, _ := store.Take(context.Background(), "idle", limit)
+	if !result.Allowed || result.Remaining != 9 {
+		t.Errorf("expected recreated bucket to be full, got %+v", result)
+	}
+}
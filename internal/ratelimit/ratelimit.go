@@ -0,0 +1,79 @@
+// Package ratelimit ограничивает частоту запросов алгоритмом token bucket.
+//
+// Каждому ключу (API-ключу, пользователю или IP-адресу) соответствует корзина на Burst
+// токенов, которая пополняется со скоростью Rate токенов в секунду; запрос забирает один
+// токен, а при пустой корзине отклоняется. Корзины хранятся в Store: в памяти процесса
+// (MemoryStore) или в общем хранилище, если экземпляров сервиса несколько.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit — параметры корзины
+type Limit struct {
+	// Rate — скорость пополнения, токенов в секунду
+	Rate float64
+	// Burst — ёмкость корзины: сколько запросов можно выполнить подряд
+	Burst int
+}
+
+// Enabled сообщает, действует ли ограничение; нулевой Limit ничего не ограничивает
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// String возвращает лимит в формате ParseLimit
+func (l Limit) String() string {
+	return strconv.FormatFloat(l.Rate, 'f', -1, 64) + ":" + strconv.Itoa(l.Burst)
+}
+
+// ParseLimit разбирает лимит вида "rate:burst", например "10:20" — 10 запросов в секунду
+// и до 20 подряд. Без burst ёмкость корзины равна скорости, округлённой вверх;
+// "0" отключает ограничение.
+func ParseLimit(value string) (Limit, error) {
+	rateValue, burstValue, hasBurst := strings.Cut(strings.TrimSpace(value), ":")
+	rate, err := strconv.ParseFloat(strings.TrimSpace(rateValue), 64)
+	if err != nil || rate < 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+		return Limit{}, fmt.Errorf("invalid rate %q: must be a non-negative number", rateValue)
+	}
+	if rate == 0 {
+		return Limit{}, nil
+	}
+
+	burst := int(math.Ceil(rate))
+	if hasBurst {
+		burst, err = strconv.Atoi(strings.TrimSpace(burstValue))
+		if err != nil || burst <= 0 {
+			return Limit{}, fmt.Errorf("invalid burst %q: must be a positive integer", burstValue)
+		}
+	}
+	return Limit{Rate: rate, Burst: burst}, nil
+}
+
+// Result — итог попытки забрать токен
+type Result struct {
+	// Allowed — токен получен, запрос можно выполнять
+	Allowed bool
+	// Limit — ёмкость корзины
+	Limit int
+	// Remaining — сколько целых токенов осталось в корзине
+	Remaining int
+	// RetryAfter — через сколько появится следующий токен; ноль, если запрос разрешён
+	RetryAfter time.Duration
+	// Reset — через сколько корзина наполнится полностью
+	Reset time.Duration
+}
+
+// Store хранит корзины и атомарно забирает из них токены. Реализация для общего
+// хранилища должна выполнять пополнение и списание одной операцией, чтобы параллельные
+// запросы разных экземпляров сервиса не получили один и тот же токен. Ошибка хранилища
+// не отклоняет запрос: middleware пропускает его без ограничения.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
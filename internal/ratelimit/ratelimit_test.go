@@ -0,0 +1,33 @@
+package ratelimit
+
+import "testing"
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Limit
+		wantErr bool
+	}{
+		{"10:20", Limit{Rate: 10, Burst: 20}, false},
+		{" 0.5 : 3 ", Limit{Rate: 0.5, Burst: 3}, false},
+		{"2.5", Limit{Rate: 2.5, Burst: 3}, false},
+		{"0", Limit{}, false},
+		{"0:5", Limit{}, false},
+		{"-1:5", Limit{}, true},
+		{"abc", Limit{}, true},
+		{"10:0", Limit{}, true},
+		{"10:x", Limit{}, true},
+		{"Inf:5", Limit{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseLimit(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %t, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+)
+
+const apiKeyColumns = `id, name, prefix, key_hash, user_id, role, organization_id, created_at, expires_at, last_used_at, revoked_at`
+
+// apiKeyTouchInterval — не чаще этого last_used_at ключа перезаписывается при использовании
+const apiKeyTouchInterval = time.Minute
+
+// scanAPIKey читает строку с колонками apiKeyColumns
+func scanAPIKey(row rowScanner) (*model.APIKey, error) {
+	var key model.APIKey
+	var organizationID sql.NullString
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.Hash, &key.UserID, &key.Role, &organizationID, &key.CreatedAt, &expiresAt, &lastUsedAt, &revokedAt)
+	if err != nil {
+		return nil, err
+	}
+	key.OrganizationID = organizationID.String
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return &key, nil
+}
+
+// CreateAPIKey сохраняет API-ключ; created_at проставляет база данных
+func (r *PostgresRepository) CreateAPIKey(ctx context.Context, key *model.APIKey) error {
+	query := `INSERT INTO api_keys (id, name, prefix, key_hash, user_id, role, organization_id, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, '')::UUID, $8)
+	RETURNING created_at`
+	err := r.db.QueryRowContext(ctx, query, key.ID, key.Name, key.Prefix, key.Hash, key.UserID, key.Role, key.OrganizationID, key.ExpiresAt).Scan(&key.CreatedAt)
+	return TranslateError(err)
+}
+
+// GetAPIKeyByHash возвращает ключ по хешу, в том числе отозванный или просроченный
+func (r *PostgresRepository) GetAPIKeyByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1`
+	return scanAPIKey(r.db.QueryRowContext(ctx, query, hash))
+}
+
+// TouchAPIKey отмечает использование ключа; чтобы не писать в базу на каждый запрос,
+// last_used_at обновляется не чаще apiKeyTouchInterval
+func (r *PostgresRepository) TouchAPIKey(ctx context.Context, id string, at time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = $2 WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $3)`
+	_, err := r.db.ExecContext(ctx, query, id, at, at.Add(-apiKeyTouchInterval))
+	return err
+}
+
+// ListAPIKeys возвращает ключи, упорядоченные по дате создания; userID, если задан, выбирает ключи пользователя.
+// В репозитории, ограниченном организацией, возвращаются только ключи этой организации.
+func (r *PostgresRepository) ListAPIKeys(ctx context.Context, userID string) ([]model.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE ($1 = '' OR user_id::TEXT = $1)
+	AND ($2 = '' OR organization_id::TEXT = $2) ORDER BY created_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, r.scope.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []model.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey отзывает ключ и возвращает его; если ключа нет, он уже отозван или принадлежит
+// другой организации, возвращает sql.ErrNoRows
+func (r *PostgresRepository) RevokeAPIKey(ctx context.Context, id string) (*model.APIKey, error) {
+	query := `UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL
+	AND ($2 = '' OR organization_id::TEXT = $2)
+	RETURNING ` + apiKeyColumns
+	return scanAPIKey(r.db.QueryRowContext(ctx, query, id, r.scope.OrganizationID))
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+)
+
+const auditColumns = `id, subscription_id, organization_id, user_id, action, actor, actor_role, auth_method, request_id, changes, created_at`
+
+// AppendAudit записывает запись журнала аудита; created_at проставляет база данных.
+// Вызывается в той же транзакции, что и изменение подписки.
+func (r *PostgresRepository) AppendAudit(ctx context.Context, entry *model.AuditEntry) error {
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO audit_log (subscription_id, organization_id, user_id, action, actor, actor_role, auth_method, request_id, changes)
+	 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	 RETURNING id, created_at`
+	return r.db.QueryRowContext(ctx, query, entry.SubscriptionID, entry.OrganizationID, entry.UserID, entry.Action,
+		entry.Actor, entry.ActorRole, entry.AuthMethod, entry.RequestID, changes).Scan(&entry.ID, &entry.CreatedAt)
+}
+
+// ListAudit возвращает записи журнала по фильтру от новых к старым в пределах scope
+func (r *PostgresRepository) ListAudit(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, error) {
+	query := `SELECT ` + auditColumns + ` FROM audit_log WHERE 1=1`
+	condition, args, argIdx := scopeConditionAt(r.scope, []interface{}{}, 1)
+	query += condition
+
+	if filter.SubscriptionID != "" {
+		query += ` AND subscription_id = $` + fmt.Sprint(argIdx)
+		args = append(args, filter.SubscriptionID)
+		argIdx++
+	}
+	if filter.UserID != "" {
+		query += ` AND user_id = $` + fmt.Sprint(argIdx)
+		args = append(args, filter.UserID)
+		argIdx++
+	}
+	if filter.Actor != "" {
+		query += ` AND actor = $` + fmt.Sprint(argIdx)
+		args = append(args, filter.Actor)
+		argIdx++
+	}
+	if filter.Action != "" {
+		query += ` AND action = $` + fmt.Sprint(argIdx)
+		args = append(args, filter.Action)
+		argIdx++
+	}
+	if filter.RequestID != "" {
+		query += ` AND request_id = $` + fmt.Sprint(argIdx)
+		args = append(args, filter.RequestID)
+		argIdx++
+	}
+	if filter.From != nil {
+		query += ` AND created_at >= $` + fmt.Sprint(argIdx)
+		args = append(args, *filter.From)
+		argIdx++
+	}
+	if filter.To != nil {
+		query += ` AND created_at <= $` + fmt.Sprint(argIdx)
+		args = append(args, *filter.To)
+		argIdx++
+	}
+	if filter.BeforeID > 0 {
+		query += ` AND id < $` + fmt.Sprint(argIdx)
+		args = append(args, filter.BeforeID)
+		argIdx++
+	}
+	query += ` ORDER BY id DESC`
+	if filter.Limit > 0 {
+		query += ` LIMIT $` + fmt.Sprint(argIdx)
+		args = append(args, filter.Limit)
+	}
+
+	entries := []model.AuditEntry{}
+	err := r.inTenant(ctx, func(repo *PostgresRepository) error {
+		rows, err := repo.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var entry model.AuditEntry
+			var changes []byte
+			err := rows.Scan(&entry.ID, &entry.SubscriptionID, &entry.OrganizationID, &entry.UserID, &entry.Action,
+				&entry.Actor, &entry.ActorRole, &entry.AuthMethod, &entry.RequestID, &changes, &entry.CreatedAt)
+			if err != nil {
+				return err
+			}
+			if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
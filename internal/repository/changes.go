@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+)
+
+// AppendChange записывает событие в subscription_changes с организацией и текущим владельцем
+// подписки. Вызывается в той же транзакции, что и изменение подписки, пока подписка ещё в таблице.
+func (r *PostgresRepository) AppendChange(ctx context.Context, change *model.SubscriptionChange) error {
+	var snapshot []byte
+	if change.Subscription != nil {
+		var err error
+		snapshot, err = json.Marshal(change.Subscription)
+		if err != nil {
+			return err
+		}
+	}
+
+	query := `INSERT INTO subscription_changes (subscription_id, organization_id, user_id, change_type, snapshot)
+	 SELECT id, organization_id, user_id, $2::VARCHAR, $3::JSONB FROM subscriptions WHERE id = $1
+	 RETURNING id, organization_id, user_id, changed_at`
+	return r.db.QueryRowContext(ctx, query, change.SubscriptionID, change.Type, snapshot).
+		Scan(&change.Sequence, &change.OrganizationID, &change.UserID, &change.ChangedAt)
+}
+
+// ListChanges возвращает события после afterSequence.
+// Отдаются только события транзакций, которые старше всех ещё выполняющихся: иначе
+// событие с меньшим id, зафиксированное позже, могло бы оказаться позади токена клиента.
+func (r *PostgresRepository) ListChanges(ctx context.Context, afterSequence int64, limit int) ([]model.SubscriptionChange, error) {
+	// Область проверяется по организации и владельцу, записанным в событии: события остаются
+	// в ленте после окончательного удаления подписки и не переходят к её новому владельцу
+	condition, args := r.scopeCondition([]interface{}{afterSequence, limit})
+	query := `SELECT id, subscription_id, organization_id, user_id, change_type, snapshot, changed_at FROM subscription_changes
+	WHERE id > $1 AND txid < pg_snapshot_xmin(pg_current_snapshot())` + condition + `
+	ORDER BY id
+	LIMIT $2`
+
+	var changes []model.SubscriptionChange
+
+	err := r.inTenant(ctx, func(repo *PostgresRepository) error {
+		rows, err := repo.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var change model.SubscriptionChange
+			var snapshot sql.NullString
+
+			err := rows.Scan(&change.Sequence, &change.SubscriptionID, &change.OrganizationID, &change.UserID,
+				&change.Type, &snapshot, &change.ChangedAt)
+			if err != nil {
+				return err
+			}
+
+			if snapshot.Valid {
+				var sub model.Subscription
+				if err := json.Unmarshal([]byte(snapshot.String), &sub); err != nil {
+					return err
+				}
+				change.Subscription = &sub
+			}
+			changes = append(changes, change)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,949 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+)
+
+// memoryState — данные хранилища в памяти, общие для репозитория и его транзакций
+type memoryState struct {
+	mu            sync.RWMutex
+	subscriptions map[string]model.Subscription
+	pauses        map[string][]model.Pause
+	prices        map[string][]model.PriceChange
+	services      map[string]model.Service
+	apiKeys       map[string]model.APIKey
+	rates         map[rateKey]model.ExchangeRate
+	changes       []model.SubscriptionChange
+	audit         []model.AuditEntry
+}
+
+// rateKey — первичный ключ курса: пара валют и дата
+type rateKey struct {
+	base, quote string
+	date        time.Time
+}
+
+// MemoryRepository хранит подписки в памяти процесса.
+// Повторяет семантику PostgresRepository и безопасен для конкурентного использования.
+type MemoryRepository struct {
+	*memoryState
+	// inTx — репозиторий работает внутри WithTx, блокировка уже захвачена
+	inTx bool
+	now  func() time.Time
+	// scope ограничивает подписки, см. WithScope
+	scope model.Scope
+}
+
+// NewMemoryRepository создаёт пустое хранилище в памяти
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		memoryState: &memoryState{
+			subscriptions: make(map[string]model.Subscription),
+			pauses:        make(map[string][]model.Pause),
+			prices:        make(map[string][]model.PriceChange),
+			services:      make(map[string]model.Service),
+			apiKeys:       make(map[string]model.APIKey),
+			rates:         make(map[rateKey]model.ExchangeRate),
+		},
+		now: time.Now,
+	}
+}
+
+func (r *MemoryRepository) lock() {
+	if !r.inTx {
+		r.mu.Lock()
+	}
+}
+
+func (r *MemoryRepository) unlock() {
+	if !r.inTx {
+		r.mu.Unlock()
+	}
+}
+
+func (r *MemoryRepository) rlock() {
+	if !r.inTx {
+		r.mu.RLock()
+	}
+}
+
+func (r *MemoryRepository) runlock() {
+	if !r.inTx {
+		r.mu.RUnlock()
+	}
+}
+
+// WithTx выполняет fn под эксклюзивной блокировкой хранилища.
+// Если fn вернула ошибку, все изменения, сделанные внутри, откатываются.
+func (r *MemoryRepository) WithTx(ctx context.Context, fn func(repo SubscriptionRepository) error) error {
+	if r.inTx {
+		return fn(r)
+	}
+
+	r.lock()
+	defer r.unlock()
+
+	subscriptions := make(map[string]model.Subscription, len(r.subscriptions))
+	for id, sub := range r.subscriptions {
+		subscriptions[id] = sub
+	}
+	pauses := make(map[string][]model.Pause, len(r.pauses))
+	for id, list := range r.pauses {
+		pauses[id] = append([]model.Pause(nil), list...)
+	}
+	prices := make(map[string][]model.PriceChange, len(r.prices))
+	for id, list := range r.prices {
+		prices[id] = append([]model.PriceChange(nil), list...)
+	}
+	services := make(map[string]model.Service, len(r.services))
+	for id, svc := range r.services {
+		services[id] = svc
+	}
+	apiKeys := make(map[string]model.APIKey, len(r.apiKeys))
+	for id, key := range r.apiKeys {
+		apiKeys[id] = key
+	}
+	rates := make(map[rateKey]model.ExchangeRate, len(r.rates))
+	for key, rate := range r.rates {
+		rates[key] = rate
+	}
+	changes := len(r.changes)
+	audit := len(r.audit)
+
+	tx := &MemoryRepository{memoryState: r.memoryState, inTx: true, now: r.now, scope: r.scope}
+	if err := fn(tx); err != nil {
+		r.subscriptions = subscriptions
+		r.pauses = pauses
+		r.prices = prices
+		r.services = services
+		r.apiKeys = apiKeys
+		r.rates = rates
+		r.changes = r.changes[:changes]
+		r.audit = r.audit[:audit]
+		return err
+	}
+	return nil
+}
+
+// WithScope возвращает репозиторий над теми же данными, ограниченный scope
+func (r *MemoryRepository) WithScope(scope model.Scope) SubscriptionRepository {
+	return &MemoryRepository{memoryState: r.memoryState, inTx: r.inTx, now: r.now, scope: scope}
+}
+
+// inScope сообщает, входит ли подписка в область репозитория, как scopeCondition в PostgreSQL
+func (r *MemoryRepository) inScope(sub model.Subscription) bool {
+	return (r.scope.OrganizationID == "" || sub.OrganizationID == r.scope.OrganizationID) &&
+		(r.scope.UserID == "" || sub.UserID == r.scope.UserID)
+}
+
+// copySubscription отвязывает указатели, чтобы вызывающий код не менял хранимые данные
+func copySubscription(sub model.Subscription) model.Subscription {
+	if sub.ServiceID != nil {
+		serviceID := *sub.ServiceID
+		sub.ServiceID = &serviceID
+	}
+	sub.Tags = append([]string{}, sub.Tags...)
+	if sub.EndDate != nil {
+		endDate := *sub.EndDate
+		sub.EndDate = &endDate
+	}
+	if sub.DeletedAt != nil {
+		deletedAt := *sub.DeletedAt
+		sub.DeletedAt = &deletedAt
+	}
+	return sub
+}
+
+// output готовит подписку к выдаче: копирует её и вычисляет статус, как statusExpr в PostgreSQL
+func (r *MemoryRepository) output(sub model.Subscription) model.Subscription {
+	sub = copySubscription(sub)
+	sub.Status = sub.EffectiveStatus(r.now())
+	return sub
+}
+
+func (r *MemoryRepository) CreateSubscription(ctx context.Context, sub *model.Subscription) error {
+	r.lock()
+	defer r.unlock()
+
+	if _, ok := r.subscriptions[sub.ID]; ok {
+		return ErrDuplicate
+	}
+	if sub.Status == "" {
+		sub.Status = model.StatusActive
+	}
+	if sub.OrganizationID == "" {
+		sub.OrganizationID = model.DefaultOrganizationID
+	}
+	sub.Version = 1
+	now := r.now()
+	sub.CreatedAt, sub.UpdatedAt = now, now
+	r.subscriptions[sub.ID] = copySubscription(*sub)
+	return nil
+}
+
+func (r *MemoryRepository) GetSubscription(ctx context.Context, id string, includeDeleted bool) (*model.Subscription, error) {
+	r.rlock()
+	defer r.runlock()
+
+	sub, ok := r.subscriptions[id]
+	if !ok || !r.inScope(sub) || (sub.DeletedAt != nil && !includeDeleted) {
+		return nil, sql.ErrNoRows
+	}
+	sub = r.output(sub)
+	return &sub, nil
+}
+
+func (r *MemoryRepository) UpdateSubscription(ctx context.Context, sub *model.Subscription) error {
+	r.lock()
+	defer r.unlock()
+
+	existing, ok := r.subscriptions[sub.ID]
+	if !ok || !r.inScope(existing) || existing.DeletedAt != nil || (sub.Version > 0 && sub.Version != existing.Version) {
+		return sql.ErrNoRows
+	}
+	sub.Version = existing.Version + 1
+	sub.CreatedAt, sub.UpdatedAt = existing.CreatedAt, r.now()
+	stored := copySubscription(*sub)
+	// Статус и организация запросом обновления не меняются
+	stored.Status, stored.OrganizationID = existing.Status, existing.OrganizationID
+	sub.OrganizationID = existing.OrganizationID
+	r.subscriptions[sub.ID] = stored
+	return nil
+}
+
+func (r *MemoryRepository) DeleteSubscription(ctx context.Context, id string, version int64) error {
+	r.lock()
+	defer r.unlock()
+
+	sub, ok := r.subscriptions[id]
+	if !ok || !r.inScope(sub) || sub.DeletedAt != nil || (version > 0 && version != sub.Version) {
+		return sql.ErrNoRows
+	}
+	sub.Version++
+	deletedAt := r.now()
+	sub.DeletedAt = &deletedAt
+	sub.UpdatedAt = deletedAt
+	r.subscriptions[id] = sub
+	return nil
+}
+
+func (r *MemoryRepository) RestoreSubscription(ctx context.Context, id string) (*model.Subscription, error) {
+	r.lock()
+	defer r.unlock()
+
+	sub, ok := r.subscriptions[id]
+	if !ok || !r.inScope(sub) || sub.DeletedAt == nil {
+		return nil, sql.ErrNoRows
+	}
+	sub.DeletedAt = nil
+	sub.Version++
+	sub.UpdatedAt = r.now()
+	r.subscriptions[id] = sub
+	sub = r.output(sub)
+	return &sub, nil
+}
+
+func (r *MemoryRepository) SetSubscriptionStatus(ctx context.Context, id, status string, endDate *time.Time) (*model.Subscription, error) {
+	r.lock()
+	defer r.unlock()
+
+	sub, ok := r.subscriptions[id]
+	if !ok || !r.inScope(sub) || sub.DeletedAt != nil {
+		return nil, sql.ErrNoRows
+	}
+	sub.Status = status
+	sub.EndDate = endDate
+	sub.Version++
+	sub.UpdatedAt = r.now()
+	r.subscriptions[id] = copySubscription(sub)
+	sub = r.output(sub)
+	return &sub, nil
+}
+
+func (r *MemoryRepository) PurgeDeleted(ctx context.Context, olderThan time.Time) (int64, error) {
+	r.lock()
+	defer r.unlock()
+
+	var purged int64
+	for id, sub := range r.subscriptions {
+		if r.inScope(sub) && sub.DeletedAt != nil && sub.DeletedAt.Before(olderThan) {
+			delete(r.subscriptions, id)
+			delete(r.pauses, id)
+			delete(r.prices, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (r *MemoryRepository) ListSubscriptions(ctx context.Context, filter model.SubscriptionFilter) ([]model.Subscription, error) {
+	r.rlock()
+	defer r.runlock()
+
+	desc := filter.Order == "desc"
+
+	var subscriptions []model.Subscription
+	for _, sub := range r.subscriptions {
+		if !r.inScope(sub) || (sub.DeletedAt != nil && !filter.IncludeDeleted) {
+			continue
+		}
+		if filter.UserID != "" && sub.UserID != filter.UserID {
+			continue
+		}
+		if filter.ServiceName != "" && sub.ServiceName != filter.ServiceName {
+			continue
+		}
+		if filter.Currency != "" && sub.Currency != filter.Currency {
+			continue
+		}
+		if filter.Category != "" && sub.Category != filter.Category {
+			continue
+		}
+		if len(filter.Tags) > 0 && !sub.HasTags(filter.Tags, filter.MatchAllTags) {
+			continue
+		}
+		if filter.MinPrice != nil && sub.Price < *filter.MinPrice {
+			continue
+		}
+		if filter.MaxPrice != nil && sub.Price > *filter.MaxPrice {
+			continue
+		}
+		if filter.UpdatedSince != nil && !sub.UpdatedAt.After(*filter.UpdatedSince) {
+			continue
+		}
+		if filter.ActiveFrom != nil && filter.ActiveTo != nil && !activeBetween(sub, *filter.ActiveFrom, *filter.ActiveTo) {
+			continue
+		}
+		if filter.Status != "" && sub.EffectiveStatus(r.now()) != filter.Status {
+			continue
+		}
+		if filter.After != nil {
+			c := compareBySort(sub, filter.Sort, filter.After.Value, filter.After.ID)
+			if (!desc && c <= 0) || (desc && c >= 0) {
+				continue
+			}
+		}
+		subscriptions = append(subscriptions, r.output(sub))
+	}
+
+	sort.Slice(subscriptions, func(i, j int) bool {
+		b := subscriptions[j]
+		c := compareBySort(subscriptions[i], filter.Sort, sortValue(b, filter.Sort), b.ID)
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	if filter.Limit > 0 && len(subscriptions) > filter.Limit+1 {
+		subscriptions = subscriptions[:filter.Limit+1]
+	}
+
+	return subscriptions, nil
+}
+
+func (r *MemoryRepository) StreamSubscriptions(ctx context.Context, filter model.SubscriptionFilter, fn func(model.Subscription) error) error {
+	filter.Limit = 0
+	subscriptions, err := r.ListSubscriptions(ctx, filter)
+	if err != nil {
+		return err
+	}
+	return streamAll(subscriptions, fn)
+}
+
+func (r *MemoryRepository) StreamSubscriptionsForPeriod(ctx context.Context, filter model.CostFilter, fn func(model.Subscription) error) error {
+	subscriptions, err := r.listSubscriptionsForPeriod(filter)
+	if err != nil {
+		return err
+	}
+	return streamAll(subscriptions, fn)
+}
+
+// streamAll передаёт подписки в fn вне блокировки, чтобы fn могла обращаться к репозиторию
+func streamAll(subscriptions []model.Subscription, fn func(model.Subscription) error) error {
+	for _, sub := range subscriptions {
+		if err := fn(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) listSubscriptionsForPeriod(filter model.CostFilter) ([]model.Subscription, error) {
+	r.rlock()
+	defer r.runlock()
+
+	var subscriptions []model.Subscription
+	for _, sub := range r.subscriptions {
+		if !r.inScope(sub) || (sub.DeletedAt != nil && !filter.IncludeDeleted) {
+			continue
+		}
+		if filter.UserID != "" && sub.UserID != filter.UserID {
+			continue
+		}
+		if filter.ServiceName != "" && sub.ServiceName != filter.ServiceName {
+			continue
+		}
+		if filter.ServiceID != "" && (sub.ServiceID == nil || *sub.ServiceID != filter.ServiceID) {
+			continue
+		}
+		if filter.Category != "" && sub.Category != filter.Category {
+			continue
+		}
+		if len(filter.Tags) > 0 && !sub.HasTags(filter.Tags, filter.MatchAllTags) {
+			continue
+		}
+		// Последний оплаченный цикл подписки заканчивается не раньше начала периода, как в cycleExpr
+		if !filter.StartDate.IsZero() && sub.EndDate != nil && !sub.AddCycles(*sub.EndDate, 1).After(filter.StartDate) {
+			continue
+		}
+		if !filter.EndDate.IsZero() && sub.StartDate.After(filter.EndDate) {
+			continue
+		}
+		subscriptions = append(subscriptions, r.output(sub))
+	}
+
+	sort.Slice(subscriptions, func(i, j int) bool {
+		b := subscriptions[j]
+		return compareBySort(subscriptions[i], "start_date", b.StartDate, b.ID) < 0
+	})
+
+	return subscriptions, nil
+}
+
+func (r *MemoryRepository) ExistingKeys(ctx context.Context, keys []model.SubscriptionKey) (map[model.SubscriptionKey]bool, error) {
+	r.rlock()
+	defer r.runlock()
+
+	wanted := make(map[model.SubscriptionKey]bool, len(keys))
+	for _, key := range keys {
+		wanted[key] = true
+	}
+
+	existing := make(map[model.SubscriptionKey]bool)
+	for _, sub := range r.subscriptions {
+		if !r.inScope(sub) || sub.DeletedAt != nil {
+			continue
+		}
+		key := model.SubscriptionKey{UserID: sub.UserID, ServiceName: sub.ServiceName, StartDate: sub.StartDate}
+		if wanted[key] {
+			existing[key] = true
+		}
+	}
+	return existing, nil
+}
+
+func (r *MemoryRepository) StartPause(ctx context.Context, subscriptionID string, at time.Time) error {
+	r.lock()
+	defer r.unlock()
+
+	if _, ok := r.subscriptions[subscriptionID]; !ok {
+		return sql.ErrNoRows
+	}
+	r.pauses[subscriptionID] = append(r.pauses[subscriptionID], model.Pause{PausedAt: at})
+	return nil
+}
+
+func (r *MemoryRepository) EndPause(ctx context.Context, subscriptionID string, at time.Time) error {
+	r.lock()
+	defer r.unlock()
+
+	for i, pause := range r.pauses[subscriptionID] {
+		if pause.ResumedAt == nil {
+			resumedAt := at
+			r.pauses[subscriptionID][i].ResumedAt = &resumedAt
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) ListPauses(ctx context.Context, subscriptionIDs []string) (map[string][]model.Pause, error) {
+	r.rlock()
+	defer r.runlock()
+
+	pauses := make(map[string][]model.Pause)
+	for _, id := range subscriptionIDs {
+		if list, ok := r.pauses[id]; ok {
+			pauses[id] = append([]model.Pause(nil), list...)
+		}
+	}
+	return pauses, nil
+}
+
+func (r *MemoryRepository) UpsertPriceChange(ctx context.Context, change *model.PriceChange) error {
+	r.lock()
+	defer r.unlock()
+
+	if _, ok := r.subscriptions[change.SubscriptionID]; !ok {
+		return sql.ErrNoRows
+	}
+	change.UpdatedAt = r.now()
+	list := r.prices[change.SubscriptionID]
+	i := sort.Search(len(list), func(i int) bool { return !list[i].EffectiveFrom.Before(change.EffectiveFrom) })
+	if i < len(list) && list[i].EffectiveFrom.Equal(change.EffectiveFrom) {
+		list[i] = *change
+		return nil
+	}
+	list = append(list, model.PriceChange{})
+	copy(list[i+1:], list[i:])
+	list[i] = *change
+	r.prices[change.SubscriptionID] = list
+	return nil
+}
+
+func (r *MemoryRepository) DeletePriceChange(ctx context.Context, subscriptionID string, effectiveFrom time.Time) error {
+	r.lock()
+	defer r.unlock()
+
+	list := r.prices[subscriptionID]
+	for i, change := range list {
+		if change.EffectiveFrom.Equal(effectiveFrom) {
+			r.prices[subscriptionID] = append(list[:i:i], list[i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (r *MemoryRepository) ListPriceChanges(ctx context.Context, subscriptionIDs []string) (map[string][]model.PriceChange, error) {
+	r.rlock()
+	defer r.runlock()
+
+	changes := make(map[string][]model.PriceChange)
+	for _, id := range subscriptionIDs {
+		if list, ok := r.prices[id]; ok && len(list) > 0 {
+			changes[id] = append([]model.PriceChange(nil), list...)
+		}
+	}
+	return changes, nil
+}
+
+// copyService отвязывает срезы и указатели сервиса от хранимых данных
+func copyService(svc model.Service) model.Service {
+	svc.Aliases = append([]string{}, svc.Aliases...)
+	if svc.DefaultPrice != nil {
+		price := *svc.DefaultPrice
+		svc.DefaultPrice = &price
+	}
+	return svc
+}
+
+// serviceNameTaken сообщает, занято ли название другим сервисом, как уникальный индекс по lower(name)
+func (r *MemoryRepository) serviceNameTaken(svc *model.Service) bool {
+	for id, other := range r.services {
+		if id != svc.ID && strings.EqualFold(other.Name, svc.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryRepository) CreateService(ctx context.Context, svc *model.Service) error {
+	r.lock()
+	defer r.unlock()
+
+	if _, ok := r.services[svc.ID]; ok || r.serviceNameTaken(svc) {
+		return ErrDuplicate
+	}
+	now := r.now()
+	svc.CreatedAt, svc.UpdatedAt = now, now
+	r.services[svc.ID] = copyService(*svc)
+	return nil
+}
+
+func (r *MemoryRepository) GetService(ctx context.Context, id string) (*model.Service, error) {
+	r.rlock()
+	defer r.runlock()
+
+	svc, ok := r.services[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	svc = copyService(svc)
+	return &svc, nil
+}
+
+func (r *MemoryRepository) FindServiceByName(ctx context.Context, name string) (*model.Service, error) {
+	r.rlock()
+	defer r.runlock()
+
+	// Совпадение с каноническим названием важнее совпадения с синонимом, как ORDER BY в PostgreSQL
+	var found *model.Service
+	for _, svc := range r.services {
+		if strings.EqualFold(svc.Name, name) {
+			svc = copyService(svc)
+			return &svc, nil
+		}
+		for _, alias := range svc.Aliases {
+			if found == nil && strings.EqualFold(alias, name) {
+				svc = copyService(svc)
+				found = &svc
+			}
+		}
+	}
+	if found == nil {
+		return nil, sql.ErrNoRows
+	}
+	return found, nil
+}
+
+func (r *MemoryRepository) UpdateService(ctx context.Context, svc *model.Service) error {
+	r.lock()
+	defer r.unlock()
+
+	current, ok := r.services[svc.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if r.serviceNameTaken(svc) {
+		return ErrDuplicate
+	}
+	svc.CreatedAt, svc.UpdatedAt = current.CreatedAt, r.now()
+	r.services[svc.ID] = copyService(*svc)
+	return nil
+}
+
+func (r *MemoryRepository) DeleteService(ctx context.Context, id string) error {
+	r.lock()
+	defer r.unlock()
+
+	if _, ok := r.services[id]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(r.services, id)
+
+	// ON DELETE SET NULL: триггеры PostgreSQL при этом увеличивают версию и обновляют updated_at
+	for subID, sub := range r.subscriptions {
+		if sub.ServiceID != nil && *sub.ServiceID == id {
+			sub.ServiceID = nil
+			sub.Version++
+			sub.UpdatedAt = r.now()
+			r.subscriptions[subID] = sub
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) ListServices(ctx context.Context, filter model.ServiceFilter) ([]model.Service, error) {
+	r.rlock()
+	defer r.runlock()
+
+	var services []model.Service
+	for _, svc := range r.services {
+		if filter.Category != "" && svc.Category != filter.Category {
+			continue
+		}
+		services = append(services, copyService(svc))
+	}
+
+	sort.Slice(services, func(i, j int) bool {
+		return strings.ToLower(services[i].Name) < strings.ToLower(services[j].Name)
+	})
+	return services, nil
+}
+
+// copyAPIKey отвязывает указатели ключа от хранимых данных
+func copyAPIKey(key model.APIKey) model.APIKey {
+	for _, t := range []**time.Time{&key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt} {
+		if *t != nil {
+			v := **t
+			*t = &v
+		}
+	}
+	return key
+}
+
+func (r *MemoryRepository) CreateAPIKey(ctx context.Context, key *model.APIKey) error {
+	r.lock()
+	defer r.unlock()
+
+	if _, ok := r.apiKeys[key.ID]; ok {
+		return ErrDuplicate
+	}
+	for _, other := range r.apiKeys {
+		if other.Hash == key.Hash {
+			return ErrDuplicate
+		}
+	}
+	key.CreatedAt = r.now()
+	r.apiKeys[key.ID] = copyAPIKey(*key)
+	return nil
+}
+
+func (r *MemoryRepository) GetAPIKeyByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	r.rlock()
+	defer r.runlock()
+
+	for _, key := range r.apiKeys {
+		if key.Hash == hash {
+			key = copyAPIKey(key)
+			return &key, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *MemoryRepository) TouchAPIKey(ctx context.Context, id string, at time.Time) error {
+	r.lock()
+	defer r.unlock()
+
+	key, ok := r.apiKeys[id]
+	if !ok || (key.LastUsedAt != nil && !key.LastUsedAt.Before(at.Add(-apiKeyTouchInterval))) {
+		return nil
+	}
+	key.LastUsedAt = &at
+	r.apiKeys[id] = key
+	return nil
+}
+
+// keyInScope сообщает, относится ли ключ к организации репозитория, как ListAPIKeys в PostgreSQL
+func (r *MemoryRepository) keyInScope(key model.APIKey) bool {
+	return r.scope.OrganizationID == "" || key.OrganizationID == r.scope.OrganizationID
+}
+
+func (r *MemoryRepository) ListAPIKeys(ctx context.Context, userID string) ([]model.APIKey, error) {
+	r.rlock()
+	defer r.runlock()
+
+	keys := []model.APIKey{}
+	for _, key := range r.apiKeys {
+		if (userID != "" && key.UserID != userID) || !r.keyInScope(key) {
+			continue
+		}
+		keys = append(keys, copyAPIKey(key))
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+		return keys[i].ID < keys[j].ID
+	})
+	return keys, nil
+}
+
+func (r *MemoryRepository) RevokeAPIKey(ctx context.Context, id string) (*model.APIKey, error) {
+	r.lock()
+	defer r.unlock()
+
+	key, ok := r.apiKeys[id]
+	if !ok || key.RevokedAt != nil || !r.keyInScope(key) {
+		return nil, sql.ErrNoRows
+	}
+	now := r.now()
+	key.RevokedAt = &now
+	r.apiKeys[id] = key
+	key = copyAPIKey(key)
+	return &key, nil
+}
+
+func (r *MemoryRepository) UpsertExchangeRate(ctx context.Context, rate *model.ExchangeRate) error {
+	r.lock()
+	defer r.unlock()
+
+	rate.UpdatedAt = r.now()
+	r.rates[rateKey{base: rate.BaseCurrency, quote: rate.QuoteCurrency, date: rate.Date}] = *rate
+	return nil
+}
+
+func (r *MemoryRepository) DeleteExchangeRate(ctx context.Context, base, quote string, date time.Time) error {
+	r.lock()
+	defer r.unlock()
+
+	key := rateKey{base: base, quote: quote, date: date}
+	if _, ok := r.rates[key]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(r.rates, key)
+	return nil
+}
+
+func (r *MemoryRepository) ListExchangeRates(ctx context.Context, filter model.ExchangeRateFilter) ([]model.ExchangeRate, error) {
+	r.rlock()
+	defer r.runlock()
+
+	var rates []model.ExchangeRate
+	for _, rate := range r.rates {
+		if filter.BaseCurrency != "" && rate.BaseCurrency != filter.BaseCurrency {
+			continue
+		}
+		if filter.QuoteCurrency != "" && rate.QuoteCurrency != filter.QuoteCurrency {
+			continue
+		}
+		if filter.From != nil && rate.Date.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && rate.Date.After(*filter.To) {
+			continue
+		}
+		rates = append(rates, rate)
+	}
+
+	sort.Slice(rates, func(i, j int) bool {
+		a, b := rates[i], rates[j]
+		if a.BaseCurrency != b.BaseCurrency {
+			return a.BaseCurrency < b.BaseCurrency
+		}
+		if a.QuoteCurrency != b.QuoteCurrency {
+			return a.QuoteCurrency < b.QuoteCurrency
+		}
+		return a.Date.Before(b.Date)
+	})
+	return rates, nil
+}
+
+func (r *MemoryRepository) AppendChange(ctx context.Context, change *model.SubscriptionChange) error {
+	r.lock()
+	defer r.unlock()
+
+	sub, ok := r.subscriptions[change.SubscriptionID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	change.Sequence = int64(len(r.changes)) + 1
+	change.OrganizationID = sub.OrganizationID
+	change.UserID = sub.UserID
+	change.ChangedAt = r.now()
+	stored := *change
+	if stored.Subscription != nil {
+		sub := copySubscription(*stored.Subscription)
+		stored.Subscription = &sub
+	}
+	r.changes = append(r.changes, stored)
+	return nil
+}
+
+func (r *MemoryRepository) ListChanges(ctx context.Context, afterSequence int64, limit int) ([]model.SubscriptionChange, error) {
+	r.rlock()
+	defer r.runlock()
+
+	var changes []model.SubscriptionChange
+	for _, change := range r.changes {
+		if change.Sequence <= afterSequence {
+			continue
+		}
+		if !r.inScope(model.Subscription{OrganizationID: change.OrganizationID, UserID: change.UserID}) {
+			continue
+		}
+		if limit > 0 && len(changes) == limit {
+			break
+		}
+		if change.Subscription != nil {
+			sub := copySubscription(*change.Subscription)
+			change.Subscription = &sub
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func (r *MemoryRepository) AppendAudit(ctx context.Context, entry *model.AuditEntry) error {
+	r.lock()
+	defer r.unlock()
+
+	entry.ID = int64(len(r.audit)) + 1
+	entry.CreatedAt = r.now()
+	r.audit = append(r.audit, copyAuditEntry(*entry))
+	return nil
+}
+
+func (r *MemoryRepository) ListAudit(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, error) {
+	r.rlock()
+	defer r.runlock()
+
+	entries := []model.AuditEntry{}
+	for i := len(r.audit) - 1; i >= 0; i-- {
+		entry := r.audit[i]
+		// Запись относится к области репозитория по организации и владельцу подписки, как в PostgreSQL
+		if !r.inScope(model.Subscription{OrganizationID: entry.OrganizationID, UserID: entry.UserID}) {
+			continue
+		}
+		if (filter.SubscriptionID != "" && entry.SubscriptionID != filter.SubscriptionID) ||
+			(filter.UserID != "" && entry.UserID != filter.UserID) ||
+			(filter.Actor != "" && entry.Actor != filter.Actor) ||
+			(filter.Action != "" && entry.Action != filter.Action) ||
+			(filter.RequestID != "" && entry.RequestID != filter.RequestID) ||
+			(filter.From != nil && entry.CreatedAt.Before(*filter.From)) ||
+			(filter.To != nil && entry.CreatedAt.After(*filter.To)) ||
+			(filter.BeforeID > 0 && entry.ID >= filter.BeforeID) {
+			continue
+		}
+		if filter.Limit > 0 && len(entries) == filter.Limit {
+			break
+		}
+		entries = append(entries, copyAuditEntry(entry))
+	}
+	return entries, nil
+}
+
+// copyAuditEntry копирует запись журнала вместе с набором изменённых полей
+func copyAuditEntry(entry model.AuditEntry) model.AuditEntry {
+	changes := make(map[string]model.FieldChange, len(entry.Changes))
+	for field, change := range entry.Changes {
+		changes[field] = change
+	}
+	entry.Changes = changes
+	return entry
+}
+
+// activeBetween сообщает, действует ли подписка хотя бы один день отрезка [from, to]
+func activeBetween(sub model.Subscription, from, to time.Time) bool {
+	if sub.StartDate.After(to) {
+		return false
+	}
+	return sub.EndDate == nil || !sub.EndDate.Before(from)
+}
+
+// sortValue возвращает значение поля сортировки подписки
+func sortValue(sub model.Subscription, field string) interface{} {
+	switch field {
+	case "price":
+		return sub.Price
+	case "service_name":
+		return sub.ServiceName
+	case "created_at":
+		return sub.CreatedAt
+	case "updated_at":
+		return sub.UpdatedAt
+	default:
+		return sub.StartDate
+	}
+}
+
+// compareBySort сравнивает подписку с парой (value, id) так же, как сравнение кортежей в SQL
+func compareBySort(sub model.Subscription, field string, value interface{}, id string) int {
+	c := 0
+	switch field {
+	case "price":
+		v, _ := value.(int)
+		switch {
+		case sub.Price < v:
+			c = -1
+		case sub.Price > v:
+			c = 1
+		}
+	case "service_name":
+		v, _ := value.(string)
+		c = strings.Compare(sub.ServiceName, v)
+	case "created_at":
+		v, _ := value.(time.Time)
+		c = sub.CreatedAt.Compare(v)
+	case "updated_at":
+		v, _ := value.(time.Time)
+		c = sub.UpdatedAt.Compare(v)
+	default:
+		v, _ := value.(time.Time)
+		c = sub.StartDate.Compare(v)
+	}
+	if c != 0 {
+		return c
+	}
+	return strings.Compare(sub.ID, id)
+}
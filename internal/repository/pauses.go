@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/lib/pq"
+)
+
+// StartPause открывает период приостановки подписки; если подписки нет, возвращает sql.ErrNoRows
+func (r *PostgresRepository) StartPause(ctx context.Context, subscriptionID string, at time.Time) error {
+	query := `INSERT INTO subscription_pauses (subscription_id, organization_id, paused_at)
+	SELECT id, organization_id, $2::TIMESTAMPTZ FROM subscriptions WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, subscriptionID, at)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// EndPause закрывает открытый период приостановки подписки, если он есть
+func (r *PostgresRepository) EndPause(ctx context.Context, subscriptionID string, at time.Time) error {
+	query := `UPDATE subscription_pauses SET resumed_at = $2 WHERE subscription_id = $1 AND resumed_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, subscriptionID, at)
+	return err
+}
+
+// ListPauses возвращает периоды приостановки указанных подписок, сгруппированные по ID подписки
+func (r *PostgresRepository) ListPauses(ctx context.Context, subscriptionIDs []string) (map[string][]model.Pause, error) {
+	pauses := make(map[string][]model.Pause)
+	if len(subscriptionIDs) == 0 {
+		return pauses, nil
+	}
+
+	query := `SELECT subscription_id, paused_at, resumed_at FROM subscription_pauses
+	WHERE subscription_id = ANY($1)
+	ORDER BY subscription_id, paused_at`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(subscriptionIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var pause model.Pause
+		if err := rows.Scan(&id, &pause.PausedAt, &pause.ResumedAt); err != nil {
+			return nil, err
+		}
+		pauses[id] = append(pauses[id], pause)
+	}
+
+	return pauses, rows.Err()
+}
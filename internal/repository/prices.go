@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/lib/pq"
+)
+
+// UpsertPriceChange сохраняет цену подписки с даты, заменяя прежнюю цену на ту же дату;
+// если подписки нет, возвращает sql.ErrNoRows
+func (r *PostgresRepository) UpsertPriceChange(ctx context.Context, change *model.PriceChange) error {
+	query := `INSERT INTO price_history (subscription_id, organization_id, effective_from, price)
+	SELECT id, organization_id, $2::DATE, $3::BIGINT FROM subscriptions WHERE id = $1
+	ON CONFLICT (subscription_id, effective_from) DO UPDATE SET price = EXCLUDED.price, updated_at = now()
+	RETURNING updated_at`
+	return r.db.QueryRowContext(ctx, query, change.SubscriptionID, change.EffectiveFrom, change.Price).Scan(&change.UpdatedAt)
+}
+
+// DeletePriceChange удаляет изменение цены подписки на дату; если его нет, возвращает sql.ErrNoRows
+func (r *PostgresRepository) DeletePriceChange(ctx context.Context, subscriptionID string, effectiveFrom time.Time) error {
+	query := `DELETE FROM price_history WHERE subscription_id = $1 AND effective_from = $2`
+
+	result, err := r.db.ExecContext(ctx, query, subscriptionID, effectiveFrom)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ListPriceChanges возвращает изменения цен указанных подписок, сгруппированные по ID подписки
+// и упорядоченные по дате
+func (r *PostgresRepository) ListPriceChanges(ctx context.Context, subscriptionIDs []string) (map[string][]model.PriceChange, error) {
+	changes := make(map[string][]model.PriceChange)
+	if len(subscriptionIDs) == 0 {
+		return changes, nil
+	}
+
+	query := `SELECT subscription_id, effective_from, price, updated_at FROM price_history
+	WHERE subscription_id = ANY($1)
+	ORDER BY subscription_id, effective_from`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(subscriptionIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var change model.PriceChange
+		if err := rows.Scan(&change.SubscriptionID, &change.EffectiveFrom, &change.Price, &change.UpdatedAt); err != nil {
+			return nil, err
+		}
+		changes[change.SubscriptionID] = append(changes[change.SubscriptionID], change)
+	}
+
+	return changes, rows.Err()
+}
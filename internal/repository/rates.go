@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+)
+
+// UpsertExchangeRate сохраняет курс пары валют на дату, заменяя прежний
+func (r *PostgresRepository) UpsertExchangeRate(ctx context.Context, rate *model.ExchangeRate) error {
+	query := `INSERT INTO exchange_rates (base_currency, quote_currency, rate_date, rate)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (base_currency, quote_currency, rate_date) DO UPDATE SET rate = EXCLUDED.rate, updated_at = now()
+	RETURNING updated_at`
+	return r.db.QueryRowContext(ctx, query, rate.BaseCurrency, rate.QuoteCurrency, rate.Date, rate.Rate).Scan(&rate.UpdatedAt)
+}
+
+// DeleteExchangeRate удаляет курс пары валют на дату; если его нет, возвращает sql.ErrNoRows
+func (r *PostgresRepository) DeleteExchangeRate(ctx context.Context, base, quote string, date time.Time) error {
+	query := `DELETE FROM exchange_rates WHERE base_currency = $1 AND quote_currency = $2 AND rate_date = $3`
+
+	result, err := r.db.ExecContext(ctx, query, base, quote, date)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ListExchangeRates возвращает курсы по фильтру, упорядоченные по паре валют и дате.
+// Курс отдаётся строкой без потери точности NUMERIC.
+func (r *PostgresRepository) ListExchangeRates(ctx context.Context, filter model.ExchangeRateFilter) ([]model.ExchangeRate, error) {
+	query := `SELECT base_currency, quote_currency, rate_date, rate::TEXT, updated_at FROM exchange_rates WHERE 1=1`
+	args := []interface{}{}
+	argIdx := 1
+
+	if filter.BaseCurrency != "" {
+		query += ` AND base_currency = $` + fmt.Sprint(argIdx)
+		args = append(args, filter.BaseCurrency)
+		argIdx++
+	}
+	if filter.QuoteCurrency != "" {
+		query += ` AND quote_currency = $` + fmt.Sprint(argIdx)
+		args = append(args, filter.QuoteCurrency)
+		argIdx++
+	}
+	if filter.From != nil {
+		query += ` AND rate_date >= $` + fmt.Sprint(argIdx)
+		args = append(args, *filter.From)
+		argIdx++
+	}
+	if filter.To != nil {
+		query += ` AND rate_date <= $` + fmt.Sprint(argIdx)
+		args = append(args, *filter.To)
+		argIdx++
+	}
+	query += ` ORDER BY base_currency, quote_currency, rate_date`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []model.ExchangeRate
+	for rows.Next() {
+		var rate model.ExchangeRate
+		if err := rows.Scan(&rate.BaseCurrency, &rate.QuoteCurrency, &rate.Date, &rate.Rate, &rate.UpdatedAt); err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+)
+
+var (
+	// ErrDuplicate возвращается при нарушении уникальности: подписка с уже существующим ID
+	// или сервис каталога с уже занятым названием
+	ErrDuplicate = errors.New("duplicate key")
+	// ErrInvalidReference — запись ссылается на строку, которой уже нет, например на сервис
+	// каталога, удалённый параллельным запросом (нарушение внешнего ключа)
+	ErrInvalidReference = errors.New("referenced row does not exist")
+	// ErrConstraint — данные нарушают ограничение CHECK таблицы
+	ErrConstraint = errors.New("check constraint violated")
+	// ErrRowSecurity — строка не прошла политику построчной безопасности организации запроса
+	ErrRowSecurity = errors.New("row-level security policy violated")
+)
+
+// SubscriptionRepository описывает хранилище подписок.
+// Если подписка не найдена, методы возвращают sql.ErrNoRows.
+type SubscriptionRepository interface {
+	CreateSubscription(ctx context.Context, sub *model.Subscription) error
+	// GetSubscription возвращает подписку; мягко удалённые возвращаются только при includeDeleted
+	GetSubscription(ctx context.Context, id string, includeDeleted bool) (*model.Subscription, error)
+	// UpdateSubscription обновляет не удалённую подписку. Если sub.Version больше нуля,
+	// обновление выполняется только при совпадении версии, иначе возвращается sql.ErrNoRows.
+	// После успешного обновления sub.Version содержит новую версию.
+	UpdateSubscription(ctx context.Context, sub *model.Subscription) error
+	// DeleteSubscription мягко удаляет подписку, проставляя deleted_at; version больше нуля
+	// требует совпадения версии так же, как в UpdateSubscription
+	DeleteSubscription(ctx context.Context, id string, version int64) error
+	// RestoreSubscription снимает отметку об удалении; если подписка не удалена, возвращает sql.ErrNoRows
+	RestoreSubscription(ctx context.Context, id string) (*model.Subscription, error)
+	// SetSubscriptionStatus меняет статус и end_date не удалённой подписки и возвращает её
+	SetSubscriptionStatus(ctx context.Context, id, status string, endDate *time.Time) (*model.Subscription, error)
+	// PurgeDeleted окончательно удаляет подписки, удалённые раньше olderThan, и возвращает их число
+	PurgeDeleted(ctx context.Context, olderThan time.Time) (int64, error)
+	// ListSubscriptions возвращает до filter.Limit+1 подписок, чтобы можно было определить наличие следующей страницы
+	ListSubscriptions(ctx context.Context, filter model.SubscriptionFilter) ([]model.Subscription, error)
+	// ExistingKeys возвращает те из keys, для которых уже есть не удалённая подписка
+	ExistingKeys(ctx context.Context, keys []model.SubscriptionKey) (map[model.SubscriptionKey]bool, error)
+	// StreamSubscriptions передаёт в fn все подписки по фильтру без ограничения filter.Limit,
+	// читая их из курсора по одной; ошибка fn прерывает чтение
+	StreamSubscriptions(ctx context.Context, filter model.SubscriptionFilter, fn func(model.Subscription) error) error
+	// StreamSubscriptionsForPeriod передаёт в fn подписки, пересекающиеся с периодом фильтра, в порядке start_date
+	StreamSubscriptionsForPeriod(ctx context.Context, filter model.CostFilter, fn func(model.Subscription) error) error
+
+	// UpsertExchangeRate сохраняет курс пары валют на дату, заменяя прежний; rate.UpdatedAt заполняется
+	UpsertExchangeRate(ctx context.Context, rate *model.ExchangeRate) error
+	// DeleteExchangeRate удаляет курс пары валют на дату; если его нет, возвращает sql.ErrNoRows
+	DeleteExchangeRate(ctx context.Context, base, quote string, date time.Time) error
+	// ListExchangeRates возвращает курсы по фильтру, упорядоченные по паре валют и дате
+	ListExchangeRates(ctx context.Context, filter model.ExchangeRateFilter) ([]model.ExchangeRate, error)
+
+	// CreateService сохраняет сервис каталога; занятое название возвращает ErrDuplicate
+	CreateService(ctx context.Context, svc *model.Service) error
+	// GetService возвращает сервис каталога по ID
+	GetService(ctx context.Context, id string) (*model.Service, error)
+	// FindServiceByName ищет сервис по названию или синониму без учёта регистра
+	FindServiceByName(ctx context.Context, name string) (*model.Service, error)
+	// UpdateService заменяет данные сервиса каталога; занятое название возвращает ErrDuplicate
+	UpdateService(ctx context.Context, svc *model.Service) error
+	// DeleteService удаляет сервис каталога; ссылки подписок на него сбрасываются
+	DeleteService(ctx context.Context, id string) error
+	// ListServices возвращает сервисы каталога по фильтру, упорядоченные по названию
+	ListServices(ctx context.Context, filter model.ServiceFilter) ([]model.Service, error)
+
+	// CreateAPIKey сохраняет API-ключ и заполняет key.CreatedAt; совпадение хеша возвращает ErrDuplicate
+	CreateAPIKey(ctx context.Context, key *model.APIKey) error
+	// GetAPIKeyByHash возвращает ключ по хешу, в том числе отозванный или просроченный
+	GetAPIKeyByHash(ctx context.Context, hash string) (*model.APIKey, error)
+	// TouchAPIKey отмечает использование ключа в момент at; запись происходит не чаще раза в минуту
+	TouchAPIKey(ctx context.Context, id string, at time.Time) error
+	// ListAPIKeys возвращает ключи по дате создания; непустой userID выбирает ключи одного пользователя
+	ListAPIKeys(ctx context.Context, userID string) ([]model.APIKey, error)
+	// RevokeAPIKey отзывает ключ и возвращает его; если ключа нет или он уже отозван, возвращает sql.ErrNoRows
+	RevokeAPIKey(ctx context.Context, id string) (*model.APIKey, error)
+
+	// StartPause открывает период приостановки подписки; если подписки нет, возвращает sql.ErrNoRows
+	StartPause(ctx context.Context, subscriptionID string, at time.Time) error
+	// EndPause закрывает открытый период приостановки, если он есть
+	EndPause(ctx context.Context, subscriptionID string, at time.Time) error
+	// ListPauses возвращает периоды приостановки подписок, сгруппированные по ID подписки
+	ListPauses(ctx context.Context, subscriptionIDs []string) (map[string][]model.Pause, error)
+
+	// UpsertPriceChange сохраняет цену подписки с даты, заменяя прежнюю; change.UpdatedAt заполняется.
+	// Если подписки нет, возвращает sql.ErrNoRows
+	UpsertPriceChange(ctx context.Context, change *model.PriceChange) error
+	// DeletePriceChange удаляет изменение цены подписки на дату; если его нет, возвращает sql.ErrNoRows
+	DeletePriceChange(ctx context.Context, subscriptionID string, effectiveFrom time.Time) error
+	// ListPriceChanges возвращает изменения цен подписок, сгруппированные по ID подписки и упорядоченные по дате
+	ListPriceChanges(ctx context.Context, subscriptionIDs []string) (map[string][]model.PriceChange, error)
+
+	// AppendChange записывает событие в ленту изменений и заполняет change.Sequence и change.ChangedAt;
+	// подписка должна существовать, иначе возвращается sql.ErrNoRows
+	AppendChange(ctx context.Context, change *model.SubscriptionChange) error
+	// ListChanges возвращает до limit событий с Sequence больше afterSequence в порядке записи.
+	// Область проверяется по организации и владельцу подписки на момент события
+	ListChanges(ctx context.Context, afterSequence int64, limit int) ([]model.SubscriptionChange, error)
+
+	// AppendAudit записывает запись журнала аудита и заполняет entry.ID и entry.CreatedAt
+	AppendAudit(ctx context.Context, entry *model.AuditEntry) error
+	// ListAudit возвращает записи журнала аудита по фильтру, от новых к старым
+	ListAudit(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, error)
+
+	// WithScope возвращает репозиторий, все запросы которого к подпискам, ленте изменений
+	// и журналу аудита ограничены scope (организацией и владельцем) прямо в SQL: чужие подписки
+	// не находятся, не изменяются и не попадают в списки и суммы. API-ключи ограничиваются
+	// организацией. Паузы и цены доступны по ID подписки, поэтому сервис обращается к ним
+	// только после того, как нашёл подписку через этот же репозиторий.
+	WithScope(scope model.Scope) SubscriptionRepository
+
+	// WithTx выполняет fn в транзакции: все изменения через переданный репозиторий
+	// фиксируются вместе или откатываются, если fn вернула ошибку
+	WithTx(ctx context.Context, fn func(repo SubscriptionRepository) error) error
+}
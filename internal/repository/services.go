@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/lib/pq"
+)
+
+const serviceColumns = `id, name, aliases, category, default_price, currency, vendor_url, created_at, updated_at`
+
+// scanService читает строку с колонками serviceColumns
+func scanService(row rowScanner) (*model.Service, error) {
+	var svc model.Service
+	var defaultPrice sql.NullInt64
+
+	err := row.Scan(&svc.ID, &svc.Name, pq.Array(&svc.Aliases), &svc.Category, &defaultPrice, &svc.Currency, &svc.VendorURL, &svc.CreatedAt, &svc.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if defaultPrice.Valid {
+		price := int(defaultPrice.Int64)
+		svc.DefaultPrice = &price
+	}
+	if svc.Aliases == nil {
+		svc.Aliases = []string{}
+	}
+	return &svc, nil
+}
+
+// CreateService сохраняет сервис каталога; занятое название возвращает ErrDuplicate
+func (r *PostgresRepository) CreateService(ctx context.Context, svc *model.Service) error {
+	query := `INSERT INTO services (id, name, aliases, category, default_price, currency, vendor_url)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING created_at, updated_at`
+	err := r.db.QueryRowContext(ctx, query, svc.ID, svc.Name, pq.Array(svc.Aliases), svc.Category, svc.DefaultPrice, svc.Currency, svc.VendorURL).
+		Scan(&svc.CreatedAt, &svc.UpdatedAt)
+	return TranslateError(err)
+}
+
+// GetService возвращает сервис каталога по ID
+func (r *PostgresRepository) GetService(ctx context.Context, id string) (*model.Service, error) {
+	query := `SELECT ` + serviceColumns + ` FROM services WHERE id = $1`
+	return scanService(r.db.QueryRowContext(ctx, query, id))
+}
+
+// FindServiceByName ищет сервис, у которого название или один из синонимов совпадает с name без учёта регистра
+func (r *PostgresRepository) FindServiceByName(ctx context.Context, name string) (*model.Service, error) {
+	query := `SELECT ` + serviceColumns + ` FROM services
+	WHERE lower(name) = lower($1) OR EXISTS (SELECT 1 FROM unnest(aliases) AS alias WHERE lower(alias) = lower($1))
+	ORDER BY lower(name) = lower($1) DESC
+	LIMIT 1`
+	return scanService(r.db.QueryRowContext(ctx, query, name))
+}
+
+// UpdateService заменяет данные сервиса каталога; занятое название возвращает ErrDuplicate
+func (r *PostgresRepository) UpdateService(ctx context.Context, svc *model.Service) error {
+	query := `UPDATE services SET name = $1, aliases = $2, category = $3, default_price = $4, currency = $5, vendor_url = $6, updated_at = now()
+	WHERE id = $7
+	RETURNING created_at, updated_at`
+	err := r.db.QueryRowContext(ctx, query, svc.Name, pq.Array(svc.Aliases), svc.Category, svc.DefaultPrice, svc.Currency, svc.VendorURL, svc.ID).
+		Scan(&svc.CreatedAt, &svc.UpdatedAt)
+	return TranslateError(err)
+}
+
+// DeleteService удаляет сервис каталога; подписки остаются без ссылки на него
+func (r *PostgresRepository) DeleteService(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM services WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ListServices возвращает сервисы каталога по фильтру, упорядоченные по названию
+func (r *PostgresRepository) ListServices(ctx context.Context, filter model.ServiceFilter) ([]model.Service, error) {
+	query := `SELECT ` + serviceColumns + ` FROM services WHERE 1=1`
+	args := []interface{}{}
+	argIdx := 1
+
+	if filter.Category != "" {
+		query += ` AND category = $` + fmt.Sprint(argIdx)
+		args = append(args, filter.Category)
+		argIdx++
+	}
+	query += ` ORDER BY lower(name)`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var services []model.Service
+	for rows.Next() {
+		svc, err := scanService(rows)
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, *svc)
+	}
+
+	return services, rows.Err()
+}
@@ -1,69 +1,330 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"time"
 
 	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/lib/pq"
 )
 
-func CreateSubscription(db *sql.DB, id, serviceName string, price int, userID string, startDate time.Time, endDate *time.Time) error {
-	query := `INSERT INTO subscriptions (id, service_name, price, user_id, start_date, end_date)
-	 VALUES ($1, $2, $3, $4, $5, $6)`
-	_, err := db.Exec(query, id, serviceName, price, userID, startDate, endDate)
+// Коды ошибок PostgreSQL, которые переводятся в ошибки репозитория
+const (
+	uniqueViolation       = "23505"
+	foreignKeyViolation   = "23503"
+	checkViolation        = "23514"
+	insufficientPrivilege = "42501" // в том числе нарушение WITH CHECK политики построчной безопасности
+)
+
+// statusExpr вычисляет статус подписки: активная или приостановленная подписка,
+// чей end_date (последний день действия) уже прошёл, считается истёкшей
+const statusExpr = `CASE WHEN status IN ('active', 'paused') AND end_date < CURRENT_DATE THEN 'expired' ELSE status END`
+
+// cycleExpr — длительность одного цикла оплаты подписки
+const cycleExpr = `CASE billing_period
+	WHEN 'weekly' THEN make_interval(days => 7 * interval_count)
+	WHEN 'quarterly' THEN make_interval(months => 3 * interval_count)
+	WHEN 'yearly' THEN make_interval(years => interval_count)
+	ELSE make_interval(months => interval_count) END`
+
+const subscriptionColumns = `id, service_id, service_name, price, currency, billing_period, interval_count, category, tags, user_id, organization_id, start_date, end_date, ` + statusExpr + `, version, created_at, updated_at, deleted_at`
+
+// dbtx — общие методы *sql.DB и *sql.Tx
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// PostgresRepository хранит подписки в PostgreSQL
+type PostgresRepository struct {
+	db dbtx
+	// pool — исходный пул соединений; nil внутри транзакции
+	pool *sql.DB
+	// scope ограничивает запросы к подпискам, см. WithScope
+	scope model.Scope
+	// RowSecurity включает передачу организации из scope в app.tenant_id, по которой
+	// политики построчной безопасности PostgreSQL скрывают подписки других организаций
+	RowSecurity bool
+}
+
+// NewPostgresRepository создаёт репозиторий поверх открытого подключения к БД
+func NewPostgresRepository(db *sql.DB) *PostgresRepository {
+	return &PostgresRepository{db: db, pool: db}
+}
+
+// WithTx выполняет fn в транзакции БД. Вложенные вызовы используют уже открытую транзакцию.
+func (r *PostgresRepository) WithTx(ctx context.Context, fn func(repo SubscriptionRepository) error) error {
+	if r.pool == nil {
+		return fn(r)
+	}
+
+	tx, err := r.pool.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	return err
+	defer tx.Rollback()
+
+	if r.RowSecurity && r.scope.OrganizationID != "" {
+		// Настройка действует до конца транзакции и не переходит к следующему владельцу соединения
+		if _, err := tx.ExecContext(ctx, `SELECT set_config('app.tenant_id', $1, true)`, r.scope.OrganizationID); err != nil {
+			return err
+		}
+	}
+
+	if err := fn(&PostgresRepository{db: tx, scope: r.scope, RowSecurity: r.RowSecurity}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// WithScope возвращает репозиторий на том же подключении, ограниченный scope
+func (r *PostgresRepository) WithScope(scope model.Scope) SubscriptionRepository {
+	return &PostgresRepository{db: r.db, pool: r.pool, scope: scope, RowSecurity: r.RowSecurity}
+}
+
+// inTenant выполняет fn так, чтобы запросы к подпискам проверялись политиками построчной
+// безопасности: с RowSecurity и организацией в scope — в транзакции с app.tenant_id,
+// иначе просто на текущем подключении
+func (r *PostgresRepository) inTenant(ctx context.Context, fn func(repo *PostgresRepository) error) error {
+	if !r.RowSecurity || r.scope.OrganizationID == "" || r.pool == nil {
+		return fn(r)
+	}
+	return r.WithTx(ctx, func(repo SubscriptionRepository) error {
+		return fn(repo.(*PostgresRepository))
+	})
+}
+
+// scopeCondition возвращает условие, ограничивающее подписки областью репозитория,
+// и args с добавленными аргументами условия; без ограничения условие пустое
+func (r *PostgresRepository) scopeCondition(args []interface{}) (string, []interface{}) {
+	condition, args, _ := scopeConditionAt(r.scope, args, len(args)+1)
+	return condition, args
+}
+
+// scopeConditionAt строит условие scope с аргументами начиная с $argIdx и возвращает
+// номер следующего свободного аргумента
+func scopeConditionAt(scope model.Scope, args []interface{}, argIdx int) (string, []interface{}, int) {
+	condition := ""
+	if scope.OrganizationID != "" {
+		condition += ` AND organization_id = $` + fmt.Sprint(argIdx)
+		args = append(args, scope.OrganizationID)
+		argIdx++
+	}
+	if scope.UserID != "" {
+		condition += ` AND user_id = $` + fmt.Sprint(argIdx)
+		args = append(args, scope.UserID)
+		argIdx++
+	}
+	return condition, args, argIdx
 }
 
-func GetSubscription(db *sql.DB, id string) (*model.Subscription, error) {
-	query := `SELECT id, service_name, price, user_id, start_date, end_date FROM subscriptions WHERE id = $1`
-	row := db.QueryRow(query, id)
+// rowScanner общий интерфейс для *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
 
+// scanSubscription читает строку с колонками subscriptionColumns
+func scanSubscription(row rowScanner) (*model.Subscription, error) {
 	var sub model.Subscription
-	var endDate sql.NullTime
+	var serviceID sql.NullString
+	var endDate, deletedAt sql.NullTime
 
-	err := row.Scan(&sub.ID, &sub.ServiceName, &sub.Price, &sub.UserID, &sub.StartDate, &sub.EndDate)
+	err := row.Scan(&sub.ID, &serviceID, &sub.ServiceName, &sub.Price, &sub.Currency, &sub.BillingPeriod, &sub.IntervalCount, &sub.Category, pq.Array(&sub.Tags), &sub.UserID, &sub.OrganizationID, &sub.StartDate, &endDate, &sub.Status, &sub.Version, &sub.CreatedAt, &sub.UpdatedAt, &deletedAt)
 	if err != nil {
 		return nil, err
 	}
 
+	if serviceID.Valid {
+		sub.ServiceID = &serviceID.String
+	}
+	if sub.Tags == nil {
+		sub.Tags = []string{}
+	}
 	if endDate.Valid {
 		sub.EndDate = &endDate.Time
-	} else {
-		sub.EndDate = nil
+	}
+	if deletedAt.Valid {
+		sub.DeletedAt = &deletedAt.Time
 	}
 
 	return &sub, nil
-} // реализовать если успею GetSubscriptionByID, ByServiceName и GetByPrice
+}
 
-func UpdateSubscription(db *sql.DB, id, serviceName string, price int, userID string, startDate time.Time, endDate *time.Time) error {
-	query := `UPDATE subscriptions SET service_name = $1, price = $2, user_id = $3, start_date = $4, end_date = $5 
-	WHERE id = $6`
+// querySubscriptions выполняет запрос и читает все строки
+func (r *PostgresRepository) querySubscriptions(ctx context.Context, query string, args ...interface{}) ([]model.Subscription, error) {
+	var subscriptions []model.Subscription
 
-	result, err := db.Exec(query, serviceName, price, userID, startDate, endDate, id)
+	err := r.streamSubscriptions(ctx, func(sub model.Subscription) error {
+		subscriptions = append(subscriptions, sub)
+		return nil
+	}, query, args...)
 	if err != nil {
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+// streamSubscriptions выполняет запрос и передаёт строки в fn по мере чтения из курсора,
+// не накапливая результат в памяти. Ошибка fn прерывает чтение.
+func (r *PostgresRepository) streamSubscriptions(ctx context.Context, fn func(model.Subscription) error, query string, args ...interface{}) error {
+	return r.inTenant(ctx, func(repo *PostgresRepository) error {
+		rows, err := repo.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			sub, err := scanSubscription(rows)
+			if err != nil {
+				return err
+			}
+			if err := fn(*sub); err != nil {
+				return err
+			}
+		}
+
+		return rows.Err()
+	})
+}
+
+// querySubscription выполняет запрос, возвращающий одну подписку
+func (r *PostgresRepository) querySubscription(ctx context.Context, query string, args ...interface{}) (*model.Subscription, error) {
+	var sub *model.Subscription
+	err := r.inTenant(ctx, func(repo *PostgresRepository) error {
+		var err error
+		sub, err = scanSubscription(repo.db.QueryRowContext(ctx, query, args...))
+		return err
+	})
+	return sub, err
+}
+
+// execSubscriptions выполняет изменяющий подписки запрос без возвращаемых строк
+func (r *PostgresRepository) execSubscriptions(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	var result sql.Result
+	err := r.inTenant(ctx, func(repo *PostgresRepository) error {
+		var err error
+		result, err = repo.db.ExecContext(ctx, query, args...)
 		return err
+	})
+	return result, err
+}
+
+// CreateSubscription сохраняет подписку; created_at и updated_at проставляет база данных
+func (r *PostgresRepository) CreateSubscription(ctx context.Context, sub *model.Subscription) error {
+	if sub.OrganizationID == "" {
+		sub.OrganizationID = model.DefaultOrganizationID
 	}
+	query := `INSERT INTO subscriptions (id, service_id, service_name, price, currency, billing_period, interval_count, category, tags, user_id, organization_id, start_date, end_date, status)
+	 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	 RETURNING version, created_at, updated_at`
+	err := r.inTenant(ctx, func(repo *PostgresRepository) error {
+		return repo.db.QueryRowContext(ctx, query, sub.ID, sub.ServiceID, sub.ServiceName, sub.Price, sub.Currency, sub.BillingPeriod, sub.IntervalCount, sub.Category, pq.Array(tagsOrEmpty(sub.Tags)), sub.UserID, sub.OrganizationID, sub.StartDate, sub.EndDate, sub.Status).
+			Scan(&sub.Version, &sub.CreatedAt, &sub.UpdatedAt)
+	})
+	return TranslateError(err)
+}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
+// tagsOrEmpty заменяет nil пустым списком: колонка tags не допускает NULL
+func tagsOrEmpty(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+// tagsCondition возвращает условие фильтра по тегам с параметром $argIdx:
+// пересечение массивов для любого из тегов или вхождение для всех
+func tagsCondition(matchAll bool, argIdx int) string {
+	if matchAll {
+		return ` AND tags @> $` + fmt.Sprint(argIdx) + `::TEXT[]`
+	}
+	return ` AND tags && $` + fmt.Sprint(argIdx) + `::TEXT[]`
+}
+
+// TranslateError приводит ошибки драйвера к ошибкам репозитория: ErrDuplicate, ErrInvalidReference,
+// ErrConstraint и ErrRowSecurity. Прочие ошибки возвращаются как есть.
+func TranslateError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	switch pqErr.Code {
+	case uniqueViolation:
+		return ErrDuplicate
+	case foreignKeyViolation:
+		return fmt.Errorf("%w: %s", ErrInvalidReference, pqErr.Constraint)
+	case checkViolation:
+		return fmt.Errorf("%w: %s", ErrConstraint, pqErr.Constraint)
+	case insufficientPrivilege:
+		return fmt.Errorf("%w: %s", ErrRowSecurity, pqErr.Message)
+	default:
 		return err
 	}
+}
 
-	if rowsAffected == 0 {
-		return sql.ErrNoRows
+// IsUnavailable сообщает, что ошибка временная и запрос можно повторить: база недоступна,
+// соединение оборвалось, истёк тайм-аут, не хватило ресурсов или транзакция проиграла конфликт
+func IsUnavailable(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		// 08 — соединение, 40 — сериализация и взаимоблокировки, 53 — ресурсы,
+		// 55 — блокировки, 57 — отмена по тайм-ауту и остановка сервера
+		case "08", "40", "53", "55", "57":
+			return true
+		}
+		return false
 	}
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+func (r *PostgresRepository) GetSubscription(ctx context.Context, id string, includeDeleted bool) (*model.Subscription, error) {
+	query := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE id = $1`
+	if !includeDeleted {
+		query += ` AND deleted_at IS NULL`
+	}
+	condition, args := r.scopeCondition([]interface{}{id})
+	return r.querySubscription(ctx, query+condition, args...)
+}
+
+// UpdateSubscription обновляет подписку; updated_at и version обновляют триггеры в базе данных.
+// Если sub.Version больше нуля, строка обновляется только при совпадении версии.
+// Если подписки нет или версия не совпала, возвращает sql.ErrNoRows.
+func (r *PostgresRepository) UpdateSubscription(ctx context.Context, sub *model.Subscription) error {
+	condition, args := r.scopeCondition([]interface{}{sub.ServiceID, sub.ServiceName, sub.Price, sub.Currency, sub.BillingPeriod, sub.IntervalCount,
+		sub.Category, pq.Array(tagsOrEmpty(sub.Tags)), sub.UserID, sub.StartDate, sub.EndDate, sub.ID, sub.Version})
+	query := `UPDATE subscriptions SET service_id = $1, service_name = $2, price = $3, currency = $4, billing_period = $5, interval_count = $6,
+	category = $7, tags = $8, user_id = $9, start_date = $10, end_date = $11
+	WHERE id = $12 AND deleted_at IS NULL AND ($13::BIGINT = 0 OR version = $13)` + condition + `
+	RETURNING version, created_at, updated_at`
 
-	return err
+	return r.inTenant(ctx, func(repo *PostgresRepository) error {
+		return repo.db.QueryRowContext(ctx, query, args...).Scan(&sub.Version, &sub.CreatedAt, &sub.UpdatedAt)
+	})
 }
 
-func DeleteSubscription(db *sql.DB, id string) error {
-	query := `DELETE FROM subscriptions WHERE id = $1`
+// DeleteSubscription мягко удаляет подписку; при version больше нуля — только если версия совпадает.
+// Если подписки нет, она уже удалена или версия не совпала, возвращает sql.ErrNoRows.
+func (r *PostgresRepository) DeleteSubscription(ctx context.Context, id string, version int64) error {
+	condition, args := r.scopeCondition([]interface{}{id, version})
+	query := `UPDATE subscriptions SET deleted_at = now()
+	WHERE id = $1 AND deleted_at IS NULL AND ($2::BIGINT = 0 OR version = $2)` + condition
 
-	result, err := db.Exec(query, id)
+	result, err := r.execSubscriptions(ctx, query, args...)
 	if err != nil {
 		return err
 	}
@@ -80,71 +341,242 @@ func DeleteSubscription(db *sql.DB, id string) error {
 	return nil
 }
 
-func ListSubscriptions(db *sql.DB) ([]model.Subscription, error) {
-	query := `SELECT id, service_name, price, user_id, start_date, end_date FROM subscriptions`
-	rows, err := db.Query(query)
+// RestoreSubscription восстанавливает мягко удалённую подписку
+func (r *PostgresRepository) RestoreSubscription(ctx context.Context, id string) (*model.Subscription, error) {
+	condition, args := r.scopeCondition([]interface{}{id})
+	query := `UPDATE subscriptions SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL` + condition + `
+	RETURNING ` + subscriptionColumns
+	return r.querySubscription(ctx, query, args...)
+}
+
+// SetSubscriptionStatus меняет статус и дату окончания не удалённой подписки.
+// Если подписки нет, возвращает sql.ErrNoRows.
+func (r *PostgresRepository) SetSubscriptionStatus(ctx context.Context, id, status string, endDate *time.Time) (*model.Subscription, error) {
+	condition, args := r.scopeCondition([]interface{}{id, status, endDate})
+	query := `UPDATE subscriptions SET status = $2, end_date = $3 WHERE id = $1 AND deleted_at IS NULL` + condition + `
+	RETURNING ` + subscriptionColumns
+	return r.querySubscription(ctx, query, args...)
+}
+
+// PurgeDeleted окончательно удаляет подписки, мягко удалённые раньше olderThan
+func (r *PostgresRepository) PurgeDeleted(ctx context.Context, olderThan time.Time) (int64, error) {
+	condition, args := r.scopeCondition([]interface{}{olderThan})
+	query := `DELETE FROM subscriptions WHERE deleted_at IS NOT NULL AND deleted_at < $1` + condition
+
+	result, err := r.execSubscriptions(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	defer rows.Close()
+	return result.RowsAffected()
+}
 
-	var subscriptions []model.Subscription
+// sortColumns сопоставляет допустимые поля сортировки с колонками таблицы
+var sortColumns = map[string]string{
+	"start_date":   "start_date",
+	"price":        "price",
+	"service_name": "service_name",
+	"created_at":   "created_at",
+	"updated_at":   "updated_at",
+}
 
-	for rows.Next() {
-		var sub model.Subscription
-		var endDate sql.NullTime
+// ListSubscriptions возвращает страницу подписок по фильтру.
+// Пагинация keyset: строки после курсора (значение поля сортировки, id).
+func (r *PostgresRepository) ListSubscriptions(ctx context.Context, filter model.SubscriptionFilter) ([]model.Subscription, error) {
+	query, args := listQuery(filter, r.scope)
+	return r.querySubscriptions(ctx, query, args...)
+}
 
-		err := rows.Scan(&sub.ID, &sub.ServiceName, &sub.Price, &sub.UserID, &sub.StartDate, &sub.EndDate)
-		if err != nil {
-			return nil, err
-		}
+// StreamSubscriptions передаёт в fn все подписки по фильтру в порядке сортировки, без ограничения filter.Limit
+func (r *PostgresRepository) StreamSubscriptions(ctx context.Context, filter model.SubscriptionFilter, fn func(model.Subscription) error) error {
+	filter.Limit = 0
+	query, args := listQuery(filter, r.scope)
+	return r.streamSubscriptions(ctx, fn, query, args...)
+}
 
-		if endDate.Valid {
-			sub.EndDate = &endDate.Time
-		} else {
-			sub.EndDate = nil
-		}
-		subscriptions = append(subscriptions, sub)
+// listQuery строит запрос списка подписок по фильтру в пределах scope
+func listQuery(filter model.SubscriptionFilter, scope model.Scope) (string, []interface{}) {
+	sortColumn, ok := sortColumns[filter.Sort]
+	if !ok {
+		sortColumn = "start_date"
+	}
+	direction := "ASC"
+	comparison := ">"
+	if filter.Order == "desc" {
+		direction = "DESC"
+		comparison = "<"
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, err
+	query := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE 1=1`
+	args := []interface{}{}
+	argIdx := 1
+
+	if !filter.IncludeDeleted {
+		query += ` AND deleted_at IS NULL`
+	}
+	condition, args, argIdx := scopeConditionAt(scope, args, argIdx)
+	query += condition
+	if filter.UserID != "" {
+		query += ` AND user_id = $` + fmt.Sprint(argIdx)
+		args = append(args, filter.UserID)
+		argIdx++
+	}
+	if filter.ServiceName != "" {
+		query += ` AND service_name = $` + fmt.Sprint(argIdx)
+		args = append(args, filter.ServiceName)
+		argIdx++
+	}
+	if filter.Currency != "" {
+		query += ` AND currency = $` + fmt.Sprint(argIdx)
+		args = append(args, filter.Currency)
+		argIdx++
+	}
+	if filter.Category != "" {
+		query += ` AND category = $` + fmt.Sprint(argIdx)
+		args = append(args, filter.Category)
+		argIdx++
+	}
+	if len(filter.Tags) > 0 {
+		query += tagsCondition(filter.MatchAllTags, argIdx)
+		args = append(args, pq.Array(filter.Tags))
+		argIdx++
+	}
+	if filter.MinPrice != nil {
+		query += ` AND price >= $` + fmt.Sprint(argIdx)
+		args = append(args, *filter.MinPrice)
+		argIdx++
+	}
+	if filter.MaxPrice != nil {
+		query += ` AND price <= $` + fmt.Sprint(argIdx)
+		args = append(args, *filter.MaxPrice)
+		argIdx++
+	}
+	if filter.UpdatedSince != nil {
+		query += ` AND updated_at > $` + fmt.Sprint(argIdx)
+		args = append(args, *filter.UpdatedSince)
+		argIdx++
+	}
+	if filter.ActiveFrom != nil && filter.ActiveTo != nil {
+		query += ` AND start_date <= $` + fmt.Sprint(argIdx) + ` AND (end_date IS NULL OR end_date >= $` + fmt.Sprint(argIdx+1) + `)`
+		args = append(args, *filter.ActiveTo, *filter.ActiveFrom)
+		argIdx += 2
+	}
+	if filter.Status != "" {
+		query += ` AND ` + statusExpr + ` = $` + fmt.Sprint(argIdx)
+		args = append(args, filter.Status)
+		argIdx++
+	}
+	if filter.After != nil {
+		query += ` AND (` + sortColumn + `, id) ` + comparison + ` ($` + fmt.Sprint(argIdx) + `, $` + fmt.Sprint(argIdx+1) + `)`
+		args = append(args, filter.After.Value, filter.After.ID)
+		argIdx += 2
 	}
 
-	return subscriptions, nil
+	query += ` ORDER BY ` + sortColumn + ` ` + direction + `, id ` + direction
+	if filter.Limit > 0 {
+		query += ` LIMIT $` + fmt.Sprint(argIdx)
+		args = append(args, filter.Limit+1)
+		argIdx++
+	}
+
+	return query, args
 }
 
-func CalculateTotalCost(db *sql.DB, userID, serviceName string, startDate, endDate time.Time) (int, error) {
-	query := `SELECT COALESCE(SUM(price), 0) FROM subscriptions WHERE 1=1`
+// StreamSubscriptionsForPeriod передаёт в fn подписки, пересекающиеся с периодом [filter.StartDate, filter.EndDate].
+// Нулевые даты означают отсутствие ограничения с соответствующей стороны.
+func (r *PostgresRepository) StreamSubscriptionsForPeriod(ctx context.Context, filter model.CostFilter, fn func(model.Subscription) error) error {
+	query := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE 1=1`
 	args := []interface{}{}
 	argIdx := 1
 
-	if userID != "" {
+	if !filter.IncludeDeleted {
+		query += ` AND deleted_at IS NULL`
+	}
+	condition, args, argIdx := scopeConditionAt(r.scope, args, argIdx)
+	query += condition
+	if filter.UserID != "" {
 		query += ` AND user_id = $` + fmt.Sprint(argIdx)
-		args = append(args, userID)
+		args = append(args, filter.UserID)
 		argIdx++
 	}
-	if serviceName != "" {
+	if filter.ServiceName != "" {
 		query += ` AND service_name = $` + fmt.Sprint(argIdx)
-		args = append(args, serviceName)
+		args = append(args, filter.ServiceName)
+		argIdx++
+	}
+	if filter.ServiceID != "" {
+		query += ` AND service_id = $` + fmt.Sprint(argIdx)
+		args = append(args, filter.ServiceID)
+		argIdx++
+	}
+	if filter.Category != "" {
+		query += ` AND category = $` + fmt.Sprint(argIdx)
+		args = append(args, filter.Category)
+		argIdx++
+	}
+	if len(filter.Tags) > 0 {
+		query += tagsCondition(filter.MatchAllTags, argIdx)
+		args = append(args, pq.Array(filter.Tags))
 		argIdx++
 	}
-	if !startDate.IsZero() {
-		query += ` AND start_date >= $` + fmt.Sprint(argIdx)
-		args = append(args, startDate)
+	if !filter.StartDate.IsZero() {
+		// Последний оплаченный цикл подписки заканчивается не раньше начала периода
+		query += ` AND (end_date IS NULL OR end_date + ` + cycleExpr + ` > $` + fmt.Sprint(argIdx) + `)`
+		args = append(args, filter.StartDate)
 		argIdx++
 	}
-	if !endDate.IsZero() {
+	if !filter.EndDate.IsZero() {
+		// Подписка началась не позже конца периода
 		query += ` AND start_date <= $` + fmt.Sprint(argIdx)
-		args = append(args, endDate)
+		args = append(args, filter.EndDate)
 		argIdx++
 	}
+	query += ` ORDER BY start_date, id`
+
+	return r.streamSubscriptions(ctx, fn, query, args...)
+}
+
+// ExistingKeys возвращает те из keys, для которых уже есть не удалённая подписка
+func (r *PostgresRepository) ExistingKeys(ctx context.Context, keys []model.SubscriptionKey) (map[model.SubscriptionKey]bool, error) {
+	existing := make(map[model.SubscriptionKey]bool)
+	if len(keys) == 0 {
+		return existing, nil
+	}
 
-	var totalCost int
-	err := db.QueryRow(query, args...).Scan(&totalCost)
+	userIDs := make([]string, len(keys))
+	serviceNames := make([]string, len(keys))
+	startDates := make([]string, len(keys))
+	for i, key := range keys {
+		userIDs[i] = key.UserID
+		serviceNames[i] = key.ServiceName
+		startDates[i] = key.StartDate.Format("2006-01-02")
+	}
+
+	condition, args := r.scopeCondition([]interface{}{pq.Array(userIDs), pq.Array(serviceNames), pq.Array(startDates)})
+	query := `SELECT user_id, service_name, start_date FROM subscriptions
+	WHERE deleted_at IS NULL AND (user_id, service_name, start_date) IN (
+		SELECT * FROM unnest($1::UUID[], $2::TEXT[], $3::DATE[])
+	)` + condition
+
+	err := r.inTenant(ctx, func(repo *PostgresRepository) error {
+		rows, err := repo.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var key model.SubscriptionKey
+			if err := rows.Scan(&key.UserID, &key.ServiceName, &key.StartDate); err != nil {
+				return err
+			}
+			key.StartDate = time.Date(key.StartDate.Year(), key.StartDate.Month(), key.StartDate.Day(), 0, 0, 0, 0, time.UTC)
+			existing[key] = true
+		}
+		return rows.Err()
+	})
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	return totalCost, nil
+	return existing, nil
 }
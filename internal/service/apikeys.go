@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Headliner38/Subscription_Service/internal/auth"
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/utils"
+)
+
+// errAPIKeyNotFound — ключа с таким ID нет или он уже отозван
+var errAPIKeyNotFound = newNotFoundError("api key not found")
+
+// APIKeyInput — данные нового API-ключа в том виде, в котором они пришли в запросе
+type APIKeyInput struct {
+	Name   string
+	UserID string
+	// Role — роль владельца ключа; по умолчанию user
+	Role string
+	// ExpiresAt — момент истечения в RFC 3339 или дата YYYY-MM-DD; без него ключ бессрочный
+	ExpiresAt *string
+	// OrganizationID — организация, с данными которой работает ключ; без неё ключ не привязан к организации
+	OrganizationID string
+}
+
+// validateAPIKeyInput проверяет данные ключа и приводит их к виду, в котором они хранятся
+func validateAPIKeyInput(in APIKeyInput, now time.Time) (model.APIKey, error) {
+	key := model.APIKey{
+		Name:   strings.TrimSpace(in.Name),
+		UserID: strings.TrimSpace(in.UserID),
+		Role:   strings.ToLower(strings.TrimSpace(in.Role)),
+		// Организация хранится в нижнем регистре, как её возвращает PostgreSQL
+		OrganizationID: strings.ToLower(strings.TrimSpace(in.OrganizationID)),
+	}
+
+	if key.Name == "" {
+		return key, NewValidationError("name", "name is required")
+	}
+	if len(key.Name) > 255 {
+		return key, NewValidationError("name", "name must be at most 255 characters")
+	}
+	if !utils.IsValidUUID(key.UserID) {
+		return key, NewValidationError("user_id", "user_id must be a valid UUID")
+	}
+	if key.Role == "" {
+		key.Role = model.RoleUser
+	}
+	if key.Role != model.RoleUser && key.Role != model.RoleAdmin {
+		return key, NewValidationError("role", "role must be one of: user, admin")
+	}
+	if key.OrganizationID != "" && !utils.IsValidUUID(key.OrganizationID) {
+		return key, NewValidationError("organization_id", "organization_id must be a valid UUID")
+	}
+	if in.ExpiresAt != nil && *in.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, *in.ExpiresAt)
+		if err != nil {
+			if expiresAt, err = time.Parse(dateLayout, *in.ExpiresAt); err != nil {
+				return key, NewValidationError("expires_at", "invalid expires_at format, expected RFC 3339 or YYYY-MM-DD")
+			}
+		}
+		if !expiresAt.After(now) {
+			return key, NewValidationError("expires_at", "expires_at must be in the future")
+		}
+		key.ExpiresAt = &expiresAt
+	}
+	return key, nil
+}
+
+// CreateAPIKey выпускает API-ключ и возвращает его вместе с секретом.
+// Секрет не сохраняется и больше нигде не показывается. Сервис, ограниченный организацией,
+// выпускает ключи только для неё.
+func (s *SubscriptionService) CreateAPIKey(ctx context.Context, input APIKeyInput) (*model.APIKey, string, error) {
+	log.Printf("[SERVICE] Creating API key %q for user: %s", input.Name, input.UserID)
+
+	if strings.TrimSpace(input.OrganizationID) == "" {
+		input.OrganizationID = s.scope.OrganizationID
+	}
+	key, err := validateAPIKeyInput(input, time.Now())
+	if err == nil && s.scope.OrganizationID != "" && key.OrganizationID != strings.ToLower(s.scope.OrganizationID) {
+		err = NewValidationError("organization_id", "organization_id must be the organization of the request")
+	}
+	if err != nil {
+		log.Printf("[ERROR] Invalid API key data: %v", err)
+		return nil, "", err
+	}
+
+	secret, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		log.Printf("[ERROR] Failed to generate API key: %v", err)
+		return nil, "", err
+	}
+	key.ID = utils.GenerateUUID()
+	key.Prefix, key.Hash = prefix, hash
+
+	if err := s.Repo.CreateAPIKey(ctx, &key); err != nil {
+		log.Printf("[ERROR] Failed to save API key: %v", err)
+		return nil, "", wrapRepoError(err)
+	}
+
+	log.Printf("[SUCCESS] API key created with ID: %s, prefix: %s", key.ID, key.Prefix)
+	return &key, secret, nil
+}
+
+// ListAPIKeys возвращает API-ключи, включая отозванные; непустой userID выбирает ключи одного пользователя
+func (s *SubscriptionService) ListAPIKeys(ctx context.Context, userID string) ([]model.APIKey, error) {
+	log.Printf("[SERVICE] Listing API keys, user: %q", userID)
+
+	if userID != "" && !utils.IsValidUUID(userID) {
+		return nil, NewValidationError("user_id", "user_id must be a valid UUID")
+	}
+
+	keys, err := s.Repo.ListAPIKeys(ctx, userID)
+	if err != nil {
+		log.Printf("[ERROR] Failed to list API keys: %v", err)
+		return nil, wrapRepoError(err)
+	}
+
+	log.Printf("[SUCCESS] Retrieved %d API keys", len(keys))
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает API-ключ; запросы с ним сразу перестают проходить аутентификацию
+func (s *SubscriptionService) RevokeAPIKey(ctx context.Context, id string) (*model.APIKey, error) {
+	log.Printf("[SERVICE] Revoking API key with ID: %s", id)
+
+	if !utils.IsValidUUID(id) {
+		log.Printf("[ERROR] API key ID is not a valid UUID: %s", id)
+		return nil, errAPIKeyNotFound
+	}
+
+	key, err := s.Repo.RevokeAPIKey(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errAPIKeyNotFound
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to revoke API key: %v", err)
+		return nil, wrapRepoError(err)
+	}
+
+	log.Printf("[SUCCESS] Revoked API key with ID: %s", id)
+	return key, nil
+}
+
+// AuthenticateAPIKey находит действующий ключ по секрету и возвращает его владельца.
+// Неизвестный, отозванный и просроченный ключи дают auth.ErrInvalidCredentials.
+func (s *SubscriptionService) AuthenticateAPIKey(ctx context.Context, secret string) (*model.Principal, error) {
+	key, err := s.Repo.GetAPIKeyByHash(ctx, auth.HashAPIKey(secret))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, auth.ErrInvalidCredentials
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to look up API key: %v", err)
+		return nil, wrapRepoError(err)
+	}
+
+	now := time.Now()
+	if !key.Active(now) {
+		log.Printf("[ERROR] Rejected inactive API key: %s", key.Prefix)
+		return nil, auth.ErrInvalidCredentials
+	}
+	// Отметка об использовании не должна мешать запросу
+	if err := s.Repo.TouchAPIKey(ctx, key.ID, now); err != nil {
+		log.Printf("[ERROR] Failed to record API key usage: %v", err)
+	}
+
+	return &model.Principal{Subject: key.UserID, Role: key.Role, Method: model.AuthMethodAPIKey, KeyID: key.ID, OrganizationID: key.OrganizationID}, nil
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/repository"
+	"github.com/Headliner38/Subscription_Service/internal/utils"
+)
+
+const auditCursorPrefix = "aud:"
+
+// auditIgnoredFields — служебные поля подписки, которые меняются при каждом изменении
+// и в журнал аудита не записываются
+var auditIgnoredFields = map[string]bool{"version": true, "created_at": true, "updated_at": true}
+
+// auditActions — действия, по которым можно фильтровать журнал
+var auditActions = []string{model.AuditCreate, model.AuditUpdate, model.AuditDelete, model.AuditRestore,
+	model.AuditPause, model.AuditResume, model.AuditCancel, model.AuditSetPrice, model.AuditDeletePrice}
+
+// WithActor возвращает сервис, который записывает изменения подписок в журнал аудита от имени actor
+func (s *SubscriptionService) WithActor(actor model.Actor) *SubscriptionService {
+	return &SubscriptionService{Repo: s.Repo, scope: s.scope, actor: actor}
+}
+
+// audit записывает в журнал действие над подпиской sub с изменёнными полями changes.
+// Вызывается через repo той же транзакции, что и само изменение.
+func (s *SubscriptionService) audit(ctx context.Context, repo repository.SubscriptionRepository, action string, sub *model.Subscription, changes map[string]model.FieldChange) error {
+	return repo.AppendAudit(ctx, &model.AuditEntry{
+		SubscriptionID: sub.ID,
+		OrganizationID: sub.OrganizationID,
+		UserID:         sub.UserID,
+		Action:         action,
+		Actor:          s.actor.Subject,
+		ActorRole:      s.actor.Role,
+		AuthMethod:     s.actor.Method,
+		RequestID:      s.actor.RequestID,
+		Changes:        changes,
+	})
+}
+
+// auditChange записывает в журнал переход подписки из состояния before в after;
+// before равен nil при создании
+func (s *SubscriptionService) auditChange(ctx context.Context, repo repository.SubscriptionRepository, action string, before, after *model.Subscription) error {
+	changes, err := diffSubscriptions(before, after)
+	if err != nil {
+		return err
+	}
+	return s.audit(ctx, repo, action, after, changes)
+}
+
+// diffSubscriptions сравнивает поля JSON-представления подписок и возвращает различающиеся.
+// Значения берутся из JSON, поэтому в журнале они выглядят так же, как в ответах API.
+func diffSubscriptions(before, after *model.Subscription) (map[string]model.FieldChange, error) {
+	oldFields, err := subscriptionFields(before)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := subscriptionFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]model.FieldChange)
+	for field, value := range newFields {
+		if old, ok := oldFields[field]; !auditIgnoredFields[field] && (!ok || !reflect.DeepEqual(old, value)) {
+			changes[field] = model.FieldChange{Old: old, New: value}
+		}
+	}
+	// Поля с omitempty, которые были заданы, а теперь пусты
+	for field, old := range oldFields {
+		if _, ok := newFields[field]; !auditIgnoredFields[field] && !ok {
+			changes[field] = model.FieldChange{Old: old}
+		}
+	}
+	return changes, nil
+}
+
+// subscriptionFields возвращает поля подписки в виде разобранного JSON; nil — пустой набор
+func subscriptionFields(sub *model.Subscription) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	if sub == nil {
+		return fields, nil
+	}
+	data, err := json.Marshal(sub)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// AuditParams — параметры выборки журнала аудита в том виде, в котором они пришли в запросе
+type AuditParams struct {
+	SubscriptionID string
+	UserID         string
+	Actor          string
+	Action         string
+	RequestID      string
+	// From и To — границы времени записи в RFC 3339 или датой YYYY-MM-DD (день включительно)
+	From   string
+	To     string
+	Limit  string
+	Cursor string
+}
+
+// toFilter валидирует параметры и преобразует их в фильтр репозитория
+func (p AuditParams) toFilter() (model.AuditFilter, error) {
+	filter := model.AuditFilter{
+		SubscriptionID: strings.ToLower(p.SubscriptionID),
+		UserID:         strings.ToLower(p.UserID),
+		Actor:          p.Actor,
+		Action:         strings.ToLower(p.Action),
+		RequestID:      p.RequestID,
+		Limit:          defaultListLimit,
+	}
+
+	if filter.SubscriptionID != "" && !utils.IsValidUUID(filter.SubscriptionID) {
+		return filter, NewValidationError("subscription_id", "subscription_id must be a valid UUID")
+	}
+	if filter.UserID != "" && !utils.IsValidUUID(filter.UserID) {
+		return filter, NewValidationError("user_id", "user_id must be a valid UUID")
+	}
+	if filter.Action != "" {
+		valid := false
+		for _, action := range auditActions {
+			if filter.Action == action {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return filter, NewValidationError("action", "action must be one of: "+strings.Join(auditActions, ", "))
+		}
+	}
+
+	var err error
+	if filter.From, err = parseAuditTime("from", p.From, false); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseAuditTime("to", p.To, true); err != nil {
+		return filter, err
+	}
+	if filter.From != nil && filter.To != nil && filter.To.Before(*filter.From) {
+		return filter, NewValidationError("to", "to cannot be before from")
+	}
+
+	if p.Limit != "" {
+		limit, err := strconv.Atoi(p.Limit)
+		if err != nil || limit <= 0 || limit > maxListLimit {
+			return filter, NewValidationError("limit", "limit must be an integer between 1 and "+strconv.Itoa(maxListLimit))
+		}
+		filter.Limit = limit
+	}
+	if filter.BeforeID, err = decodeAuditCursor(p.Cursor); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// parseAuditTime разбирает момент в RFC 3339 или дату YYYY-MM-DD. Дата в верхней границе
+// (endOfDay) включает весь день.
+func parseAuditTime(field, value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return nil, NewValidationError(field, field+" must be in RFC 3339 or YYYY-MM-DD format")
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return &t, nil
+}
+
+// ListAudit возвращает страницу журнала аудита от новых записей к старым
+func (s *SubscriptionService) ListAudit(ctx context.Context, params AuditParams) (*model.AuditPage, error) {
+	log.Printf("[SERVICE] Listing audit log with params: %+v", params)
+
+	filter, err := params.toFilter()
+	if err != nil {
+		log.Printf("[ERROR] Invalid audit parameters: %v", err)
+		return nil, err
+	}
+	return s.listAudit(ctx, filter)
+}
+
+// SubscriptionHistory возвращает страницу журнала аудита одной подписки, в том числе удалённой
+func (s *SubscriptionService) SubscriptionHistory(ctx context.Context, id string, params AuditParams) (*model.AuditPage, error) {
+	log.Printf("[SERVICE] Getting history of subscription with ID: %s", id)
+
+	// Подписка должна быть видна вызывающему: чужая история не отличается от отсутствующей
+	if _, err := s.GetSubscription(ctx, id, true); err != nil {
+		return nil, err
+	}
+	params.SubscriptionID = id
+	filter, err := params.toFilter()
+	if err != nil {
+		log.Printf("[ERROR] Invalid history parameters: %v", err)
+		return nil, err
+	}
+	return s.listAudit(ctx, filter)
+}
+
+// listAudit читает на одну запись больше лимита, чтобы узнать, есть ли следующая страница
+func (s *SubscriptionService) listAudit(ctx context.Context, filter model.AuditFilter) (*model.AuditPage, error) {
+	limit := filter.Limit
+	filter.Limit++
+	entries, err := s.Repo.ListAudit(ctx, filter)
+	if err != nil {
+		log.Printf("[ERROR] Failed to list audit log from DB: %v", err)
+		return nil, wrapRepoError(err)
+	}
+
+	page := &model.AuditPage{Items: entries}
+	if page.Items == nil {
+		page.Items = []model.AuditEntry{}
+	}
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		page.NextCursor = encodeAuditCursor(page.Items[limit-1].ID)
+	}
+
+	log.Printf("[SUCCESS] Retrieved %d audit entries", len(page.Items))
+	return page, nil
+}
+
+// encodeAuditCursor кодирует ID последней выданной записи в непрозрачный курсор
+func encodeAuditCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(auditCursorPrefix + strconv.FormatInt(id, 10)))
+}
+
+// decodeAuditCursor возвращает ID записи, на которой закончилась предыдущая страница
+func decodeAuditCursor(cursor string) (int64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+
+	invalid := NewValidationError("cursor", "invalid cursor")
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, invalid
+	}
+	value, ok := strings.CutPrefix(string(data), auditCursorPrefix)
+	if !ok {
+		return 0, invalid
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, invalid
+	}
+	return id, nil
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/repository"
+)
+
+// Операции пакетного запроса
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// maxBatchSize ограничивает число операций в одном пакете
+const maxBatchSize = 1000
+
+// BatchOperation — одна операция пакета. Для create и update используются поля подписки,
+// для update и delete — ID и, при необходимости, ожидаемая версия.
+type BatchOperation struct {
+	Op      string
+	ID      string
+	Version int64
+	SubscriptionInput
+}
+
+// BatchResult — результат одной операции пакета; Err == nil означает успех
+type BatchResult struct {
+	Index        int
+	Op           string
+	ID           string
+	Subscription *model.Subscription
+	Err          error
+}
+
+// batchItemError указывает, на какой операции атомарного пакета произошла ошибка
+type batchItemError struct {
+	index int
+	err   error
+}
+
+func (e *batchItemError) Error() string {
+	return fmt.Sprintf("operations[%d]: %v", e.index, e.err)
+}
+
+func (e *batchItemError) Unwrap() error {
+	return e.err
+}
+
+// newBatchItemError привязывает ошибку к операции: у ошибок валидации к имени поля добавляется индекс
+func newBatchItemError(index int, err error) error {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		result := &ValidationError{}
+		for _, f := range validationErr.Fields {
+			result.Fields = append(result.Fields, FieldError{
+				Field:   fmt.Sprintf("operations[%d].%s", index, f.Field),
+				Message: f.Message,
+			})
+		}
+		return result
+	}
+	return &batchItemError{index: index, err: err}
+}
+
+// ExecuteBatch выполняет операции по порядку с теми же проверками, что и одиночные запросы.
+// В атомарном режиме все операции выполняются в одной транзакции, и первая же ошибка
+// откатывает пакет целиком. Иначе каждая операция выполняется независимо, а ошибки
+// возвращаются в результатах. requireVersion обязывает указывать версию для update и delete.
+func (s *SubscriptionService) ExecuteBatch(ctx context.Context, ops []BatchOperation, atomic, requireVersion bool) ([]BatchResult, error) {
+	log.Printf("[SERVICE] Executing batch of %d operations (atomic: %t)", len(ops), atomic)
+
+	if len(ops) == 0 {
+		return nil, NewValidationError("operations", "operations must not be empty")
+	}
+	if len(ops) > maxBatchSize {
+		return nil, NewValidationError("operations", fmt.Sprintf("operations must contain at most %d items", maxBatchSize))
+	}
+
+	if !atomic {
+		results := make([]BatchResult, len(ops))
+		failed := 0
+		for i, op := range ops {
+			results[i] = s.executeBatchOperation(ctx, i, op, requireVersion)
+			if results[i].Err != nil {
+				failed++
+			}
+		}
+		log.Printf("[SUCCESS] Batch executed: %d succeeded, %d failed", len(ops)-failed, failed)
+		return results, nil
+	}
+
+	var results []BatchResult
+	err := s.Repo.WithTx(ctx, func(repo repository.SubscriptionRepository) error {
+		// Сервис поверх транзакции: вложенные WithTx одиночных операций используют её же
+		txService := &SubscriptionService{Repo: repo, scope: s.scope, actor: s.actor}
+		results = make([]BatchResult, 0, len(ops))
+		for i, op := range ops {
+			result := txService.executeBatchOperation(ctx, i, op, requireVersion)
+			if result.Err != nil {
+				return newBatchItemError(i, result.Err)
+			}
+			results = append(results, result)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("[ERROR] Atomic batch rolled back: %v", err)
+		return nil, wrapRepoError(err)
+	}
+
+	log.Printf("[SUCCESS] Atomic batch of %d operations committed", len(ops))
+	return results, nil
+}
+
+// executeBatchOperation выполняет одну операцию пакета через одиночные методы сервиса
+func (s *SubscriptionService) executeBatchOperation(ctx context.Context, index int, op BatchOperation, requireVersion bool) BatchResult {
+	result := BatchResult{Index: index, Op: op.Op, ID: op.ID}
+
+	if requireVersion && op.Version == 0 && (op.Op == BatchUpdate || op.Op == BatchDelete) {
+		result.Err = NewValidationError("version", "version is required")
+		return result
+	}
+
+	switch op.Op {
+	case BatchCreate:
+		result.Subscription, result.Err = s.CreateSubscription(ctx, op.SubscriptionInput)
+	case BatchUpdate:
+		result.Subscription, result.Err = s.UpdateSubscription(ctx, op.ID, op.SubscriptionInput, op.Version)
+	case BatchDelete:
+		result.Err = s.DeleteSubscription(ctx, op.ID, op.Version)
+	default:
+		result.Err = NewValidationError("op", "op must be one of: create, update, delete")
+	}
+
+	if result.Subscription != nil {
+		result.ID = result.Subscription.ID
+	}
+	return result
+}
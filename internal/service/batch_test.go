@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+)
+
+// batchFixture создаёт две подписки и возвращает их вместе с пакетом, последняя операция которого падает
+func batchFixture(t *testing.T, svc *SubscriptionService) (*model.Subscription, *model.Subscription, []BatchOperation) {
+	t.Helper()
+	ctx := context.Background()
+	kept, err := svc.CreateSubscription(ctx, validInput())
+	if err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+	removed, err := svc.CreateSubscription(ctx, validInput())
+	if err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+
+	updated := validInput()
+	updated.Price = 20000
+	ops := []BatchOperation{
+		{Op: BatchCreate, SubscriptionInput: validInput()},
+		{Op: BatchUpdate, ID: kept.ID, Version: kept.Version, SubscriptionInput: updated},
+		{Op: BatchDelete, ID: removed.ID, Version: removed.Version},
+		{Op: BatchDelete, ID: missingID},
+	}
+	return kept, removed, ops
+}
+
+func TestExecuteBatchAtomicRollsBack(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService()
+	kept, removed, ops := batchFixture(t, svc)
+
+	results, err := svc.ExecuteBatch(ctx, ops, true, false)
+	if !errors.Is(err, ErrNotFound) || results != nil {
+		t.Fatalf("expected not found error without results, got %v, %v", results, err)
+	}
+
+	// Ни создание, ни изменение, ни удаление до упавшей операции не сохранились
+	page, err := svc.ListSubscriptions(ctx, ListSubscriptionsParams{})
+	if err != nil {
+		t.Fatalf("ListSubscriptions: %v", err)
+	}
+	if len(page.Items) != 2 {
+		t.Errorf("expected 2 subscriptions, got %d", len(page.Items))
+	}
+	got, err := svc.GetSubscription(ctx, kept.ID, false)
+	if err != nil {
+		t.Fatalf("GetSubscription: %v", err)
+	}
+	if got.Price != kept.Price || got.Version != kept.Version {
+		t.Errorf("expected update to be rolled back, got price %d version %d", got.Price, got.Version)
+	}
+	if _, err := svc.GetSubscription(ctx, removed.ID, false); err != nil {
+		t.Errorf("expected delete to be rolled back, got %v", err)
+	}
+	if types := changeTypes(t, svc); !reflect.DeepEqual(types, []string{model.ChangeCreated, model.ChangeCreated}) {
+		t.Errorf("expected no feed events from the batch, got %v", types)
+	}
+}
+
+func TestExecuteBatchAtomicValidationIndex(t *testing.T) {
+	svc := newTestService()
+	_, _, ops := batchFixture(t, svc)
+	ops[3] = BatchOperation{Op: BatchUpdate, ID: ops[1].ID}
+
+	_, err := svc.ExecuteBatch(context.Background(), ops, true, true)
+	if got := validationField(err); got != "operations[3].version" {
+		t.Errorf("expected operations[3].version validation error, got %v", err)
+	}
+}
+
+func TestExecuteBatchNonAtomicKeepsSucceeded(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService()
+	kept, removed, ops := batchFixture(t, svc)
+
+	results, err := svc.ExecuteBatch(ctx, ops, false, false)
+	if err != nil {
+		t.Fatalf("ExecuteBatch: %v", err)
+	}
+	for i, result := range results[:3] {
+		if result.Err != nil {
+			t.Errorf("operation %d: unexpected error %v", i, result.Err)
+		}
+	}
+	if !errors.Is(results[3].Err, ErrNotFound) {
+		t.Errorf("expected operation 3 to fail with not found, got %v", results[3].Err)
+	}
+
+	got, err := svc.GetSubscription(ctx, kept.ID, false)
+	if err != nil {
+		t.Fatalf("GetSubscription: %v", err)
+	}
+	if got.Price != 20000 {
+		t.Errorf("expected update to be applied, got price %d", got.Price)
+	}
+	if _, err := svc.GetSubscription(ctx, removed.ID, false); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected delete to be applied, got %v", err)
+	}
+}
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/money"
+	"github.com/Headliner38/Subscription_Service/internal/repository"
+	"github.com/Headliner38/Subscription_Service/internal/utils"
+)
+
+// errServiceNotFound — сервиса с таким ID нет в каталоге
+var errServiceNotFound = newNotFoundError("service not found")
+
+// ServiceInput — данные сервиса каталога в том виде, в котором они пришли в запросе
+type ServiceInput struct {
+	Name         string
+	Aliases      []string
+	Category     string
+	DefaultPrice *int
+	// Currency — валюта цены по умолчанию; по умолчанию рубли
+	Currency  string
+	VendorURL string
+}
+
+// validateServiceInput проверяет данные сервиса и приводит их к виду, в котором они хранятся:
+// синонимы без пробелов по краям и повторов, категория в нижнем регистре
+func validateServiceInput(in ServiceInput) (model.Service, error) {
+	svc := model.Service{
+		Name:         strings.TrimSpace(in.Name),
+		Aliases:      []string{},
+		Category:     normalizeCategory(in.Category),
+		DefaultPrice: in.DefaultPrice,
+		Currency:     normalizeCurrency(in.Currency),
+		VendorURL:    strings.TrimSpace(in.VendorURL),
+	}
+
+	if svc.Name == "" {
+		return svc, NewValidationError("name", "name is required")
+	}
+	if len(svc.Name) > 255 {
+		return svc, NewValidationError("name", "name must be at most 255 characters")
+	}
+	seen := map[string]bool{strings.ToLower(svc.Name): true}
+	for _, alias := range in.Aliases {
+		alias = strings.TrimSpace(alias)
+		if alias == "" || seen[strings.ToLower(alias)] {
+			continue
+		}
+		seen[strings.ToLower(alias)] = true
+		svc.Aliases = append(svc.Aliases, alias)
+	}
+	if len(svc.Category) > maxCategoryLength {
+		return svc, NewValidationError("category", fmt.Sprintf("category must be at most %d characters", maxCategoryLength))
+	}
+	if svc.DefaultPrice != nil && *svc.DefaultPrice <= 0 {
+		return svc, NewValidationError("default_price", "default_price must be positive")
+	}
+	if !money.IsValidCurrency(svc.Currency) {
+		return svc, NewValidationError("currency", "currency must be an ISO 4217 code")
+	}
+	if svc.VendorURL != "" {
+		u, err := url.Parse(svc.VendorURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return svc, NewValidationError("vendor_url", "vendor_url must be an absolute http or https URL")
+		}
+	}
+
+	return svc, nil
+}
+
+// checkServiceNames проверяет, что название и синонимы сервиса не заняты другими сервисами каталога
+func checkServiceNames(ctx context.Context, repo repository.SubscriptionRepository, svc *model.Service) error {
+	for _, name := range append([]string{svc.Name}, svc.Aliases...) {
+		other, err := repo.FindServiceByName(ctx, name)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if other.ID != svc.ID {
+			return newConflictError(fmt.Sprintf("name %q is already used by service %s", name, other.Name))
+		}
+	}
+	return nil
+}
+
+// wrapServiceError переводит ошибки хранилища при работе с каталогом в ошибки сервиса
+func wrapServiceError(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return errServiceNotFound
+	case errors.Is(err, repository.ErrDuplicate):
+		return newConflictError("service name is already used")
+	default:
+		return wrapRepoError(err)
+	}
+}
+
+// CreateService добавляет сервис в каталог
+func (s *SubscriptionService) CreateService(ctx context.Context, input ServiceInput) (*model.Service, error) {
+	log.Printf("[SERVICE] Creating catalog service: %s", input.Name)
+
+	svc, err := validateServiceInput(input)
+	if err != nil {
+		log.Printf("[ERROR] Invalid catalog service data: %v", err)
+		return nil, err
+	}
+	svc.ID = utils.GenerateUUID()
+
+	err = s.Repo.WithTx(ctx, func(repo repository.SubscriptionRepository) error {
+		if err := checkServiceNames(ctx, repo, &svc); err != nil {
+			return err
+		}
+		return repo.CreateService(ctx, &svc)
+	})
+	if err != nil {
+		log.Printf("[ERROR] Failed to save catalog service: %v", err)
+		return nil, wrapServiceError(err)
+	}
+
+	log.Printf("[SUCCESS] Catalog service created with ID: %s", svc.ID)
+	return &svc, nil
+}
+
+// GetService возвращает сервис каталога по ID
+func (s *SubscriptionService) GetService(ctx context.Context, id string) (*model.Service, error) {
+	log.Printf("[SERVICE] Getting catalog service with ID: %s", id)
+
+	if !utils.IsValidUUID(id) {
+		log.Printf("[ERROR] Catalog service ID is not a valid UUID: %s", id)
+		return nil, errServiceNotFound
+	}
+
+	svc, err := s.Repo.GetService(ctx, id)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get catalog service: %v", err)
+		return nil, wrapServiceError(err)
+	}
+
+	log.Printf("[SUCCESS] Retrieved catalog service with ID: %s", id)
+	return svc, nil
+}
+
+// UpdateService полностью заменяет данные сервиса каталога. Уже привязанные подписки
+// сохраняют service_name, с которым были созданы, но по-прежнему ссылаются на сервис.
+func (s *SubscriptionService) UpdateService(ctx context.Context, id string, input ServiceInput) (*model.Service, error) {
+	log.Printf("[SERVICE] Updating catalog service with ID: %s", id)
+
+	if !utils.IsValidUUID(id) {
+		log.Printf("[ERROR] Catalog service ID is not a valid UUID for update: %s", id)
+		return nil, errServiceNotFound
+	}
+	svc, err := validateServiceInput(input)
+	if err != nil {
+		log.Printf("[ERROR] Invalid catalog service data for update: %v", err)
+		return nil, err
+	}
+	svc.ID = id
+
+	err = s.Repo.WithTx(ctx, func(repo repository.SubscriptionRepository) error {
+		if _, err := repo.GetService(ctx, id); err != nil {
+			return err
+		}
+		if err := checkServiceNames(ctx, repo, &svc); err != nil {
+			return err
+		}
+		return repo.UpdateService(ctx, &svc)
+	})
+	if err != nil {
+		log.Printf("[ERROR] Failed to update catalog service: %v", err)
+		return nil, wrapServiceError(err)
+	}
+
+	log.Printf("[SUCCESS] Updated catalog service with ID: %s", id)
+	return &svc, nil
+}
+
+// DeleteService удаляет сервис из каталога; подписки остаются со своим service_name, но без ссылки
+func (s *SubscriptionService) DeleteService(ctx context.Context, id string) error {
+	log.Printf("[SERVICE] Deleting catalog service with ID: %s", id)
+
+	if !utils.IsValidUUID(id) {
+		log.Printf("[ERROR] Catalog service ID is not a valid UUID for deletion: %s", id)
+		return errServiceNotFound
+	}
+
+	if err := s.Repo.DeleteService(ctx, id); err != nil {
+		log.Printf("[ERROR] Failed to delete catalog service: %v", err)
+		return wrapServiceError(err)
+	}
+
+	log.Printf("[SUCCESS] Deleted catalog service with ID: %s", id)
+	return nil
+}
+
+// ListServices возвращает сервисы каталога, упорядоченные по названию; category ограничивает выборку
+func (s *SubscriptionService) ListServices(ctx context.Context, category string) ([]model.Service, error) {
+	log.Printf("[SERVICE] Listing catalog services, category: %q", category)
+
+	services, err := s.Repo.ListServices(ctx, model.ServiceFilter{Category: normalizeCategory(category)})
+	if err != nil {
+		log.Printf("[ERROR] Failed to list catalog services: %v", err)
+		return nil, wrapRepoError(err)
+	}
+	if services == nil {
+		services = []model.Service{}
+	}
+
+	log.Printf("[SUCCESS] Retrieved %d catalog services", len(services))
+	return services, nil
+}
+
+// serviceResolver сопоставляет данные подписки с каталогом и запоминает уже найденные сервисы,
+// чтобы импорт не обращался к базе на каждой строке
+type serviceResolver struct {
+	repo   repository.SubscriptionRepository
+	byID   map[string]*model.Service
+	byName map[string]*model.Service
+}
+
+func newServiceResolver(repo repository.SubscriptionRepository) *serviceResolver {
+	return &serviceResolver{
+		repo:   repo,
+		byID:   make(map[string]*model.Service),
+		byName: make(map[string]*model.Service),
+	}
+}
+
+// lookup находит сервис по ID, а если ID не задан — по названию или синониму без учёта регистра.
+// nil без ошибки означает, что название в каталоге не найдено.
+func (r *serviceResolver) lookup(ctx context.Context, id, name string) (*model.Service, error) {
+	if id != "" {
+		if svc, ok := r.byID[id]; ok {
+			return svc, nil
+		}
+		if !utils.IsValidUUID(id) {
+			return nil, NewValidationError("service_id", "service_id must be a valid UUID")
+		}
+		svc, err := r.repo.GetService(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, NewValidationError("service_id", "service not found in catalog")
+		}
+		if err != nil {
+			return nil, err
+		}
+		r.byID[id] = svc
+		return svc, nil
+	}
+
+	key := strings.ToLower(strings.TrimSpace(name))
+	if key == "" {
+		return nil, nil
+	}
+	if svc, ok := r.byName[key]; ok {
+		return svc, nil
+	}
+	svc, err := r.repo.FindServiceByName(ctx, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		svc, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.byName[key] = svc
+	return svc, nil
+}
+
+// resolve привязывает подписку к сервису каталога: по service_id, а если он не задан — по названию.
+// Название заменяется каноническим, а без цены и категории подставляются цена и категория сервиса.
+// Название, которого нет в каталоге, остаётся как есть, без привязки.
+func (r *serviceResolver) resolve(ctx context.Context, in *SubscriptionInput) error {
+	svc, err := r.lookup(ctx, in.ServiceID, in.ServiceName)
+	if err != nil || svc == nil {
+		return err
+	}
+
+	in.ServiceID = svc.ID
+	in.ServiceName = svc.Name
+	if strings.TrimSpace(in.Category) == "" {
+		in.Category = svc.Category
+	}
+	if in.Price == 0 && svc.DefaultPrice != nil {
+		if in.Currency != "" && normalizeCurrency(in.Currency) != svc.Currency {
+			return NewValidationError("price", fmt.Sprintf("price is required: default price of %s is in %s", svc.Name, svc.Currency))
+		}
+		in.Price = *svc.DefaultPrice
+		in.Currency = svc.Currency
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+)
+
+const (
+	defaultChangesLimit = 100
+	maxChangesLimit     = 1000
+	changeTokenPrefix   = "chg:"
+)
+
+// ListChanges возвращает события ленты изменений после токена since.
+// Пустой since означает чтение с начала ленты.
+func (s *SubscriptionService) ListChanges(ctx context.Context, since, limitStr string) (*model.ChangePage, error) {
+	log.Printf("[SERVICE] Listing subscription changes since token: %q", since)
+
+	afterSequence, err := decodeChangeToken(since)
+	if err != nil {
+		log.Printf("[ERROR] Invalid change token: %s", since)
+		return nil, err
+	}
+
+	limit := defaultChangesLimit
+	if limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > maxChangesLimit {
+			log.Printf("[ERROR] Invalid changes limit: %s", limitStr)
+			return nil, NewValidationError("limit", "limit must be an integer between 1 and "+strconv.Itoa(maxChangesLimit))
+		}
+	}
+
+	// Запрашиваем на одно событие больше, чтобы узнать, есть ли ещё
+	changes, err := s.Repo.ListChanges(ctx, afterSequence, limit+1)
+	if err != nil {
+		log.Printf("[ERROR] Failed to list changes from DB: %v", err)
+		return nil, wrapRepoError(err)
+	}
+
+	page := &model.ChangePage{Events: changes, NextToken: since}
+	if page.Events == nil {
+		page.Events = []model.SubscriptionChange{}
+	}
+	if len(page.Events) > limit {
+		page.Events = page.Events[:limit]
+		page.HasMore = true
+	}
+	if len(page.Events) > 0 {
+		page.NextToken = encodeChangeToken(page.Events[len(page.Events)-1].Sequence)
+	}
+
+	log.Printf("[SUCCESS] Retrieved %d subscription changes", len(page.Events))
+	return page, nil
+}
+
+// encodeChangeToken кодирует позицию в ленте в непрозрачный токен
+func encodeChangeToken(sequence int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(changeTokenPrefix + strconv.FormatInt(sequence, 10)))
+}
+
+// decodeChangeToken возвращает позицию в ленте, закодированную в токене
+func decodeChangeToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+
+	invalid := NewValidationError("since", "invalid change token")
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, invalid
+	}
+	value, ok := strings.CutPrefix(string(data), changeTokenPrefix)
+	if !ok {
+		return 0, invalid
+	}
+	sequence, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || sequence < 0 {
+		return 0, invalid
+	}
+	return sequence, nil
+}
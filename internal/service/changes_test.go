@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+)
+
+// changeTypes возвращает типы событий ленты, видимых сервису, в порядке записи
+func changeTypes(t *testing.T, svc *SubscriptionService) []string {
+	t.Helper()
+	page, err := svc.ListChanges(context.Background(), "", "")
+	if err != nil {
+		t.Fatalf("ListChanges: %v", err)
+	}
+	types := []string{}
+	for _, change := range page.Events {
+		types = append(types, change.Type)
+	}
+	return types
+}
+
+func TestListChangesScopedByOwnerAtEventTime(t *testing.T) {
+	ctx := context.Background()
+	admin := newTestService()
+	owner := admin.WithScope(model.Scope{UserID: testUserID})
+	other := admin.WithScope(model.Scope{UserID: otherUserID})
+
+	sub, err := admin.CreateSubscription(ctx, validInput())
+	if err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+	moved := validInput()
+	moved.UserID = otherUserID
+	if _, err := admin.UpdateSubscription(ctx, sub.ID, moved, 0); err != nil {
+		t.Fatalf("UpdateSubscription: %v", err)
+	}
+	if err := admin.DeleteSubscription(ctx, sub.ID, 0); err != nil {
+		t.Fatalf("DeleteSubscription: %v", err)
+	}
+	if _, err := admin.PurgeDeleted(ctx, -time.Minute); err != nil {
+		t.Fatalf("PurgeDeleted: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		svc  *SubscriptionService
+		want []string
+	}{
+		// Прежний владелец сохраняет свои события, но не видит событий после передачи
+		{"previous owner", owner, []string{model.ChangeCreated}},
+		// Новый владелец не получает чужих снимков, а tombstone переживает окончательное удаление
+		{"new owner", other, []string{model.ChangeUpdated, model.ChangeDeleted}},
+		{"admin", admin, []string{model.ChangeCreated, model.ChangeUpdated, model.ChangeDeleted}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := changeTypes(t, tt.svc); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestListChangesResumesFromToken(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService()
+	var ids []string
+	for i := 0; i < 3; i++ {
+		sub, err := svc.CreateSubscription(ctx, validInput())
+		if err != nil {
+			t.Fatalf("CreateSubscription: %v", err)
+		}
+		ids = append(ids, sub.ID)
+	}
+	if err := svc.DeleteSubscription(ctx, ids[0], 0); err != nil {
+		t.Fatalf("DeleteSubscription: %v", err)
+	}
+
+	// Четыре события читаем страницами по два и продолжаем с выданного токена
+	first, err := svc.ListChanges(ctx, "", "2")
+	if err != nil {
+		t.Fatalf("ListChanges: %v", err)
+	}
+	if len(first.Events) != 2 || !first.HasMore || first.NextToken == "" {
+		t.Fatalf("expected a full first page with more events, got %+v", first)
+	}
+	second, err := svc.ListChanges(ctx, first.NextToken, "2")
+	if err != nil {
+		t.Fatalf("ListChanges: %v", err)
+	}
+	if len(second.Events) != 2 || second.HasMore {
+		t.Fatalf("expected the last two events, got %+v", second)
+	}
+	if second.Events[0].SubscriptionID != ids[2] || second.Events[0].Type != model.ChangeCreated {
+		t.Errorf("expected the page to resume after the second event, got %+v", second.Events[0])
+	}
+
+	// Tombstone содержит только идентификатор: снимка удалённой подписки в ленте нет
+	tombstone := second.Events[1]
+	if tombstone.Type != model.ChangeDeleted || tombstone.SubscriptionID != ids[0] || tombstone.Subscription != nil {
+		t.Errorf("expected a tombstone for %s, got %+v", ids[0], tombstone)
+	}
+
+	// В конце ленты токен не меняется, чтобы клиент мог опрашивать её с того же места
+	empty, err := svc.ListChanges(ctx, second.NextToken, "2")
+	if err != nil {
+		t.Fatalf("ListChanges: %v", err)
+	}
+	if len(empty.Events) != 0 || empty.HasMore || empty.NextToken != second.NextToken {
+		t.Errorf("expected an empty page keeping the token, got %+v", empty)
+	}
+}
+
+func TestListChangesValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		since string
+		limit string
+		field string
+	}{
+		{"not base64", "not a token!", "", "since"},
+		{"wrong prefix", base64.RawURLEncoding.EncodeToString([]byte("page:5")), "", "since"},
+		{"negative sequence", encodeChangeToken(-1), "", "since"},
+		{"zero limit", "", "0", "limit"},
+		{"limit too large", "", "100000", "limit"},
+		{"limit not a number", "", "ten", "limit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTestService().ListChanges(context.Background(), tt.since, tt.limit)
+			if got := validationField(err); got != tt.field {
+				t.Errorf("expected validation error for %q, got %v", tt.field, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/money"
+	"github.com/Headliner38/Subscription_Service/internal/repository"
+)
+
+// datedRate — разобранный курс пары валют, действующий с date
+type datedRate struct {
+	date time.Time
+	rate *big.Rat
+}
+
+// converter пересчитывает помесячные платежи в валюту отчёта по курсу соответствующего месяца.
+// Курсы пары загружаются из репозитория при первом обращении и живут в пределах одного запроса.
+type converter struct {
+	repo   repository.SubscriptionRepository
+	target string
+	rates  map[[2]string][]datedRate
+}
+
+func newConverter(repo repository.SubscriptionRepository, target string) *converter {
+	return &converter{repo: repo, target: target, rates: make(map[[2]string][]datedRate)}
+}
+
+// convert возвращает платёж amount в валюте currency за месяц month в валюте отчёта.
+// Месяц пересчитывается по курсу, действовавшему в его последний день, то есть по последнему
+// курсу с датой не позже него. Если у прямой пары такого курса нет или обратный курс новее,
+// используется обратный.
+func (c *converter) convert(ctx context.Context, amount int, currency string, month time.Time) (int, error) {
+	if currency == c.target {
+		return amount, nil
+	}
+
+	monthEnd := monthStart(month).AddDate(0, 1, -1)
+	direct, err := c.rateAt(ctx, currency, c.target, monthEnd)
+	if err != nil {
+		return 0, err
+	}
+	inverse, err := c.rateAt(ctx, c.target, currency, monthEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	var rate *big.Rat
+	switch {
+	case direct != nil && (inverse == nil || !inverse.date.After(direct.date)):
+		rate = direct.rate
+	case inverse != nil:
+		rate = new(big.Rat).Inv(inverse.rate)
+	default:
+		return 0, NewValidationError("currency", fmt.Sprintf("no %s/%s exchange rate for %s", currency, c.target, month.Format(monthLayout)))
+	}
+
+	return money.Convert(amount, currency, c.target, rate), nil
+}
+
+// rateAt возвращает последний курс пары base/quote с датой не позже at или nil, если его нет
+func (c *converter) rateAt(ctx context.Context, base, quote string, at time.Time) (*datedRate, error) {
+	pair := [2]string{base, quote}
+	rates, ok := c.rates[pair]
+	if !ok {
+		stored, err := c.repo.ListExchangeRates(ctx, model.ExchangeRateFilter{BaseCurrency: base, QuoteCurrency: quote})
+		if err != nil {
+			return nil, err
+		}
+		rates = make([]datedRate, 0, len(stored))
+		for _, r := range stored {
+			rate, err := money.ParseRate(r.Rate)
+			if err != nil {
+				return nil, fmt.Errorf("exchange rate %s/%s on %s: %w", base, quote, r.Date.Format(dateLayout), err)
+			}
+			rates = append(rates, datedRate{date: r.Date, rate: rate})
+		}
+		c.rates[pair] = rates
+	}
+
+	// Курсы упорядочены по дате: ищем первый курс позже at и берём предыдущий
+	idx := sort.Search(len(rates), func(i int) bool { return rates[i].date.After(at) })
+	if idx == 0 {
+		return nil, nil
+	}
+	return &rates[idx-1], nil
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/utils"
+)
+
+const (
+	monthLayout = "01-2006"
+	dateLayout  = "2006-01-02"
+)
+
+// TotalCostParams — параметры подсчёта стоимости в том виде, в котором они пришли в запросе
+type TotalCostParams struct {
+	UserID      string
+	ServiceName string
+	// ServiceID выбирает подписки, привязанные к сервису каталога
+	ServiceID string
+	Category  string
+	// Tags — теги через запятую; TagsMatch — any (хотя бы один) или all (все)
+	Tags      string
+	TagsMatch string
+	StartDate string
+	EndDate   string
+	// Currency — валюта, в которую пересчитывается стоимость; по умолчанию рубли
+	Currency       string
+	IncludeDeleted bool
+}
+
+// toFilter валидирует параметры и преобразует их в фильтр репозитория
+func (p TotalCostParams) toFilter() (model.CostFilter, error) {
+	filter := model.CostFilter{
+		UserID:         p.UserID,
+		ServiceName:    p.ServiceName,
+		ServiceID:      strings.ToLower(p.ServiceID),
+		Category:       normalizeCategory(p.Category),
+		IncludeDeleted: p.IncludeDeleted,
+	}
+
+	if p.UserID != "" && !utils.IsValidUUID(p.UserID) {
+		return filter, NewValidationError("user_id", "user_id must be a valid UUID")
+	}
+	if p.ServiceID != "" && !utils.IsValidUUID(p.ServiceID) {
+		return filter, NewValidationError("service_id", "service_id must be a valid UUID")
+	}
+	var err error
+	filter.Tags, filter.MatchAllTags, err = parseTagFilter(p.Tags, p.TagsMatch)
+	if err != nil {
+		return filter, err
+	}
+
+	// Преобразование дат: конец периода включительно, месяц MM-YYYY — целиком
+	if p.StartDate != "" {
+		filter.StartDate, err = parseDate("start_date", p.StartDate, false)
+		if err != nil {
+			return filter, err
+		}
+	}
+	if p.EndDate != "" {
+		filter.EndDate, err = parseDate("end_date", p.EndDate, true)
+		if err != nil {
+			return filter, err
+		}
+	}
+
+	// Проверка логики дат
+	if p.StartDate != "" && p.EndDate != "" && filter.EndDate.Before(filter.StartDate) {
+		return filter, NewValidationError("end_date", "end_date cannot be before start_date")
+	}
+
+	return filter, nil
+}
+
+// monthStart приводит дату к первому числу месяца
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// daysBetween возвращает число дней между датами from и to (to не включается)
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// currentCycle возвращает начало цикла оплаты, в который попадает дата at, и начало следующего.
+// Даты до начала подписки относятся к первому циклу.
+func currentCycle(sub model.Subscription, at time.Time) (time.Time, time.Time) {
+	start, next := sub.CycleStart(0), sub.CycleStart(1)
+	for k := 1; !next.After(at); k++ {
+		start, next = next, sub.CycleStart(k+1)
+	}
+	return start, next
+}
+
+// billingHistory — паузы и изменения цен подписки, влияющие на её списания
+type billingHistory struct {
+	pauses []model.Pause
+	// prices упорядочены по дате
+	prices []model.PriceChange
+}
+
+// priceAt возвращает цену подписки, действующую на дату at: последнее изменение не позже at,
+// а до первого изменения — цену из самой подписки
+func (h billingHistory) priceAt(sub model.Subscription, at time.Time) int {
+	price := sub.Price
+	for _, change := range h.prices {
+		if change.EffectiveFrom.After(at) {
+			break
+		}
+		price = change.Price
+	}
+	return price
+}
+
+// monthShare — часть списаний подписки, приходящаяся на один календарный месяц
+type monthShare struct {
+	month time.Time
+	// amount — сумма в валюте подписки
+	amount int
+	// billed — на месяц приходится часть хотя бы одного оплаченного цикла
+	billed bool
+	// paused — на месяц приходится цикл, пропущенный из-за паузы
+	paused bool
+}
+
+// billingShares раскладывает списания подписки по календарным месяцам периода [periodStart, periodEnd]
+// (обе границы включительно, нулевые означают отсутствие ограничения). Списание происходит в начале
+// каждого цикла не позже end_date, а если он не задан — не позже конца периода или now. Цикл,
+// в начале которого подписка была на паузе, не оплачивается. Цена оплаченного цикла распределяется
+// по его дням, поэтому годовая подписка даёт в каждый месяц свою долю, а сумма долей цикла
+// всегда равна цене. Цикл оплачивается по цене, действующей в день списания.
+func billingShares(sub model.Subscription, history billingHistory, periodStart, periodEnd, now time.Time) []monthShare {
+	var limit time.Time
+	switch {
+	case sub.EndDate != nil:
+		limit = *sub.EndDate
+	case !periodEnd.IsZero():
+		limit = periodEnd
+	default:
+		limit = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	if !periodEnd.IsZero() && periodEnd.Before(limit) {
+		limit = periodEnd
+	}
+
+	var shares []monthShare
+	add := func(month time.Time, amount int, billed bool) {
+		if n := len(shares); n > 0 && shares[n-1].month.Equal(month) {
+			shares[n-1].amount += amount
+			shares[n-1].billed = shares[n-1].billed || billed
+			shares[n-1].paused = shares[n-1].paused || !billed
+			return
+		}
+		shares = append(shares, monthShare{month: month, amount: amount, billed: billed, paused: !billed})
+	}
+
+	for k := 0; ; k++ {
+		cycleStart := sub.CycleStart(k)
+		if cycleStart.After(limit) {
+			break
+		}
+		cycleEnd := sub.CycleStart(k + 1)
+
+		// Часть цикла внутри периода: [from, to)
+		from, to := cycleStart, cycleEnd
+		if !periodStart.IsZero() && periodStart.After(from) {
+			from = periodStart
+		}
+		if !periodEnd.IsZero() && periodEnd.AddDate(0, 0, 1).Before(to) {
+			to = periodEnd.AddDate(0, 0, 1)
+		}
+		if !from.Before(to) {
+			continue
+		}
+
+		billed := true
+		for _, pause := range history.pauses {
+			if pause.Covers(cycleStart) {
+				billed = false
+				break
+			}
+		}
+
+		// Доля цены, накопленная к дате at; округление до минимальной единицы валюты
+		// по накопленной сумме не даёт ошибкам округления копиться между месяцами
+		price := int64(history.priceAt(sub, cycleStart))
+		length := int64(daysBetween(cycleStart, cycleEnd))
+		accrued := func(at time.Time) int {
+			return int((price*int64(daysBetween(cycleStart, at))*2 + length) / (2 * length))
+		}
+		for month := monthStart(from); month.Before(to); month = month.AddDate(0, 1, 0) {
+			a, b := month, month.AddDate(0, 1, 0)
+			if from.After(a) {
+				a = from
+			}
+			if to.Before(b) {
+				b = to
+			}
+			amount := 0
+			if billed {
+				amount = accrued(b) - accrued(a)
+			}
+			add(month, amount, billed)
+		}
+	}
+	return shares
+}
+
+// subscriptionCost считает стоимость подписки за период [periodStart, periodEnd]
+// (границы — как в billingShares) в валюте conv. Доля каждого месяца пересчитывается по его курсу.
+func subscriptionCost(ctx context.Context, sub model.Subscription, history billingHistory, periodStart, periodEnd, now time.Time, conv *converter) (model.SubscriptionCost, bool, error) {
+	shares := billingShares(sub, history, periodStart, periodEnd, now)
+	if len(shares) == 0 {
+		return model.SubscriptionCost{}, false, nil
+	}
+
+	item := model.SubscriptionCost{
+		SubscriptionID: sub.ID,
+		ServiceName:    sub.ServiceName,
+		UserID:         sub.UserID,
+		Price:          sub.Price,
+		Currency:       sub.Currency,
+		BillingPeriod:  sub.BillingPeriod,
+		IntervalCount:  sub.IntervalCount,
+		From:           shares[0].month.Format(monthLayout),
+		To:             shares[len(shares)-1].month.Format(monthLayout),
+		CostCurrency:   conv.target,
+	}
+	for _, share := range shares {
+		if !share.billed {
+			item.PausedMonths++
+			continue
+		}
+		charge, err := conv.convert(ctx, share.amount, sub.Currency, share.month)
+		if err != nil {
+			return model.SubscriptionCost{}, false, err
+		}
+		item.Months++
+		item.Cost += charge
+	}
+
+	return item, true, nil
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+)
+
+// date возвращает дату в UTC без времени
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// testSubscription возвращает подписку с периодом оплаты period и ценой price
+func testSubscription(period string, start time.Time, end *time.Time, price int) model.Subscription {
+	return model.Subscription{BillingPeriod: period, IntervalCount: 1, StartDate: start, EndDate: end, Price: price, Currency: "RUB"}
+}
+
+// shareMonths и shareAmounts раскладывают доли на месяцы MM-YYYY и суммы
+func shareMonths(shares []monthShare) []string {
+	months := make([]string, len(shares))
+	for i, share := range shares {
+		months[i] = share.month.Format(monthLayout)
+	}
+	return months
+}
+
+func shareAmounts(shares []monthShare) []int {
+	amounts := make([]int, len(shares))
+	for i, share := range shares {
+		amounts[i] = share.amount
+	}
+	return amounts
+}
+
+func TestBillingShares(t *testing.T) {
+	apr30 := date(2024, 4, 30)
+	now := date(2030, 1, 1)
+	tests := []struct {
+		name        string
+		sub         model.Subscription
+		periodStart time.Time
+		periodEnd   time.Time
+		months      []string
+		amounts     []int
+	}{
+		{
+			// Циклы 31.01, 29.02, 31.03, 30.04: короткий февраль не сдвигает следующие списания
+			name:        "monthly from 31 January",
+			sub:         testSubscription(model.PeriodMonthly, date(2024, 1, 31), &apr30, 3100),
+			periodStart: date(2024, 1, 1),
+			periodEnd:   date(2024, 4, 30),
+			months:      []string{"01-2024", "02-2024", "03-2024", "04-2024"},
+			amounts:     []int{107, 3093, 3103, 3097},
+		},
+		{
+			// Пять списаний по 700: неделя с 29.01 делится между январём и февралём,
+			// последняя неделя обрезается концом периода
+			name:        "weekly",
+			sub:         testSubscription(model.PeriodWeekly, date(2024, 1, 29), nil, 700),
+			periodStart: date(2024, 1, 1),
+			periodEnd:   date(2024, 2, 29),
+			months:      []string{"01-2024", "02-2024"},
+			amounts:     []int{300, 2900},
+		},
+		{
+			// Кварталы по 91 дню: 100 в день
+			name:        "quarterly",
+			sub:         testSubscription(model.PeriodQuarterly, date(2024, 1, 15), nil, 9100),
+			periodStart: date(2024, 1, 1),
+			periodEnd:   date(2024, 6, 30),
+			months:      []string{"01-2024", "02-2024", "03-2024", "04-2024", "05-2024", "06-2024"},
+			amounts:     []int{1700, 2900, 3100, 3000, 3100, 3000},
+		},
+		{
+			// Год с 29.02.2024 до 28.02.2025 — 365 дней по 100; следующий цикл начинается 28.02.2025
+			name:        "yearly from leap day",
+			sub:         testSubscription(model.PeriodYearly, date(2024, 2, 29), nil, 36500),
+			periodStart: date(2025, 1, 1),
+			periodEnd:   date(2025, 3, 31),
+			months:      []string{"01-2025", "02-2025", "03-2025"},
+			amounts:     []int{3100, 2800, 3100},
+		},
+		{
+			name:        "period before start",
+			sub:         testSubscription(model.PeriodMonthly, date(2024, 3, 1), nil, 1000),
+			periodStart: date(2024, 1, 1),
+			periodEnd:   date(2024, 2, 29),
+			months:      []string{},
+			amounts:     []int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares := billingShares(tt.sub, billingHistory{}, tt.periodStart, tt.periodEnd, now)
+			if months := shareMonths(shares); !reflect.DeepEqual(months, tt.months) {
+				t.Errorf("expected months %v, got %v", tt.months, months)
+			}
+			if amounts := shareAmounts(shares); !reflect.DeepEqual(amounts, tt.amounts) {
+				t.Errorf("expected amounts %v, got %v", tt.amounts, amounts)
+			}
+		})
+	}
+}
+
+func TestBillingSharesSumToPrice(t *testing.T) {
+	// Без обрезки периодом доли каждого цикла в сумме дают ровно его цену
+	for _, period := range []string{model.PeriodWeekly, model.PeriodMonthly, model.PeriodQuarterly, model.PeriodYearly} {
+		t.Run(period, func(t *testing.T) {
+			sub := testSubscription(period, date(2024, 1, 31), nil, 99999)
+			end := sub.CycleStart(3).AddDate(0, 0, -1)
+			sub.EndDate = &end
+
+			total := 0
+			for _, amount := range shareAmounts(billingShares(sub, billingHistory{}, time.Time{}, end, date(2030, 1, 1))) {
+				total += amount
+			}
+			if total != 3*99999 {
+				t.Errorf("expected three cycles of 99999, got %d", total)
+			}
+		})
+	}
+}
+
+func TestBillingSharesSkipsPausedCycles(t *testing.T) {
+	sub := testSubscription(model.PeriodMonthly, date(2024, 1, 1), nil, 1000)
+	resumed := time.Date(2024, 2, 10, 12, 0, 0, 0, time.UTC)
+	history := billingHistory{pauses: []model.Pause{{PausedAt: time.Date(2024, 1, 20, 9, 0, 0, 0, time.UTC), ResumedAt: &resumed}}}
+
+	shares := billingShares(sub, history, date(2024, 1, 1), date(2024, 3, 31), date(2030, 1, 1))
+	if amounts := shareAmounts(shares); !reflect.DeepEqual(amounts, []int{1000, 0, 1000}) {
+		t.Errorf("expected February to be skipped, got %v", amounts)
+	}
+	if !shares[1].paused || shares[1].billed {
+		t.Errorf("expected February to be marked paused, got %+v", shares[1])
+	}
+}
+
+func TestPriceAtCycleBoundaries(t *testing.T) {
+	// Цикл с 31.01 начинается 29.02: изменение в сам день цикла действует уже на него
+	sub := testSubscription(model.PeriodMonthly, date(2024, 1, 31), nil, 1000)
+	history := billingHistory{prices: []model.PriceChange{
+		{EffectiveFrom: sub.CycleStart(1), Price: 2000},
+		{EffectiveFrom: sub.CycleStart(2).AddDate(0, 0, 1), Price: 3000},
+	}}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want int
+	}{
+		{"before first change", date(2024, 2, 28), 1000},
+		{"on cycle start", date(2024, 2, 29), 2000},
+		{"day after cycle start", date(2024, 3, 1), 2000},
+		{"cycle start before change", date(2024, 3, 31), 2000},
+		{"day after next cycle start", date(2024, 4, 1), 3000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := history.priceAt(sub, tt.at); got != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBillingSharesPriceChangeAtCycleBoundary(t *testing.T) {
+	// Цена цикла берётся на дату его начала: изменение днём позже ждёт следующего цикла
+	tests := []struct {
+		name      string
+		effective time.Time
+		want      []int
+	}{
+		{"day before cycle start", date(2024, 1, 31), []int{1000, 2000, 2000}},
+		{"on cycle start", date(2024, 2, 1), []int{1000, 2000, 2000}},
+		{"day after cycle start", date(2024, 2, 2), []int{1000, 1000, 2000}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := testSubscription(model.PeriodMonthly, date(2024, 1, 1), nil, 1000)
+			history := billingHistory{prices: []model.PriceChange{{EffectiveFrom: tt.effective, Price: 2000}}}
+
+			shares := billingShares(sub, history, date(2024, 1, 1), date(2024, 3, 31), date(2030, 1, 1))
+			if got := shareAmounts(shares); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/repository"
+)
+
+// Ошибки сервиса. Обработчики HTTP сопоставляют их со статус-кодами через errors.Is.
+var (
+	ErrValidation  = errors.New("validation failed")
+	ErrNotFound    = errors.New("subscription not found")
+	ErrConflict    = errors.New("conflict")
+	ErrUnavailable = errors.New("storage unavailable")
+	// ErrPreconditionFailed — версия подписки не совпала с ожидаемой (If-Match)
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// FieldError описывает ошибку валидации конкретного поля
+type FieldError struct {
+	Field   string `json:"field" example:"price"`
+	Message string `json:"message" example:"price must be positive"`
+}
+
+// ValidationError содержит ошибки валидации по полям; errors.Is(err, ErrValidation) == true
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		messages = append(messages, f.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// NewValidationError создаёт ошибку валидации одного поля
+func NewValidationError(field, message string) *ValidationError {
+	return &ValidationError{Fields: []FieldError{{Field: field, Message: message}}}
+}
+
+// domainError — ошибка с собственным сообщением, относящаяся к одной из категорий выше
+type domainError struct {
+	kind    error
+	message string
+}
+
+func (e *domainError) Error() string {
+	return e.message
+}
+
+func (e *domainError) Is(target error) bool {
+	return target == e.kind
+}
+
+// PreconditionFailedError возвращается, когда подписку успели изменить:
+// Current содержит её актуальное состояние; errors.Is(err, ErrPreconditionFailed) == true
+type PreconditionFailedError struct {
+	Current *model.Subscription
+}
+
+func (e *PreconditionFailedError) Error() string {
+	return fmt.Sprintf("subscription version mismatch, current version is %d", e.Current.Version)
+}
+
+func (e *PreconditionFailedError) Is(target error) bool {
+	return target == ErrPreconditionFailed
+}
+
+// ImportAbortedError возвращается, когда импорт прерван сбоем после того, как часть строк
+// уже сохранена: Result описывает сохранённое, Err — причину сбоя
+type ImportAbortedError struct {
+	Result *model.ImportResult
+	Err    error
+}
+
+func (e *ImportAbortedError) Error() string {
+	return fmt.Sprintf("import aborted after row %d: %v", e.Result.CommittedThroughRow, e.Err)
+}
+
+func (e *ImportAbortedError) Unwrap() error {
+	return e.Err
+}
+
+// newConflictError создаёт ошибку конфликта с понятным клиенту сообщением
+func newConflictError(message string) error {
+	return &domainError{kind: ErrConflict, message: message}
+}
+
+// newNotFoundError создаёт ошибку «не найдено» для объектов, отличных от подписки
+func newNotFoundError(message string) error {
+	return &domainError{kind: ErrNotFound, message: message}
+}
+
+// wrapRepoError переводит ошибки хранилища в ошибки сервиса, не раскрывая детали драйвера.
+// ErrUnavailable получают только временные сбои (соединение, тайм-аут, конфликт транзакций);
+// прочие ошибки драйвера остаются внутренними и отвечают 500.
+func wrapRepoError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrValidation), errors.Is(err, ErrNotFound), errors.Is(err, ErrConflict), errors.Is(err, ErrPreconditionFailed):
+		// Ошибка сервиса, возвращённая из транзакции, уже готова для клиента
+		return err
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return err
+	}
+
+	err = repository.TranslateError(err)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrNotFound
+	case errors.Is(err, repository.ErrRowSecurity):
+		// Строка чужой организации: как и при фильтре по организации, её для запроса нет
+		return ErrNotFound
+	case errors.Is(err, repository.ErrDuplicate):
+		return newConflictError("subscription already exists")
+	case errors.Is(err, repository.ErrInvalidReference):
+		return newConflictError("referenced record no longer exists")
+	case errors.Is(err, repository.ErrConstraint):
+		return newConflictError("data violates a storage constraint")
+	case repository.IsUnavailable(err):
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	default:
+		return fmt.Errorf("storage error: %w", err)
+	}
+}
+
+// versionMismatch уточняет sql.ErrNoRows от условного изменения: если подписка существует,
+// значит не совпала версия, и возвращается PreconditionFailedError с актуальным состоянием
+func versionMismatch(ctx context.Context, repo repository.SubscriptionRepository, id string, version int64, err error) error {
+	if version == 0 || !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	current, getErr := repo.GetSubscription(ctx, id, false)
+	if getErr != nil {
+		return err
+	}
+	return &PreconditionFailedError{Current: current}
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestWrapRepoError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		kind error // nil — внутренняя ошибка, не относящаяся ни к одной категории
+	}{
+		{"no rows", sql.ErrNoRows, ErrNotFound},
+		{"service error", NewValidationError("price", "price must be positive"), ErrValidation},
+		{"unique violation", &pq.Error{Code: "23505"}, ErrConflict},
+		{"catalog service deleted concurrently", fmt.Errorf("insert: %w", &pq.Error{Code: "23503", Constraint: "subscriptions_service_id_fkey"}), ErrConflict},
+		{"check violation", &pq.Error{Code: "23514", Constraint: "subscriptions_interval_count_check"}, ErrConflict},
+		{"row-level security", &pq.Error{Code: "42501", Message: "new row violates row-level security policy"}, ErrNotFound},
+		{"connection failure", &pq.Error{Code: "08006"}, ErrUnavailable},
+		{"serialization failure", &pq.Error{Code: "40001"}, ErrUnavailable},
+		{"statement timeout", &pq.Error{Code: "57014"}, ErrUnavailable},
+		{"network error", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, ErrUnavailable},
+		{"bad connection", driver.ErrBadConn, ErrUnavailable},
+		{"undefined table", &pq.Error{Code: "42P01"}, nil},
+		{"unknown error", errors.New("boom"), nil},
+	}
+	kinds := []error{ErrNotFound, ErrValidation, ErrConflict, ErrUnavailable, ErrPreconditionFailed}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := wrapRepoError(tt.err)
+			for _, kind := range kinds {
+				if errors.Is(err, kind) != (kind == tt.kind) {
+					t.Errorf("expected errors.Is(%v, %v) to be %t", err, kind, kind == tt.kind)
+				}
+			}
+		})
+	}
+
+	if err := wrapRepoError(context.Canceled); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled to pass through, got %v", err)
+	}
+}
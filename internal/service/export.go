@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+)
+
+// costChunkSize — сколько подписок обрабатывается за раз при потоковом подсчёте стоимости:
+// для каждой пачки одним запросом загружаются периоды приостановки
+const costChunkSize = 500
+
+// ExportSubscriptions передаёт в fn все подписки, подходящие под параметры списка, в порядке сортировки.
+// В отличие от ListSubscriptions, limit не применяется, а строки читаются из курсора по одной.
+func (s *SubscriptionService) ExportSubscriptions(ctx context.Context, params ListSubscriptionsParams, fn func(model.Subscription) error) error {
+	log.Printf("[SERVICE] Exporting subscriptions with params: %+v", params)
+
+	filter, err := params.toFilter()
+	if err != nil {
+		log.Printf("[ERROR] Invalid export parameters: %v", err)
+		return err
+	}
+
+	count := 0
+	err = s.Repo.StreamSubscriptions(ctx, filter, func(sub model.Subscription) error {
+		count++
+		return fn(sub)
+	})
+	if err != nil {
+		log.Printf("[ERROR] Export of subscriptions failed after %d rows: %v", count, err)
+		return wrapRepoError(err)
+	}
+
+	log.Printf("[SUCCESS] Exported %d subscriptions", count)
+	return nil
+}
+
+// ExportCosts передаёт в fn стоимость каждой подписки за период — те же строки, что в items у CalculateTotalCost
+func (s *SubscriptionService) ExportCosts(ctx context.Context, params TotalCostParams, fn func(model.SubscriptionCost) error) error {
+	log.Printf("[SERVICE] Exporting costs for user: %s, service: %s, period: %s - %s", params.UserID, params.ServiceName, params.StartDate, params.EndDate)
+
+	filter, err := params.toFilter()
+	if err != nil {
+		log.Printf("[ERROR] Invalid cost export parameters: %v", err)
+		return err
+	}
+	currency, err := reportCurrency(params.Currency)
+	if err != nil {
+		log.Printf("[ERROR] Invalid cost export parameters: %v", err)
+		return err
+	}
+
+	count := 0
+	err = s.streamCosts(ctx, filter, currency, func(item model.SubscriptionCost) error {
+		count++
+		return fn(item)
+	})
+	if err != nil {
+		log.Printf("[ERROR] Export of costs failed after %d rows: %v", count, err)
+		return wrapRepoError(err)
+	}
+
+	log.Printf("[SUCCESS] Exported costs of %d subscriptions", count)
+	return nil
+}
+
+// streamCosts считает стоимость подписок, пересекающихся с периодом фильтра, в валюте currency
+// и передаёт её в fn по мере чтения подписок из репозитория. Подписки вне периода пропускаются.
+func (s *SubscriptionService) streamCosts(ctx context.Context, filter model.CostFilter, currency string, fn func(model.SubscriptionCost) error) error {
+	now := time.Now()
+	conv := newConverter(s.Repo, currency)
+	return s.streamWithHistory(ctx, filter, func(sub model.Subscription, history billingHistory) error {
+		item, ok, err := subscriptionCost(ctx, sub, history, filter.StartDate, filter.EndDate, now, conv)
+		if err != nil || !ok {
+			return err
+		}
+		return fn(item)
+	})
+}
+
+// streamWithHistory передаёт в fn подписки, пересекающиеся с периодом фильтра, вместе с их паузами
+// и изменениями цен. История загружается одним запросом на пачку из costChunkSize подписок.
+func (s *SubscriptionService) streamWithHistory(ctx context.Context, filter model.CostFilter, fn func(model.Subscription, billingHistory) error) error {
+	chunk := make([]model.Subscription, 0, costChunkSize)
+
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+
+		// Периоды приостановки: такие месяцы не оплачиваются
+		ids := make([]string, 0, len(chunk))
+		for _, sub := range chunk {
+			ids = append(ids, sub.ID)
+		}
+		pauses, err := s.Repo.ListPauses(ctx, ids)
+		if err != nil {
+			return err
+		}
+		prices, err := s.Repo.ListPriceChanges(ctx, ids)
+		if err != nil {
+			return err
+		}
+
+		for _, sub := range chunk {
+			if err := fn(sub, billingHistory{pauses: pauses[sub.ID], prices: prices[sub.ID]}); err != nil {
+				return err
+			}
+		}
+		chunk = chunk[:0]
+		return nil
+	}
+
+	err := s.Repo.StreamSubscriptionsForPeriod(ctx, filter, func(sub model.Subscription) error {
+		chunk = append(chunk, sub)
+		if len(chunk) < costChunkSize {
+			return nil
+		}
+		return flush()
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
@@ -0,0 +1,352 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/repository"
+	"github.com/Headliner38/Subscription_Service/internal/utils"
+)
+
+const (
+	// importChunkSize — сколько строк проверяется на дубликаты и сохраняется за одну транзакцию
+	importChunkSize = 500
+	// maxImportErrors ограничивает число ошибок в ответе
+	maxImportErrors = 1000
+	// maxImportRows ограничивает число строк файла (без заголовка, включая пустые) за один импорт
+	maxImportRows = 100000
+)
+
+// Колонки импортируемого файла
+var (
+	importColumns         = []string{"service_id", "service_name", "price", "currency", "billing_period", "interval_count", "category", "tags", "user_id", "organization_id", "start_date", "end_date"}
+	requiredImportColumns = []string{"service_name", "price", "user_id", "start_date"}
+)
+
+// RowReader построчно отдаёт записи файла; в конце возвращает io.EOF.
+// Ошибка, для которой errors.Is(err, ErrValidation), означает испорченную строку.
+type RowReader interface {
+	Read() ([]string, error)
+}
+
+// importRow — проверенная строка, ожидающая сохранения
+type importRow struct {
+	row int
+	sub *model.Subscription
+	key model.SubscriptionKey
+}
+
+// importer накапливает результат импорта по мере чтения файла
+type importer struct {
+	service *SubscriptionService
+	dryRun  bool
+	result  *model.ImportResult
+	// seen — ключи уже прочитанных строк файла и номера этих строк
+	seen    map[model.SubscriptionKey]int
+	pending []importRow
+	// services сопоставляет строки с каталогом сервисов
+	services *serviceResolver
+	// lastRow — номер последней прочитанной строки, committedThrough — последней строки,
+	// после которой пачка сохранена
+	lastRow          int
+	committedThrough int
+}
+
+// ImportSubscriptions читает подписки из rows потоково: первая запись — заголовок с названиями колонок
+// (service_name, price, user_id, start_date и необязательные колонки в любом порядке), далее по строке
+// на подписку. Строки проверяются и сопоставляются с каталогом сервисов по тем же правилам, что и
+// при создании; дубликаты внутри файла и уже существующие подписки пропускаются. При dryRun ничего не сохраняется.
+// Строки сохраняются пачками; если после сохранения части пачек чтение или запись не удались,
+// возвращается ImportAbortedError с итогом по уже сохранённым строкам.
+func (s *SubscriptionService) ImportSubscriptions(ctx context.Context, rows RowReader, dryRun bool) (*model.ImportResult, error) {
+	log.Printf("[SERVICE] Importing subscriptions (dry run: %t)", dryRun)
+
+	header, err := rows.Read()
+	if err == io.EOF {
+		return nil, NewValidationError("file", "file is empty")
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to read import header: %v", err)
+		return nil, err
+	}
+	columns, err := mapImportColumns(header)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := &importer{
+		service:  s,
+		dryRun:   dryRun,
+		result:   &model.ImportResult{DryRun: dryRun, Errors: []model.ImportRowError{}},
+		seen:     make(map[model.SubscriptionKey]int),
+		services: newServiceResolver(s.Repo),
+	}
+
+	for rowNum := 2; ; rowNum++ {
+		if rowNum-1 > maxImportRows {
+			// Остаток файла не читаем, но уже проверенное сохраняем, как и после испорченной строки
+			imp.addError(rowNum, "file", fmt.Sprintf("file has more than %d rows, the rest is not imported", maxImportRows))
+			break
+		}
+		record, err := rows.Read()
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, ErrValidation) {
+			// Испорченная строка: дальше файл не читаем, но уже проверенное сохраняем
+			imp.result.Rows++
+			imp.result.Invalid++
+			imp.addError(rowNum, "", "malformed row: "+err.Error())
+			break
+		}
+		if err != nil {
+			log.Printf("[ERROR] Failed to read import row %d: %v", rowNum, err)
+			return nil, imp.abort(err)
+		}
+
+		if err := imp.addRecord(ctx, rowNum, record, columns); err != nil {
+			log.Printf("[ERROR] Failed to resolve catalog service for import row %d: %v", rowNum, err)
+			return nil, imp.abort(wrapRepoError(err))
+		}
+		imp.lastRow = rowNum
+		if len(imp.pending) >= importChunkSize {
+			if err := imp.flush(ctx); err != nil {
+				return nil, imp.abort(err)
+			}
+		}
+	}
+	if err := imp.flush(ctx); err != nil {
+		return nil, imp.abort(err)
+	}
+
+	imp.sortErrors()
+
+	r := imp.result
+	log.Printf("[SUCCESS] Import finished: %d rows, %d valid, %d imported, %d duplicates, %d invalid", r.Rows, r.Valid, r.Imported, r.Duplicates, r.Invalid)
+	return r, nil
+}
+
+// mapImportColumns сопоставляет названия колонок заголовка с их индексами
+func mapImportColumns(header []string) (map[string]int, error) {
+	known := make(map[string]bool, len(importColumns))
+	for _, name := range importColumns {
+		known[name] = true
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		// Excel добавляет BOM в начало CSV в UTF-8
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !known[name] {
+			continue
+		}
+		if _, ok := columns[name]; ok {
+			return nil, NewValidationError("header", fmt.Sprintf("column %s is duplicated", name))
+		}
+		columns[name] = i
+	}
+
+	result := &ValidationError{}
+	for _, name := range requiredImportColumns {
+		if _, ok := columns[name]; !ok {
+			result.Fields = append(result.Fields, FieldError{Field: "header", Message: fmt.Sprintf("column %s is required", name)})
+		}
+	}
+	if len(result.Fields) > 0 {
+		return nil, result
+	}
+	return columns, nil
+}
+
+// addRecord проверяет строку и ставит её в очередь на сохранение.
+// Ошибка возвращается только при сбое хранилища; ошибки строки попадают в результат.
+func (imp *importer) addRecord(ctx context.Context, rowNum int, record []string, columns map[string]int) error {
+	value := func(name string) string {
+		idx, ok := columns[name]
+		if !ok || idx >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[idx])
+	}
+
+	empty := true
+	for _, name := range importColumns {
+		if value(name) != "" {
+			empty = false
+			break
+		}
+	}
+	if empty {
+		return nil
+	}
+	imp.result.Rows++
+
+	// Пустая цена допустима, если у сервиса из каталога есть цена по умолчанию
+	var err error
+	price := 0
+	if raw := value("price"); raw != "" {
+		price, err = strconv.Atoi(raw)
+		if err != nil {
+			imp.result.Invalid++
+			imp.addError(rowNum, "price", "price must be an integer")
+			return nil
+		}
+	}
+	intervalCount := 0
+	if raw := value("interval_count"); raw != "" {
+		intervalCount, err = strconv.Atoi(raw)
+		if err != nil {
+			imp.result.Invalid++
+			imp.addError(rowNum, "interval_count", "interval_count must be an integer")
+			return nil
+		}
+	}
+	var tags []string
+	if raw := value("tags"); raw != "" {
+		// Теги в ячейке перечисляются через запятую
+		tags = strings.Split(raw, ",")
+	}
+	endDate := value("end_date")
+	input := SubscriptionInput{
+		ServiceID:      strings.ToLower(value("service_id")),
+		ServiceName:    value("service_name"),
+		Price:          price,
+		Currency:       value("currency"),
+		BillingPeriod:  value("billing_period"),
+		IntervalCount:  intervalCount,
+		Category:       value("category"),
+		Tags:           tags,
+		UserID:         value("user_id"),
+		OrganizationID: value("organization_id"),
+		StartDate:      value("start_date"),
+		EndDate:        &endDate,
+	}
+	err = imp.services.resolve(ctx, &input)
+	if err != nil && !errors.Is(err, ErrValidation) {
+		return err
+	}
+	var data model.Subscription
+	if err == nil {
+		imp.service.scopeInput(&input)
+		data, err = validateSubscriptionInput(input)
+	}
+	if err == nil {
+		err = imp.service.checkOwner(data)
+	}
+	if err != nil {
+		imp.result.Invalid++
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			for _, f := range validationErr.Fields {
+				imp.addError(rowNum, f.Field, f.Message)
+			}
+		}
+		return nil
+	}
+
+	data.UserID = strings.ToLower(data.UserID)
+	sub := model.NewSubscription(utils.GenerateUUID(), data)
+	key := model.SubscriptionKey{UserID: sub.UserID, ServiceName: sub.ServiceName, StartDate: sub.StartDate}
+	if first, ok := imp.seen[key]; ok {
+		imp.result.Duplicates++
+		imp.addError(rowNum, "", fmt.Sprintf("duplicate of row %d", first))
+		return nil
+	}
+	imp.seen[key] = rowNum
+
+	imp.pending = append(imp.pending, importRow{row: rowNum, sub: sub, key: key})
+	return nil
+}
+
+// flush отбрасывает строки, дублирующие существующие подписки, и сохраняет остальные в одной транзакции
+func (imp *importer) flush(ctx context.Context) error {
+	if len(imp.pending) == 0 {
+		return nil
+	}
+	defer func() { imp.pending = imp.pending[:0] }()
+
+	keys := make([]model.SubscriptionKey, len(imp.pending))
+	for i, row := range imp.pending {
+		keys[i] = row.key
+	}
+	existing, err := imp.service.Repo.ExistingKeys(ctx, keys)
+	if err != nil {
+		log.Printf("[ERROR] Failed to check import duplicates: %v", err)
+		return wrapRepoError(err)
+	}
+
+	toSave := make([]*model.Subscription, 0, len(imp.pending))
+	for _, row := range imp.pending {
+		if existing[row.key] {
+			imp.result.Duplicates++
+			imp.addError(row.row, "", "subscription already exists")
+			continue
+		}
+		toSave = append(toSave, row.sub)
+	}
+	imp.result.Valid += len(toSave)
+	if imp.dryRun || len(toSave) == 0 {
+		return nil
+	}
+
+	err = imp.service.Repo.WithTx(ctx, func(repo repository.SubscriptionRepository) error {
+		for _, sub := range toSave {
+			if err := repo.CreateSubscription(ctx, sub); err != nil {
+				return err
+			}
+			err := repo.AppendChange(ctx, &model.SubscriptionChange{
+				Type:           model.ChangeCreated,
+				SubscriptionID: sub.ID,
+				Subscription:   sub,
+			})
+			if err != nil {
+				return err
+			}
+			if err := imp.service.auditChange(ctx, repo, model.AuditCreate, nil, sub); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("[ERROR] Failed to save imported subscriptions: %v", err)
+		return wrapRepoError(err)
+	}
+	imp.result.Imported += len(toSave)
+	imp.committedThrough = imp.lastRow
+	return nil
+}
+
+// abort возвращает err как есть, если ничего ещё не сохранено и импорт можно просто повторить,
+// иначе — ImportAbortedError с итогом по сохранённым строкам
+func (imp *importer) abort(err error) error {
+	if imp.committedThrough == 0 {
+		return err
+	}
+	imp.result.Aborted = true
+	imp.result.CommittedThroughRow = imp.committedThrough
+	imp.sortErrors()
+	log.Printf("[ERROR] Import aborted after row %d, %d subscriptions already imported", imp.committedThrough, imp.result.Imported)
+	return &ImportAbortedError{Result: imp.result, Err: err}
+}
+
+// sortErrors упорядочивает ошибки по строкам: дубликаты из базы находятся при сохранении пачки
+func (imp *importer) sortErrors() {
+	sort.SliceStable(imp.result.Errors, func(i, j int) bool { return imp.result.Errors[i].Row < imp.result.Errors[j].Row })
+}
+
+// addError добавляет ошибку строки, пока не достигнут предел
+func (imp *importer) addError(row int, field, message string) {
+	if len(imp.result.Errors) >= maxImportErrors {
+		imp.result.ErrorsTruncated = true
+		return
+	}
+	imp.result.Errors = append(imp.result.Errors, model.ImportRowError{Row: row, Field: field, Message: message})
+}
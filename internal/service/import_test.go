@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+)
+
+// sliceRows отдаёт заранее заданные записи, а после них — пустые строки до limit
+type sliceRows struct {
+	records [][]string
+	limit   int
+	read    int
+}
+
+func (r *sliceRows) Read() ([]string, error) {
+	r.read++
+	if r.read <= len(r.records) {
+		return r.records[r.read-1], nil
+	}
+	if r.read > r.limit {
+		return nil, io.EOF
+	}
+	return []string{}, nil
+}
+
+func TestImportSubscriptions(t *testing.T) {
+	header := []string{"service_name", "price", "user_id", "start_date"}
+	tests := []struct {
+		name      string
+		records   [][]string
+		imported  int
+		invalid   int
+		duplicate int
+	}{
+		{"valid rows", [][]string{header, {"Netflix", "100", testUserID, "01-2024"}, {"Spotify", "200", testUserID, "02-2024"}}, 2, 0, 0},
+		{"invalid price", [][]string{header, {"Netflix", "abc", testUserID, "01-2024"}}, 0, 1, 0},
+		{"invalid user", [][]string{header, {"Netflix", "100", "alice", "01-2024"}}, 0, 1, 0},
+		{"duplicate row", [][]string{header, {"Netflix", "100", testUserID, "01-2024"}, {"Netflix", "300", testUserID, "01-2024"}}, 1, 0, 1},
+		// Дубликаты определяются по дате начала: месяц без дня — это его первое число
+		{"month and its first day", [][]string{header, {"Netflix", "100", testUserID, "01-2024"}, {"Netflix", "100", testUserID, "2024-01-01"}}, 1, 0, 1},
+		{"same month, other day", [][]string{header, {"Netflix", "100", testUserID, "2024-01-01"}, {"Netflix", "100", testUserID, "2024-01-15"}}, 2, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService()
+			result, err := svc.ImportSubscriptions(context.Background(), &sliceRows{records: tt.records, limit: len(tt.records)}, false)
+			if err != nil {
+				t.Fatalf("ImportSubscriptions: %v", err)
+			}
+			if result.Imported != tt.imported || result.Invalid != tt.invalid || result.Duplicates != tt.duplicate {
+				t.Errorf("expected %d imported, %d invalid, %d duplicates, got %+v", tt.imported, tt.invalid, tt.duplicate, result)
+			}
+		})
+	}
+}
+
+func TestImportStopsAfterMaxRows(t *testing.T) {
+	svc := newTestService()
+	rows := &sliceRows{
+		records: [][]string{{"service_name", "price", "user_id", "start_date"}, {"Netflix", "100", testUserID, "01-2024"}},
+		limit:   maxImportRows * 3,
+	}
+
+	result, err := svc.ImportSubscriptions(context.Background(), rows, false)
+	if err != nil {
+		t.Fatalf("ImportSubscriptions: %v", err)
+	}
+	if rows.read != maxImportRows+1 {
+		t.Errorf("expected the header and %d rows to be read, got %d records", maxImportRows, rows.read)
+	}
+	if result.Imported != 1 {
+		t.Errorf("expected rows before the limit to be imported, got %+v", result)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Field != "file" {
+		t.Errorf("expected a file error about the row limit, got %+v", result.Errors)
+	}
+}
+
+// failingRows отдаёт заголовок и rows корректных строк с разными сервисами, затем ошибку err
+type failingRows struct {
+	rows int
+	err  error
+	read int
+}
+
+func (r *failingRows) Read() ([]string, error) {
+	r.read++
+	switch {
+	case r.read == 1:
+		return []string{"service_name", "price", "user_id", "start_date"}, nil
+	case r.read <= r.rows+1:
+		return []string{fmt.Sprintf("Service %d", r.read), "100", testUserID, "01-2024"}, nil
+	default:
+		return nil, r.err
+	}
+}
+
+func TestImportReportsCommittedRowsOnFailure(t *testing.T) {
+	errRead := errors.New("connection reset")
+	tests := []struct {
+		name      string
+		rows      int
+		aborted   bool
+		imported  int
+		committed int
+	}{
+		// Ни одна пачка не сохранена: импорт можно просто повторить
+		{"failure in the first chunk", importChunkSize - 1, false, 0, 0},
+		{"failure after a saved chunk", importChunkSize + 10, true, importChunkSize, importChunkSize + 1},
+		{"failure after two saved chunks", 2*importChunkSize + 1, true, 2 * importChunkSize, 2*importChunkSize + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService()
+			result, err := svc.ImportSubscriptions(context.Background(), &failingRows{rows: tt.rows, err: errRead}, false)
+			if result != nil || !errors.Is(err, errRead) {
+				t.Fatalf("expected read error and no result, got %+v, %v", result, err)
+			}
+
+			var abortedErr *ImportAbortedError
+			if errors.As(err, &abortedErr) != tt.aborted {
+				t.Fatalf("expected aborted %t, got %v", tt.aborted, err)
+			}
+			if tt.aborted {
+				r := abortedErr.Result
+				if !r.Aborted || r.Imported != tt.imported || r.CommittedThroughRow != tt.committed {
+					t.Errorf("expected %d imported through row %d, got %+v", tt.imported, tt.committed, r)
+				}
+			}
+
+			// Сохранено ровно то, о чём сообщает результат
+			saved := 0
+			err = svc.ExportSubscriptions(context.Background(), ListSubscriptionsParams{}, func(model.Subscription) error {
+				saved++
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("ExportSubscriptions: %v", err)
+			}
+			if saved != tt.imported {
+				t.Errorf("expected %d saved subscriptions, got %d", tt.imported, saved)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/repository"
+	"github.com/Headliner38/Subscription_Service/internal/utils"
+)
+
+// Действия над жизненным циклом подписки
+const (
+	actionPause  = "pause"
+	actionResume = "resume"
+	actionCancel = "cancel"
+)
+
+// transitions — допустимые переходы: действие -> статус до -> статус после.
+// Истёкшая (expired) подписка не допускает никаких переходов.
+var transitions = map[string]map[string]string{
+	actionPause: {
+		model.StatusActive: model.StatusPaused,
+	},
+	actionResume: {
+		model.StatusPaused: model.StatusActive,
+	},
+	actionCancel: {
+		model.StatusActive: model.StatusCancelled,
+		model.StatusPaused: model.StatusCancelled,
+	},
+}
+
+// PauseSubscription приостанавливает активную подписку.
+// Циклы оплаты, в день списания которых подписка на паузе, не учитываются в стоимости.
+func (s *SubscriptionService) PauseSubscription(ctx context.Context, id string) (*model.Subscription, error) {
+	return s.transition(ctx, id, actionPause)
+}
+
+// ResumeSubscription возобновляет приостановленную подписку
+func (s *SubscriptionService) ResumeSubscription(ctx context.Context, id string) (*model.Subscription, error) {
+	return s.transition(ctx, id, actionResume)
+}
+
+// CancelSubscription отменяет подписку: end_date сдвигается на текущий месяц, если он был позже или не задан
+func (s *SubscriptionService) CancelSubscription(ctx context.Context, id string) (*model.Subscription, error) {
+	return s.transition(ctx, id, actionCancel)
+}
+
+// transition выполняет действие над подпиской в одной транзакции с записью в ленту изменений
+func (s *SubscriptionService) transition(ctx context.Context, id, action string) (*model.Subscription, error) {
+	log.Printf("[SERVICE] Applying %s to subscription with ID: %s", action, id)
+
+	if !utils.IsValidUUID(id) {
+		log.Printf("[ERROR] Subscription ID is not a valid UUID for %s: %s", action, id)
+		return nil, ErrNotFound
+	}
+
+	var result *model.Subscription
+	err := s.Repo.WithTx(ctx, func(repo repository.SubscriptionRepository) error {
+		sub, err := repo.GetSubscription(ctx, id, false)
+		if err != nil {
+			return err
+		}
+
+		next, ok := transitions[action][sub.Status]
+		if !ok {
+			return newConflictError(fmt.Sprintf("cannot %s subscription in status %s", action, sub.Status))
+		}
+
+		now := time.Now()
+		endDate := sub.EndDate
+		switch action {
+		case actionPause:
+			err = repo.StartPause(ctx, id, now)
+		case actionResume:
+			err = repo.EndPause(ctx, id, now)
+		case actionCancel:
+			endDate = cancellationEndDate(*sub, now)
+			err = repo.EndPause(ctx, id, now)
+		}
+		if err != nil {
+			return err
+		}
+
+		result, err = repo.SetSubscriptionStatus(ctx, id, next, endDate)
+		if err != nil {
+			return err
+		}
+		err = repo.AppendChange(ctx, &model.SubscriptionChange{
+			Type:           model.ChangeUpdated,
+			SubscriptionID: id,
+			Subscription:   result,
+		})
+		if err != nil {
+			return err
+		}
+		// Действия жизненного цикла называются в журнале так же: pause, resume, cancel
+		return s.auditChange(ctx, repo, action, sub, result)
+	})
+	if err != nil {
+		log.Printf("[ERROR] Failed to %s subscription %s: %v", action, id, err)
+		return nil, wrapRepoError(err)
+	}
+
+	log.Printf("[SUCCESS] Subscription %s is now %s", id, result.Status)
+	return result, nil
+}
+
+// cancellationEndDate возвращает end_date отменённой подписки: последний день уже оплаченного
+// цикла — того, в который попадает сегодняшний день или, для ещё не начавшейся подписки, дата начала.
+// Уже заданный end_date не сдвигается на более позднюю дату.
+func cancellationEndDate(sub model.Subscription, now time.Time) *time.Time {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	_, next := currentCycle(sub, today)
+	end := next.AddDate(0, 0, -1)
+	if sub.EndDate != nil && sub.EndDate.Before(end) {
+		end = *sub.EndDate
+	}
+	return &end
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/utils"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+// ListSubscriptionsParams — параметры списка подписок в том виде, в котором они пришли в запросе
+type ListSubscriptionsParams struct {
+	UserID      string
+	ServiceName string
+	Currency    string
+	MinPrice    string
+	MaxPrice    string
+	Category    string
+	// Tags — теги через запятую; TagsMatch — any (хотя бы один) или all (все)
+	Tags           string
+	TagsMatch      string
+	ActiveAt       string
+	UpdatedSince   string
+	Status         string
+	Sort           string
+	Order          string
+	Limit          string
+	Cursor         string
+	IncludeDeleted bool
+}
+
+// cursorPayload — содержимое непрозрачного курсора пагинации
+type cursorPayload struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+// toFilter валидирует параметры и преобразует их в фильтр репозитория
+func (p ListSubscriptionsParams) toFilter() (model.SubscriptionFilter, error) {
+	filter := model.SubscriptionFilter{
+		UserID:         p.UserID,
+		ServiceName:    p.ServiceName,
+		Category:       normalizeCategory(p.Category),
+		Sort:           "start_date",
+		Order:          "asc",
+		Limit:          defaultListLimit,
+		IncludeDeleted: p.IncludeDeleted,
+	}
+
+	if p.UserID != "" && !utils.IsValidUUID(p.UserID) {
+		return filter, NewValidationError("user_id", "user_id must be a valid UUID")
+	}
+
+	if p.Currency != "" {
+		currency, err := reportCurrency(p.Currency)
+		if err != nil {
+			return filter, err
+		}
+		filter.Currency = currency
+	}
+
+	tags, matchAll, err := parseTagFilter(p.Tags, p.TagsMatch)
+	if err != nil {
+		return filter, err
+	}
+	filter.Tags, filter.MatchAllTags = tags, matchAll
+
+	if p.MinPrice != "" {
+		v, err := strconv.Atoi(p.MinPrice)
+		if err != nil || v < 0 {
+			return filter, NewValidationError("min_price", "min_price must be a non-negative integer")
+		}
+		filter.MinPrice = &v
+	}
+	if p.MaxPrice != "" {
+		v, err := strconv.Atoi(p.MaxPrice)
+		if err != nil || v < 0 {
+			return filter, NewValidationError("max_price", "max_price must be a non-negative integer")
+		}
+		filter.MaxPrice = &v
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MaxPrice < *filter.MinPrice {
+		return filter, NewValidationError("max_price", "max_price cannot be less than min_price")
+	}
+
+	// active_at — месяц MM-YYYY (подписка действует хотя бы один его день) или конкретная дата
+	if p.ActiveAt != "" {
+		from, err := parseDate("active_at", p.ActiveAt, false)
+		if err != nil {
+			return filter, err
+		}
+		to, _ := parseDate("active_at", p.ActiveAt, true)
+		filter.ActiveFrom, filter.ActiveTo = &from, &to
+	}
+
+	if p.UpdatedSince != "" {
+		t, err := time.Parse(time.RFC3339, p.UpdatedSince)
+		if err != nil {
+			return filter, NewValidationError("updated_since", "invalid updated_since format, expected RFC 3339 timestamp")
+		}
+		filter.UpdatedSince = &t
+	}
+
+	switch p.Status {
+	case "", model.StatusActive, model.StatusPaused, model.StatusCancelled, model.StatusExpired:
+		filter.Status = p.Status
+	default:
+		return filter, NewValidationError("status", "status must be one of: active, paused, cancelled, expired")
+	}
+
+	switch p.Sort {
+	case "":
+	case "start_date", "price", "service_name", "created_at", "updated_at":
+		filter.Sort = p.Sort
+	default:
+		return filter, NewValidationError("sort", "sort must be one of: start_date, price, service_name, created_at, updated_at")
+	}
+
+	switch p.Order {
+	case "":
+	case "asc", "desc":
+		filter.Order = p.Order
+	default:
+		return filter, NewValidationError("order", "order must be asc or desc")
+	}
+
+	if p.Limit != "" {
+		v, err := strconv.Atoi(p.Limit)
+		if err != nil || v <= 0 || v > maxListLimit {
+			return filter, NewValidationError("limit", "limit must be an integer between 1 and "+strconv.Itoa(maxListLimit))
+		}
+		filter.Limit = v
+	}
+
+	if p.Cursor != "" {
+		after, err := decodeCursor(p.Cursor, filter)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = after
+	}
+
+	return filter, nil
+}
+
+// encodeCursor строит курсор, указывающий на позицию после sub
+func encodeCursor(filter model.SubscriptionFilter, sub model.Subscription) string {
+	payload := cursorPayload{Sort: filter.Sort, Order: filter.Order, ID: sub.ID}
+	switch filter.Sort {
+	case "price":
+		payload.Value = strconv.Itoa(sub.Price)
+	case "service_name":
+		payload.Value = sub.ServiceName
+	case "created_at":
+		payload.Value = sub.CreatedAt.Format(time.RFC3339Nano)
+	case "updated_at":
+		payload.Value = sub.UpdatedAt.Format(time.RFC3339Nano)
+	default:
+		payload.Value = sub.StartDate.Format(time.RFC3339Nano)
+	}
+
+	data, _ := json.Marshal(payload)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor разбирает курсор и проверяет, что он выдан для той же сортировки
+func decodeCursor(cursor string, filter model.SubscriptionFilter) (*model.ListCursor, error) {
+	invalid := NewValidationError("cursor", "invalid cursor")
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalid
+	}
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil || !utils.IsValidUUID(payload.ID) {
+		return nil, invalid
+	}
+	if payload.Sort != filter.Sort || payload.Order != filter.Order {
+		return nil, NewValidationError("cursor", "cursor does not match sort and order")
+	}
+
+	after := &model.ListCursor{ID: payload.ID}
+	switch filter.Sort {
+	case "price":
+		v, err := strconv.Atoi(payload.Value)
+		if err != nil {
+			return nil, invalid
+		}
+		after.Value = v
+	case "service_name":
+		after.Value = payload.Value
+	default:
+		t, err := time.Parse(time.RFC3339Nano, payload.Value)
+		if err != nil {
+			return nil, invalid
+		}
+		after.Value = t
+	}
+
+	return after, nil
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/utils"
+)
+
+// SubscriptionPatch — частичное изменение подписки (JSON Merge Patch).
+// nil-поля не меняются.
+type SubscriptionPatch struct {
+	// ServiceID привязывает подписку к другому сервису каталога; новое ServiceName без ServiceID
+	// заново ищется в каталоге
+	ServiceID   *string
+	ServiceName *string
+	Price       *int
+	Currency    *string
+	// BillingPeriod и IntervalCount меняют расписание списаний
+	BillingPeriod *string
+	IntervalCount *int
+	Category      *string
+	// Tags заменяет список тегов целиком; пустой список убирает все теги
+	Tags      *[]string
+	UserID    *string
+	StartDate *string
+	// EndDateSet — end_date присутствует в патче; EndDate == nil при этом очищает дату окончания
+	EndDateSet bool
+	EndDate    *string
+}
+
+// empty сообщает, что патч ничего не меняет
+func (p SubscriptionPatch) empty() bool {
+	return p.ServiceID == nil && p.ServiceName == nil && p.Price == nil && p.Currency == nil && p.BillingPeriod == nil && p.IntervalCount == nil && p.Category == nil && p.Tags == nil && p.UserID == nil && p.StartDate == nil && !p.EndDateSet
+}
+
+// PatchSubscription применяет патч к текущему состоянию подписки и сохраняет результат
+// с теми же проверками, что и UpdateSubscription. version больше нуля требует совпадения версии.
+func (s *SubscriptionService) PatchSubscription(ctx context.Context, id string, patch SubscriptionPatch, version int64) (*model.Subscription, error) {
+	log.Printf("[SERVICE] Patching subscription with ID: %s", id)
+
+	if !utils.IsValidUUID(id) {
+		log.Printf("[ERROR] Subscription ID is not a valid UUID for patch: %s", id)
+		return nil, ErrNotFound
+	}
+
+	current, err := s.GetSubscription(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	if version > 0 && current.Version != version {
+		log.Printf("[ERROR] Version mismatch for patch of %s: expected %d, current %d", id, version, current.Version)
+		return nil, &PreconditionFailedError{Current: current}
+	}
+	if patch.empty() {
+		log.Printf("[SUCCESS] Empty patch for subscription %s, nothing to change", id)
+		return current, nil
+	}
+
+	// Накладываем патч на текущие значения
+	input := SubscriptionInput{
+		ServiceName:    current.ServiceName,
+		Price:          current.Price,
+		Currency:       current.Currency,
+		BillingPeriod:  current.BillingPeriod,
+		IntervalCount:  current.IntervalCount,
+		Category:       current.Category,
+		Tags:           current.Tags,
+		UserID:         current.UserID,
+		OrganizationID: current.OrganizationID,
+		StartDate:      current.StartDate.Format(dateLayout),
+	}
+	if current.ServiceID != nil {
+		input.ServiceID = *current.ServiceID
+	}
+	if patch.ServiceName != nil {
+		input.ServiceName = *patch.ServiceName
+		input.ServiceID = ""
+	}
+	if patch.ServiceID != nil {
+		input.ServiceID = *patch.ServiceID
+	}
+	if patch.Price != nil {
+		input.Price = *patch.Price
+	}
+	if patch.Currency != nil {
+		input.Currency = *patch.Currency
+	}
+	if patch.BillingPeriod != nil {
+		input.BillingPeriod = *patch.BillingPeriod
+	}
+	if patch.IntervalCount != nil {
+		input.IntervalCount = *patch.IntervalCount
+	}
+	if patch.Category != nil {
+		input.Category = *patch.Category
+	}
+	if patch.Tags != nil {
+		input.Tags = *patch.Tags
+	}
+	if patch.UserID != nil {
+		input.UserID = *patch.UserID
+	}
+	if patch.StartDate != nil {
+		input.StartDate = *patch.StartDate
+	}
+	if patch.EndDateSet {
+		input.EndDate = patch.EndDate
+	} else if current.EndDate != nil {
+		formatted := current.EndDate.Format(dateLayout)
+		input.EndDate = &formatted
+	}
+
+	// Сохраняем с версией прочитанной подписки, чтобы не затереть изменение, сделанное между чтением и записью
+	return s.UpdateSubscription(ctx, id, input, current.Version)
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/repository"
+	"github.com/Headliner38/Subscription_Service/internal/utils"
+)
+
+// SetPrice сохраняет цену подписки id, действующую с даты dateStr (MM-YYYY или YYYY-MM-DD).
+// Дата может быть как в прошлом, так и в будущем; цена на ту же дату заменяется.
+// Версия подписки повышается; version больше нуля требует её совпадения, как в UpdateSubscription.
+// Возвращает изменение цены и подписку с новой версией.
+func (s *SubscriptionService) SetPrice(ctx context.Context, id, dateStr string, price int, version int64) (*model.PriceChange, *model.Subscription, error) {
+	log.Printf("[SERVICE] Setting price of subscription %s from %s: %d", id, dateStr, price)
+
+	if !utils.IsValidUUID(id) {
+		log.Printf("[ERROR] Subscription ID is not a valid UUID for price change: %s", id)
+		return nil, nil, ErrNotFound
+	}
+	effectiveFrom, err := parseDate("effective_from", dateStr, false)
+	if err != nil {
+		log.Printf("[ERROR] Invalid price change: %v", err)
+		return nil, nil, err
+	}
+	if price <= 0 {
+		return nil, nil, NewValidationError("price", "price must be positive")
+	}
+
+	change := &model.PriceChange{SubscriptionID: id, EffectiveFrom: effectiveFrom, Price: price}
+	var updated *model.Subscription
+	err = s.Repo.WithTx(ctx, func(repo repository.SubscriptionRepository) error {
+		sub, err := repo.GetSubscription(ctx, id, false)
+		if err != nil {
+			return err
+		}
+		if version > 0 && sub.Version != version {
+			return &PreconditionFailedError{Current: sub}
+		}
+		// Цена на дату начала — это price самой подписки, она меняется через PUT и PATCH
+		if !effectiveFrom.After(sub.StartDate) {
+			return NewValidationError("effective_from", "effective_from must be after start_date")
+		}
+		if sub.EndDate != nil && effectiveFrom.After(*sub.EndDate) {
+			return NewValidationError("effective_from", "effective_from cannot be after end_date")
+		}
+		old, err := priceOn(ctx, repo, id, effectiveFrom)
+		if err != nil {
+			return err
+		}
+		if err := repo.UpsertPriceChange(ctx, change); err != nil {
+			return err
+		}
+		if err := touchAfterPriceChange(ctx, repo, sub); err != nil {
+			return err
+		}
+		updated = sub
+		return s.audit(ctx, repo, model.AuditSetPrice, sub, map[string]model.FieldChange{
+			priceField(effectiveFrom): {Old: old, New: price},
+		})
+	})
+	if err != nil {
+		log.Printf("[ERROR] Failed to save price change: %v", err)
+		return nil, nil, wrapRepoError(err)
+	}
+
+	log.Printf("[SUCCESS] Price of subscription %s from %s set to %d", id, dateStr, price)
+	return change, updated, nil
+}
+
+// DeletePriceChange удаляет изменение цены подписки id на дату dateStr. Версия подписки
+// повышается так же, как в SetPrice; возвращается подписка с новой версией.
+func (s *SubscriptionService) DeletePriceChange(ctx context.Context, id, dateStr string, version int64) (*model.Subscription, error) {
+	log.Printf("[SERVICE] Deleting price change of subscription %s from %s", id, dateStr)
+
+	if !utils.IsValidUUID(id) {
+		log.Printf("[ERROR] Subscription ID is not a valid UUID for price change: %s", id)
+		return nil, ErrNotFound
+	}
+	effectiveFrom, err := parseDate("effective_from", dateStr, false)
+	if err != nil {
+		log.Printf("[ERROR] Invalid price change: %v", err)
+		return nil, err
+	}
+
+	var updated *model.Subscription
+	err = s.Repo.WithTx(ctx, func(repo repository.SubscriptionRepository) error {
+		sub, err := repo.GetSubscription(ctx, id, false)
+		if err != nil {
+			return err
+		}
+		if version > 0 && sub.Version != version {
+			return &PreconditionFailedError{Current: sub}
+		}
+		old, err := priceOn(ctx, repo, id, effectiveFrom)
+		if err != nil {
+			return err
+		}
+		if err := repo.DeletePriceChange(ctx, id, effectiveFrom); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return newNotFoundError("price change not found")
+			}
+			return err
+		}
+		if err := touchAfterPriceChange(ctx, repo, sub); err != nil {
+			return err
+		}
+		updated = sub
+		return s.audit(ctx, repo, model.AuditDeletePrice, sub, map[string]model.FieldChange{
+			priceField(effectiveFrom): {Old: old},
+		})
+	})
+	if err != nil {
+		log.Printf("[ERROR] Failed to delete price change: %v", err)
+		return nil, wrapRepoError(err)
+	}
+
+	log.Printf("[SUCCESS] Deleted price change of subscription %s from %s", id, dateStr)
+	return updated, nil
+}
+
+// ListPriceChanges возвращает подписку и изменения её цены, упорядоченные по дате
+func (s *SubscriptionService) ListPriceChanges(ctx context.Context, id string) (*model.Subscription, []model.PriceChange, error) {
+	log.Printf("[SERVICE] Listing price changes of subscription %s", id)
+
+	sub, err := s.GetSubscription(ctx, id, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changes, err := s.Repo.ListPriceChanges(ctx, []string{id})
+	if err != nil {
+		log.Printf("[ERROR] Failed to list price changes from DB: %v", err)
+		return nil, nil, wrapRepoError(err)
+	}
+	items := changes[id]
+	if items == nil {
+		items = []model.PriceChange{}
+	}
+
+	log.Printf("[SUCCESS] Retrieved %d price changes of subscription %s", len(items), id)
+	return sub, items, nil
+}
+
+// touchAfterPriceChange повышает версию и updated_at подписки sub и записывает событие updated:
+// изменение цены меняет стоимость, поэтому его должны увидеть лента изменений, updated_since и ETag.
+// Обновление ждёт версию, прочитанную в этой же транзакции, чтобы не затереть параллельное изменение.
+func touchAfterPriceChange(ctx context.Context, repo repository.SubscriptionRepository, sub *model.Subscription) error {
+	if err := repo.UpdateSubscription(ctx, sub); err != nil {
+		return versionMismatch(ctx, repo, sub.ID, sub.Version, err)
+	}
+	return repo.AppendChange(ctx, &model.SubscriptionChange{
+		Type:           model.ChangeUpdated,
+		SubscriptionID: sub.ID,
+		Subscription:   sub,
+	})
+}
+
+// priceField — имя изменённого поля в журнале аудита для цены с даты effectiveFrom
+func priceField(effectiveFrom time.Time) string {
+	return "prices." + effectiveFrom.Format(dateLayout)
+}
+
+// priceOn возвращает цену подписки, заданную с даты effectiveFrom, или nil, если её нет
+func priceOn(ctx context.Context, repo repository.SubscriptionRepository, id string, effectiveFrom time.Time) (interface{}, error) {
+	changes, err := repo.ListPriceChanges(ctx, []string{id})
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range changes[id] {
+		if change.EffectiveFrom.Equal(effectiveFrom) {
+			return change.Price, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+)
+
+func TestPriceChangesBumpVersionAndFeed(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService()
+	sub, err := svc.CreateSubscription(ctx, validInput())
+	if err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+
+	steps := []struct {
+		name    string
+		run     func(version int64) (*model.Subscription, error)
+		version func(current int64) int64
+		err     error
+	}{
+		{"set price without If-Match", func(v int64) (*model.Subscription, error) {
+			_, updated, err := svc.SetPrice(ctx, sub.ID, "2024-06-01", 12000, v)
+			return updated, err
+		}, func(int64) int64 { return 0 }, nil},
+		{"set price with stale version", func(v int64) (*model.Subscription, error) {
+			_, updated, err := svc.SetPrice(ctx, sub.ID, "2024-07-01", 13000, v)
+			return updated, err
+		}, func(current int64) int64 { return current - 1 }, ErrPreconditionFailed},
+		{"set price with current version", func(v int64) (*model.Subscription, error) {
+			_, updated, err := svc.SetPrice(ctx, sub.ID, "2024-07-01", 13000, v)
+			return updated, err
+		}, func(current int64) int64 { return current }, nil},
+		{"delete price with stale version", func(v int64) (*model.Subscription, error) {
+			return svc.DeletePriceChange(ctx, sub.ID, "2024-07-01", v)
+		}, func(current int64) int64 { return current - 1 }, ErrPreconditionFailed},
+		{"delete price with current version", func(v int64) (*model.Subscription, error) {
+			return svc.DeletePriceChange(ctx, sub.ID, "2024-07-01", v)
+		}, func(current int64) int64 { return current }, nil},
+	}
+
+	version := sub.Version
+	events := 1 // событие created
+	for _, step := range steps {
+		updated, err := step.run(step.version(version))
+		if !errors.Is(err, step.err) {
+			t.Fatalf("%s: expected error %v, got %v", step.name, step.err, err)
+		}
+		if step.err == nil {
+			if updated.Version != version+1 {
+				t.Fatalf("%s: expected version %d, got %d", step.name, version+1, updated.Version)
+			}
+			version = updated.Version
+			events++
+		}
+
+		// Лента получает событие updated со снимком только при успешном изменении
+		page, err := svc.ListChanges(ctx, "", "")
+		if err != nil {
+			t.Fatalf("ListChanges: %v", err)
+		}
+		if len(page.Events) != events {
+			t.Fatalf("%s: expected %d events, got %d", step.name, events, len(page.Events))
+		}
+		last := page.Events[len(page.Events)-1]
+		if step.err == nil && (last.Type != model.ChangeUpdated || last.Subscription == nil || last.Subscription.Version != version) {
+			t.Errorf("%s: expected updated event with version %d, got %+v", step.name, version, last)
+		}
+	}
+
+	current, err := svc.GetSubscription(ctx, sub.ID, false)
+	if err != nil {
+		t.Fatalf("GetSubscription: %v", err)
+	}
+	if current.Version != version {
+		t.Errorf("expected version %d, got %d", version, current.Version)
+	}
+}
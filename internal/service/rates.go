@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/money"
+)
+
+// ExchangeRateParams — фильтры списка курсов в том виде, в котором они пришли в запросе
+type ExchangeRateParams struct {
+	BaseCurrency  string
+	QuoteCurrency string
+	From          string
+	To            string
+}
+
+// parseCurrencyPair проверяет пару валют курса
+func parseCurrencyPair(base, quote string) (string, string, error) {
+	base, quote = normalizeCurrency(base), normalizeCurrency(quote)
+	if !money.IsValidCurrency(base) {
+		return "", "", NewValidationError("base_currency", "base_currency must be an ISO 4217 code")
+	}
+	if !money.IsValidCurrency(quote) {
+		return "", "", NewValidationError("quote_currency", "quote_currency must be an ISO 4217 code")
+	}
+	if base == quote {
+		return "", "", NewValidationError("quote_currency", "quote_currency must differ from base_currency")
+	}
+	return base, quote, nil
+}
+
+// parseRateDate разбирает дату курса в формате YYYY-MM-DD
+func parseRateDate(field, value string) (time.Time, error) {
+	date, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, NewValidationError(field, "invalid "+field+" format, expected YYYY-MM-DD")
+	}
+	return date, nil
+}
+
+// SetExchangeRate сохраняет курс base/quote, действующий с даты dateStr; курс на ту же дату заменяется
+func (s *SubscriptionService) SetExchangeRate(ctx context.Context, base, quote, dateStr, rateStr string) (*model.ExchangeRate, error) {
+	log.Printf("[SERVICE] Setting exchange rate %s/%s on %s: %s", base, quote, dateStr, rateStr)
+
+	base, quote, err := parseCurrencyPair(base, quote)
+	if err != nil {
+		log.Printf("[ERROR] Invalid exchange rate: %v", err)
+		return nil, err
+	}
+	date, err := parseRateDate("date", dateStr)
+	if err != nil {
+		log.Printf("[ERROR] Invalid exchange rate: %v", err)
+		return nil, err
+	}
+	rate, err := money.ParseRate(rateStr)
+	if err != nil {
+		log.Printf("[ERROR] Invalid exchange rate: %v", err)
+		return nil, NewValidationError("rate", err.Error())
+	}
+
+	result := &model.ExchangeRate{
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		Date:          date,
+		Rate:          money.FormatRate(rate),
+	}
+	if err := s.Repo.UpsertExchangeRate(ctx, result); err != nil {
+		log.Printf("[ERROR] Failed to save exchange rate to DB: %v", err)
+		return nil, wrapRepoError(err)
+	}
+
+	log.Printf("[SUCCESS] Exchange rate %s/%s on %s set to %s", base, quote, dateStr, result.Rate)
+	return result, nil
+}
+
+// DeleteExchangeRate удаляет курс base/quote на дату dateStr
+func (s *SubscriptionService) DeleteExchangeRate(ctx context.Context, base, quote, dateStr string) error {
+	log.Printf("[SERVICE] Deleting exchange rate %s/%s on %s", base, quote, dateStr)
+
+	base, quote, err := parseCurrencyPair(base, quote)
+	if err != nil {
+		log.Printf("[ERROR] Invalid exchange rate: %v", err)
+		return err
+	}
+	date, err := parseRateDate("date", dateStr)
+	if err != nil {
+		log.Printf("[ERROR] Invalid exchange rate: %v", err)
+		return err
+	}
+
+	if err := s.Repo.DeleteExchangeRate(ctx, base, quote, date); err != nil {
+		log.Printf("[ERROR] Failed to delete exchange rate from DB: %v", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return newNotFoundError("exchange rate not found")
+		}
+		return wrapRepoError(err)
+	}
+
+	log.Printf("[SUCCESS] Deleted exchange rate %s/%s on %s", base, quote, dateStr)
+	return nil
+}
+
+// ListExchangeRates возвращает курсы по фильтрам, упорядоченные по паре валют и дате
+func (s *SubscriptionService) ListExchangeRates(ctx context.Context, params ExchangeRateParams) ([]model.ExchangeRate, error) {
+	log.Printf("[SERVICE] Listing exchange rates with params: %+v", params)
+
+	var filter model.ExchangeRateFilter
+	if params.BaseCurrency != "" {
+		filter.BaseCurrency = normalizeCurrency(params.BaseCurrency)
+		if !money.IsValidCurrency(filter.BaseCurrency) {
+			return nil, NewValidationError("base_currency", "base_currency must be an ISO 4217 code")
+		}
+	}
+	if params.QuoteCurrency != "" {
+		filter.QuoteCurrency = normalizeCurrency(params.QuoteCurrency)
+		if !money.IsValidCurrency(filter.QuoteCurrency) {
+			return nil, NewValidationError("quote_currency", "quote_currency must be an ISO 4217 code")
+		}
+	}
+	if params.From != "" {
+		from, err := parseRateDate("from", params.From)
+		if err != nil {
+			return nil, err
+		}
+		filter.From = &from
+	}
+	if params.To != "" {
+		to, err := parseRateDate("to", params.To)
+		if err != nil {
+			return nil, err
+		}
+		filter.To = &to
+	}
+
+	rates, err := s.Repo.ListExchangeRates(ctx, filter)
+	if err != nil {
+		log.Printf("[ERROR] Failed to list exchange rates from DB: %v", err)
+		return nil, wrapRepoError(err)
+	}
+
+	// NUMERIC отдаётся с нулями до полной точности, приводим к тому же виду, что при сохранении
+	for i := range rates {
+		if rate, err := money.ParseRate(rates[i].Rate); err == nil {
+			rates[i].Rate = money.FormatRate(rate)
+		}
+	}
+	if rates == nil {
+		rates = []model.ExchangeRate{}
+	}
+
+	log.Printf("[SUCCESS] Retrieved %d exchange rates", len(rates))
+	return rates, nil
+}
@@ -0,0 +1,358 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+)
+
+// spendDimensions — допустимые измерения отчёта о расходах в том порядке, в котором они выводятся
+var spendDimensions = []string{model.GroupByMonth, model.GroupByServiceName, model.GroupByUserID, model.GroupByCategory}
+
+// tagDimensions — измерения отчёта по тегам помимо самого тега
+var tagDimensions = []string{model.GroupByMonth}
+
+// SpendReportParams — параметры отчёта о расходах в том виде, в котором они пришли в запросе
+type SpendReportParams struct {
+	UserID         string
+	ServiceName    string
+	ServiceID      string
+	Category       string
+	Tags           string
+	TagsMatch      string
+	StartDate      string
+	EndDate        string
+	GroupBy        string
+	Currency       string
+	IncludeDeleted bool
+}
+
+// costParams возвращает фильтры отчёта в виде параметров подсчёта стоимости
+func (p SpendReportParams) costParams() TotalCostParams {
+	return TotalCostParams{
+		UserID:         p.UserID,
+		ServiceName:    p.ServiceName,
+		ServiceID:      p.ServiceID,
+		Category:       p.Category,
+		Tags:           p.Tags,
+		TagsMatch:      p.TagsMatch,
+		StartDate:      p.StartDate,
+		EndDate:        p.EndDate,
+		IncludeDeleted: p.IncludeDeleted,
+	}
+}
+
+// parseGroupBy разбирает список измерений через запятую и возвращает их в порядке dimensions
+func parseGroupBy(value string, dimensions []string) ([]string, error) {
+	requested := map[string]bool{}
+	for _, dim := range strings.Split(value, ",") {
+		dim = strings.TrimSpace(dim)
+		if dim == "" {
+			continue
+		}
+		known := false
+		for _, d := range dimensions {
+			if d == dim {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, NewValidationError("group_by", "group_by must be a comma-separated list of: "+strings.Join(dimensions, ", "))
+		}
+		requested[dim] = true
+	}
+
+	groupBy := make([]string, 0, len(requested))
+	for _, dim := range dimensions {
+		if requested[dim] {
+			groupBy = append(groupBy, dim)
+		}
+	}
+	return groupBy, nil
+}
+
+// monthAxis — первый и последний месяц, в которые пришлись расходы отчёта
+type monthAxis struct {
+	first, last time.Time
+}
+
+func (a *monthAxis) add(month time.Time) {
+	if a.first.IsZero() || month.Before(a.first) {
+		a.first = month
+	}
+	if month.After(a.last) {
+		a.last = month
+	}
+}
+
+// months возвращает все месяцы периода отчёта по порядку; незаданные границы периода
+// берутся по найденным расходам
+func (a monthAxis) months(filter model.CostFilter) []string {
+	first, last := a.first, a.last
+	if !filter.StartDate.IsZero() {
+		first = monthStart(filter.StartDate)
+	}
+	if !filter.EndDate.IsZero() {
+		last = monthStart(filter.EndDate)
+	}
+
+	months := []string{}
+	if first.IsZero() || last.IsZero() {
+		return months
+	}
+	for month := first; !month.After(last); month = month.AddDate(0, 1, 0) {
+		months = append(months, month.Format(monthLayout))
+	}
+	return months
+}
+
+// streamCharges раскладывает списания подписок, пересекающихся с периодом фильтра, по оплачиваемым
+// месяцам (с теми же правилами пересечения, пауз и цен, что в CalculateTotalCost) и передаёт в fn
+// платёж каждого месяца, пересчитанный в валюту currency
+func (s *SubscriptionService) streamCharges(ctx context.Context, filter model.CostFilter, currency string, fn func(sub model.Subscription, month time.Time, charge int) error) error {
+	now := time.Now()
+	conv := newConverter(s.Repo, currency)
+	return s.streamWithHistory(ctx, filter, func(sub model.Subscription, history billingHistory) error {
+		for _, share := range billingShares(sub, history, filter.StartDate, filter.EndDate, now) {
+			if !share.billed {
+				continue
+			}
+			charge, err := conv.convert(ctx, share.amount, sub.Currency, share.month)
+			if err != nil {
+				return err
+			}
+			if err := fn(sub, share.month, charge); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// spendKey — значения измерений одной группы отчёта
+type spendKey struct {
+	month       time.Time
+	serviceName string
+	userID      string
+	category    string
+	tag         string
+}
+
+// spendGroup накапливает расходы группы
+type spendGroup struct {
+	spend         int
+	subscriptions int
+	lastID        string
+}
+
+// spendGroups — группы отчёта по ключам
+type spendGroups map[spendKey]*spendGroup
+
+// add добавляет платёж подписки в группу key
+func (g spendGroups) add(key spendKey, subscriptionID string, charge int) {
+	group, ok := g[key]
+	if !ok {
+		group = &spendGroup{}
+		g[key] = group
+	}
+	group.spend += charge
+	// Подписки читаются по одной, поэтому достаточно сравнить с последней учтённой
+	if group.lastID != subscriptionID {
+		group.subscriptions++
+		group.lastID = subscriptionID
+	}
+}
+
+// sortedKeys возвращает ключи групп по месяцу, сервису, пользователю, категории и тегу
+func (g spendGroups) sortedKeys() []spendKey {
+	keys := make([]spendKey, 0, len(g))
+	for key := range g {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		switch {
+		case !a.month.Equal(b.month):
+			return a.month.Before(b.month)
+		case a.serviceName != b.serviceName:
+			return a.serviceName < b.serviceName
+		case a.userID != b.userID:
+			return a.userID < b.userID
+		case a.category != b.category:
+			return a.category < b.category
+		case a.tag != b.tag:
+			// Подписки без тегов — в конце
+			return b.tag == "" || (a.tag != "" && a.tag < b.tag)
+		}
+		return false
+	})
+	return keys
+}
+
+// SpendReport строит отчёт о расходах за период: стоимость каждой подписки раскладывается по
+// оплачиваемым месяцам (с теми же правилами пересечения, пауз и пересчёта валют, что в CalculateTotalCost)
+// и суммируется по группам group_by
+func (s *SubscriptionService) SpendReport(ctx context.Context, params SpendReportParams) (*model.SpendReport, error) {
+	log.Printf("[SERVICE] Building spend report grouped by %q for user: %s, service: %s, period: %s - %s", params.GroupBy, params.UserID, params.ServiceName, params.StartDate, params.EndDate)
+
+	groupBy, err := parseGroupBy(params.GroupBy, spendDimensions)
+	if err != nil {
+		log.Printf("[ERROR] Invalid spend report parameters: %v", err)
+		return nil, err
+	}
+	if len(groupBy) == 0 {
+		groupBy = []string{model.GroupByMonth}
+	}
+	filter, err := params.costParams().toFilter()
+	if err != nil {
+		log.Printf("[ERROR] Invalid spend report parameters: %v", err)
+		return nil, err
+	}
+	currency, err := reportCurrency(params.Currency)
+	if err != nil {
+		log.Printf("[ERROR] Invalid spend report parameters: %v", err)
+		return nil, err
+	}
+
+	byMonth, byService, byUser, byCategory := false, false, false, false
+	for _, dim := range groupBy {
+		switch dim {
+		case model.GroupByMonth:
+			byMonth = true
+		case model.GroupByServiceName:
+			byService = true
+		case model.GroupByUserID:
+			byUser = true
+		case model.GroupByCategory:
+			byCategory = true
+		}
+	}
+
+	// Добавляем платёж каждого месяца в группу подписки
+	groups := spendGroups{}
+	var axis monthAxis
+	err = s.streamCharges(ctx, filter, currency, func(sub model.Subscription, month time.Time, charge int) error {
+		axis.add(month)
+
+		var key spendKey
+		if byMonth {
+			key.month = month
+		}
+		if byService {
+			key.serviceName = sub.ServiceName
+		}
+		if byUser {
+			key.userID = sub.UserID
+		}
+		if byCategory {
+			key.category = sub.Category
+		}
+		groups.add(key, sub.ID, charge)
+		return nil
+	})
+	if err != nil {
+		log.Printf("[ERROR] Failed to build spend report: %v", err)
+		return nil, wrapRepoError(err)
+	}
+
+	report := &model.SpendReport{GroupBy: groupBy, Currency: currency, Months: axis.months(filter), Rows: make([]model.SpendRow, 0, len(groups))}
+	for _, key := range groups.sortedKeys() {
+		group := groups[key]
+		row := model.SpendRow{
+			ServiceName:   key.serviceName,
+			UserID:        key.userID,
+			Category:      key.category,
+			Spend:         group.spend,
+			Subscriptions: group.subscriptions,
+		}
+		if byMonth {
+			row.Month = key.month.Format(monthLayout)
+		}
+		report.Total += group.spend
+		report.Rows = append(report.Rows, row)
+	}
+
+	log.Printf("[SUCCESS] Built spend report: %d rows, total %d", len(report.Rows), report.Total)
+	return report, nil
+}
+
+// TagReport строит отчёт о расходах по тегам с теми же правилами, что SpendReport. Платёж подписки
+// добавляется в строку каждого её тега, подписки без тегов собираются в строку с пустым тегом.
+// Если задан фильтр tags, в отчёт попадают только строки перечисленных тегов.
+func (s *SubscriptionService) TagReport(ctx context.Context, params SpendReportParams) (*model.TagReport, error) {
+	log.Printf("[SERVICE] Building tag report for tags %q grouped by %q, period: %s - %s", params.Tags, params.GroupBy, params.StartDate, params.EndDate)
+
+	groupBy, err := parseGroupBy(params.GroupBy, tagDimensions)
+	if err != nil {
+		log.Printf("[ERROR] Invalid tag report parameters: %v", err)
+		return nil, err
+	}
+	filter, err := params.costParams().toFilter()
+	if err != nil {
+		log.Printf("[ERROR] Invalid tag report parameters: %v", err)
+		return nil, err
+	}
+	currency, err := reportCurrency(params.Currency)
+	if err != nil {
+		log.Printf("[ERROR] Invalid tag report parameters: %v", err)
+		return nil, err
+	}
+	byMonth := len(groupBy) > 0
+
+	// Теги, по которым строятся строки: из фильтра или все теги подписок
+	selected := map[string]bool{}
+	for _, tag := range filter.Tags {
+		selected[tag] = true
+	}
+
+	groups := spendGroups{}
+	var axis monthAxis
+	total := 0
+	err = s.streamCharges(ctx, filter, currency, func(sub model.Subscription, month time.Time, charge int) error {
+		axis.add(month)
+		total += charge
+
+		var key spendKey
+		if byMonth {
+			key.month = month
+		}
+		if len(sub.Tags) == 0 {
+			groups.add(key, sub.ID, charge)
+			return nil
+		}
+		for _, tag := range sub.Tags {
+			if len(selected) > 0 && !selected[tag] {
+				continue
+			}
+			key.tag = tag
+			groups.add(key, sub.ID, charge)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("[ERROR] Failed to build tag report: %v", err)
+		return nil, wrapRepoError(err)
+	}
+
+	report := &model.TagReport{GroupBy: groupBy, Currency: currency, Months: axis.months(filter), Total: total, Rows: make([]model.TagSpendRow, 0, len(groups))}
+	for _, key := range groups.sortedKeys() {
+		group := groups[key]
+		row := model.TagSpendRow{
+			Tag:           key.tag,
+			Spend:         group.spend,
+			Subscriptions: group.subscriptions,
+		}
+		if byMonth {
+			row.Month = key.month.Format(monthLayout)
+		}
+		report.Rows = append(report.Rows, row)
+	}
+
+	log.Printf("[SUCCESS] Built tag report: %d rows, total %d", len(report.Rows), report.Total)
+	return report, nil
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/repository"
+	"github.com/Headliner38/Subscription_Service/internal/utils"
+)
+
+// RestoreSubscription восстанавливает мягко удалённую подписку
+func (s *SubscriptionService) RestoreSubscription(ctx context.Context, id string) (*model.Subscription, error) {
+	log.Printf("[SERVICE] Restoring subscription with ID: %s", id)
+
+	if !utils.IsValidUUID(id) {
+		log.Printf("[ERROR] Subscription ID is not a valid UUID for restore: %s", id)
+		return nil, ErrNotFound
+	}
+
+	var restored *model.Subscription
+	err := s.Repo.WithTx(ctx, func(repo repository.SubscriptionRepository) error {
+		// Состояние до восстановления для журнала аудита
+		before, err := repo.GetSubscription(ctx, id, true)
+		if err != nil {
+			return err
+		}
+		sub, err := repo.RestoreSubscription(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Отличаем отсутствующую подписку от не удалённой
+			if _, getErr := repo.GetSubscription(ctx, id, false); getErr == nil {
+				return newConflictError("subscription is not deleted")
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		restored = sub
+		err = repo.AppendChange(ctx, &model.SubscriptionChange{
+			Type:           model.ChangeRestored,
+			SubscriptionID: id,
+			Subscription:   sub,
+		})
+		if err != nil {
+			return err
+		}
+		return s.auditChange(ctx, repo, model.AuditRestore, before, sub)
+	})
+	if err != nil {
+		log.Printf("[ERROR] Failed to restore subscription in DB: %v", err)
+		return nil, wrapRepoError(err)
+	}
+
+	log.Printf("[SUCCESS] Restored subscription with ID: %s", id)
+	return restored, nil
+}
+
+// PurgeDeleted окончательно удаляет подписки, мягко удалённые более retention назад
+func (s *SubscriptionService) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	olderThan := time.Now().Add(-retention)
+	log.Printf("[SERVICE] Purging subscriptions deleted before %s", olderThan.Format(time.RFC3339))
+
+	purged, err := s.Repo.PurgeDeleted(ctx, olderThan)
+	if err != nil {
+		log.Printf("[ERROR] Failed to purge deleted subscriptions: %v", err)
+		return 0, wrapRepoError(err)
+	}
+
+	log.Printf("[SUCCESS] Purged %d deleted subscriptions", purged)
+	return purged, nil
+}
+
+// RunPurgeJob раз в interval удаляет подписки, мягко удалённые более retention назад.
+// Блокируется до отмены ctx.
+func (s *SubscriptionService) RunPurgeJob(ctx context.Context, interval, retention time.Duration) {
+	log.Printf("[SERVICE] Purge job started: interval %s, retention %s", interval, retention)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.PurgeDeleted(ctx, retention); err != nil {
+			log.Printf("[ERROR] Purge job run failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Printf("[SERVICE] Purge job stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
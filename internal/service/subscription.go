@@ -1,10 +1,9 @@
 package service
 
 import (
-	"database/sql"
-	"errors"
+	"context"
 	"log"
-	"time"
+	"strings"
 
 	"github.com/Headliner38/Subscription_Service/internal/model"
 	"github.com/Headliner38/Subscription_Service/internal/repository"
@@ -12,51 +11,54 @@ import (
 )
 
 type SubscriptionService struct {
-	DB *sql.DB
+	Repo repository.SubscriptionRepository
+	// scope — область подписок вызывающего, см. WithScope
+	scope model.Scope
+	// actor — от чьего имени изменения записываются в журнал аудита, см. WithActor
+	actor model.Actor
 }
 
-func (s *SubscriptionService) CreateSubscription(
-	serviceName string,
-	price int,
-	userID string,
-	startDateStr string,
-	endDateStr *string,
-) (*model.Subscription, error) {
-	log.Printf("[SERVICE] Creating subscription for user: %s, service: %s, price: %d", userID, serviceName, price)
+// WithScope возвращает сервис, который видит и изменяет только подписки в пределах scope.
+// Ограничение применяет хранилище в самих запросах, поэтому чужая подписка не находится,
+// как будто её нет, а списки и суммы считаются только по своим.
+func (s *SubscriptionService) WithScope(scope model.Scope) *SubscriptionService {
+	return &SubscriptionService{Repo: s.Repo.WithScope(scope), scope: scope, actor: s.actor}
+}
 
-	// Валидация данных
-	if serviceName == "" {
-		log.Printf("[ERROR] Service name is required")
-		return nil, errors.New("service name is required")
+// scopeInput подставляет организацию области сервиса, если в данных подписки она не указана
+func (s *SubscriptionService) scopeInput(input *SubscriptionInput) {
+	if strings.TrimSpace(input.OrganizationID) == "" {
+		input.OrganizationID = s.scope.OrganizationID
 	}
-	if price <= 0 {
-		log.Printf("[ERROR] Price must be positive, got: %d", price)
-		return nil, errors.New("price must be positive")
+}
+
+// checkOwner не даёт создать подписку вне области сервиса или передать свою подписку другому пользователю
+func (s *SubscriptionService) checkOwner(sub model.Subscription) error {
+	if s.scope.OrganizationID != "" && !strings.EqualFold(sub.OrganizationID, s.scope.OrganizationID) {
+		return NewValidationError("organization_id", "organization_id must be the organization of the request")
 	}
-	if userID == "" {
-		log.Printf("[ERROR] User ID is required")
-		return nil, errors.New("user_id is required")
+	if s.scope.UserID != "" && !strings.EqualFold(sub.UserID, s.scope.UserID) {
+		return NewValidationError("user_id", "user_id must be the ID of the authenticated user")
 	}
+	return nil
+}
 
-	// Преобразование дат
-	startDate, err := time.Parse("01-2006", startDateStr)
-	if err != nil {
-		log.Printf("[ERROR] Invalid start_date format: %s", startDateStr)
-		return nil, errors.New("invalid start_date format, expected MM-YYYY")
-	}
+func (s *SubscriptionService) CreateSubscription(ctx context.Context, input SubscriptionInput) (*model.Subscription, error) {
+	log.Printf("[SERVICE] Creating subscription for user: %s, service: %s, price: %d %s", input.UserID, input.ServiceName, input.Price, input.Currency)
 
-	var endDate *time.Time
-	if endDateStr != nil && *endDateStr != "" {
-		t, err := time.Parse("01-2006", *endDateStr)
-		if err != nil {
-			log.Printf("[ERROR] Invalid end_date format: %s", *endDateStr)
-			return nil, errors.New("invalid end_date format, expected MM-YYYY")
-		}
-		endDate = &t
-		if endDate.Before(startDate) {
-			log.Printf("[ERROR] End date cannot be before start date: %s < %s", *endDateStr, startDateStr)
-			return nil, errors.New("end_date cannot be before start_date")
-		}
+	// Привязка к каталогу сервисов и валидация данных
+	if err := newServiceResolver(s.Repo).resolve(ctx, &input); err != nil {
+		log.Printf("[ERROR] Failed to resolve catalog service: %v", err)
+		return nil, wrapRepoError(err)
+	}
+	s.scopeInput(&input)
+	data, err := validateSubscriptionInput(input)
+	if err == nil {
+		err = s.checkOwner(data)
+	}
+	if err != nil {
+		log.Printf("[ERROR] Invalid subscription data: %v", err)
+		return nil, err
 	}
 
 	// Генерация UUID
@@ -64,171 +66,219 @@ func (s *SubscriptionService) CreateSubscription(
 	log.Printf("[SERVICE] Generated UUID: %s", id)
 
 	// Создание структуры подписки
-	sub := model.NewSubscription(id, serviceName, price, userID, startDate, endDate)
+	sub := model.NewSubscription(id, data)
 
-	// Вызов репозитория для сохранения в БД
-	err = repository.CreateSubscription(s.DB, sub.ID, sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate)
+	// Сохраняем подписку, событие ленты изменений и запись аудита в одной транзакции
+	err = s.Repo.WithTx(ctx, func(repo repository.SubscriptionRepository) error {
+		if err := repo.CreateSubscription(ctx, sub); err != nil {
+			return err
+		}
+		err := repo.AppendChange(ctx, &model.SubscriptionChange{
+			Type:           model.ChangeCreated,
+			SubscriptionID: sub.ID,
+			Subscription:   sub,
+		})
+		if err != nil {
+			return err
+		}
+		return s.auditChange(ctx, repo, model.AuditCreate, nil, sub)
+	})
 	if err != nil {
 		log.Printf("[ERROR] Failed to save subscription to DB: %v", err)
-		return nil, err
+		return nil, wrapRepoError(err)
 	}
 
 	log.Printf("[SUCCESS] Subscription created successfully with ID: %s", id)
 	return sub, nil
 }
 
-func (s *SubscriptionService) GetSubscription(id string) (*model.Subscription, error) {
+func (s *SubscriptionService) GetSubscription(ctx context.Context, id string, includeDeleted bool) (*model.Subscription, error) {
 	log.Printf("[SERVICE] Getting subscription with ID: %s", id)
 
 	if id == "" {
 		log.Printf("[ERROR] ID is required")
-		return nil, errors.New("id is required")
+		return nil, NewValidationError("id", "id is required")
+	}
+	if !utils.IsValidUUID(id) {
+		// Подписок с таким ID быть не может
+		log.Printf("[ERROR] Subscription ID is not a valid UUID: %s", id)
+		return nil, ErrNotFound
 	}
 
-	sub, err := repository.GetSubscription(s.DB, id)
+	sub, err := s.Repo.GetSubscription(ctx, id, includeDeleted)
 	if err != nil {
 		log.Printf("[ERROR] Failed to get subscription from DB: %v", err)
-		return nil, err
+		return nil, wrapRepoError(err)
 	}
 
 	log.Printf("[SUCCESS] Retrieved subscription with ID: %s", id)
 	return sub, nil
 }
 
-func (s *SubscriptionService) UpdateSubscription(
-	id string,
-	serviceName string,
-	price int,
-	userID string,
-	startDateStr string,
-	endDateStr *string,
-) (*model.Subscription, error) {
+// UpdateSubscription полностью заменяет данные подписки; version больше нуля требует совпадения версии
+func (s *SubscriptionService) UpdateSubscription(ctx context.Context, id string, input SubscriptionInput, version int64) (*model.Subscription, error) {
 	log.Printf("[SERVICE] Updating subscription with ID: %s", id)
 
 	// Валидация
 	if id == "" {
 		log.Printf("[ERROR] ID is required for update")
-		return nil, errors.New("id is required")
+		return nil, NewValidationError("id", "id is required")
 	}
-	if serviceName == "" {
-		log.Printf("[ERROR] Service name is required for update")
-		return nil, errors.New("service name is required")
+	if !utils.IsValidUUID(id) {
+		log.Printf("[ERROR] Subscription ID is not a valid UUID for update: %s", id)
+		return nil, ErrNotFound
 	}
-	if price <= 0 {
-		log.Printf("[ERROR] Price must be positive for update, got: %d", price)
-		return nil, errors.New("price must be positive")
+	if err := newServiceResolver(s.Repo).resolve(ctx, &input); err != nil {
+		log.Printf("[ERROR] Failed to resolve catalog service for update: %v", err)
+		return nil, wrapRepoError(err)
 	}
-	if userID == "" {
-		log.Printf("[ERROR] User ID is required for update")
-		return nil, errors.New("user_id is required")
+	// Организация подписки при обновлении не меняется: хранилище её не перезаписывает
+	s.scopeInput(&input)
+	updated, err := validateSubscriptionInput(input)
+	if err == nil {
+		err = s.checkOwner(updated)
 	}
-
-	// Преобразование дат
-	startDate, err := time.Parse("01-2006", startDateStr)
 	if err != nil {
-		log.Printf("[ERROR] Invalid start_date format for update: %s", startDateStr)
-		return nil, errors.New("invalid start_date format, expected MM-YYYY")
+		log.Printf("[ERROR] Invalid subscription data for update: %v", err)
+		return nil, err
 	}
 
-	var endDate *time.Time
-	if endDateStr != nil && *endDateStr != "" {
-		t, err := time.Parse("01-2006", *endDateStr)
+	// Обновление в БД вместе с событием ленты изменений и записью аудита
+	updated.ID = id
+	updated.Version = version
+	var sub *model.Subscription
+	err = s.Repo.WithTx(ctx, func(repo repository.SubscriptionRepository) error {
+		// Состояние до изменения для журнала аудита
+		before, err := repo.GetSubscription(ctx, id, false)
 		if err != nil {
-			log.Printf("[ERROR] Invalid end_date format for update: %s", *endDateStr)
-			return nil, errors.New("invalid end_date format, expected MM-YYYY")
+			return err
 		}
-		endDate = &t
-		if endDate.Before(startDate) {
-			log.Printf("[ERROR] End date cannot be before start date for update: %s < %s", *endDateStr, startDateStr)
-			return nil, errors.New("end_date cannot be before start_date")
+		if err := repo.UpdateSubscription(ctx, &updated); err != nil {
+			return versionMismatch(ctx, repo, id, version, err)
 		}
-	}
-
-	// Обновление в БД
-	err = repository.UpdateSubscription(s.DB, id, serviceName, price, userID, startDate, endDate)
+		// Перечитываем подписку, чтобы в событие и ответ попали статус и метки времени из БД
+		sub, err = repo.GetSubscription(ctx, id, false)
+		if err != nil {
+			return err
+		}
+		err = repo.AppendChange(ctx, &model.SubscriptionChange{
+			Type:           model.ChangeUpdated,
+			SubscriptionID: id,
+			Subscription:   sub,
+		})
+		if err != nil {
+			return err
+		}
+		return s.auditChange(ctx, repo, model.AuditUpdate, before, sub)
+	})
 	if err != nil {
 		log.Printf("[ERROR] Failed to update subscription in DB: %v", err)
-		return nil, err
-	}
-
-	// Возвращаем обновлённую подписку
-	sub, err := s.GetSubscription(id)
-	if err != nil {
-		log.Printf("[ERROR] Failed to get updated subscription: %v", err)
-		return nil, err
+		return nil, wrapRepoError(err)
 	}
 
 	log.Printf("[SUCCESS] Updated subscription with ID: %s", id)
 	return sub, nil
 }
 
-func (s *SubscriptionService) DeleteSubscription(id string) error {
+// DeleteSubscription мягко удаляет подписку; version больше нуля требует совпадения версии
+func (s *SubscriptionService) DeleteSubscription(ctx context.Context, id string, version int64) error {
 	log.Printf("[SERVICE] Deleting subscription with ID: %s", id)
 
 	if id == "" {
 		log.Printf("[ERROR] ID is required for deletion")
-		return errors.New("id is required")
+		return NewValidationError("id", "id is required")
+	}
+	if !utils.IsValidUUID(id) {
+		log.Printf("[ERROR] Subscription ID is not a valid UUID for deletion: %s", id)
+		return ErrNotFound
 	}
 
-	err := repository.DeleteSubscription(s.DB, id)
+	// Удаляем подписку, записываем tombstone в ленту изменений и запись в журнал аудита
+	err := s.Repo.WithTx(ctx, func(repo repository.SubscriptionRepository) error {
+		before, err := repo.GetSubscription(ctx, id, false)
+		if err != nil {
+			return err
+		}
+		if err := repo.DeleteSubscription(ctx, id, version); err != nil {
+			return versionMismatch(ctx, repo, id, version, err)
+		}
+		err = repo.AppendChange(ctx, &model.SubscriptionChange{
+			Type:           model.ChangeDeleted,
+			SubscriptionID: id,
+		})
+		if err != nil {
+			return err
+		}
+		after, err := repo.GetSubscription(ctx, id, true)
+		if err != nil {
+			return err
+		}
+		return s.auditChange(ctx, repo, model.AuditDelete, before, after)
+	})
 	if err != nil {
 		log.Printf("[ERROR] Failed to delete subscription from DB: %v", err)
-		return err
+		return wrapRepoError(err)
 	}
 
 	log.Printf("[SUCCESS] Deleted subscription with ID: %s", id)
 	return nil
 }
 
-func (s *SubscriptionService) ListSubscriptions() ([]model.Subscription, error) {
-	log.Printf("[SERVICE] Listing all subscriptions")
+func (s *SubscriptionService) ListSubscriptions(ctx context.Context, params ListSubscriptionsParams) (*model.SubscriptionPage, error) {
+	log.Printf("[SERVICE] Listing subscriptions with params: %+v", params)
 
-	subscriptions, err := repository.ListSubscriptions(s.DB)
+	filter, err := params.toFilter()
 	if err != nil {
-		log.Printf("[ERROR] Failed to list subscriptions from DB: %v", err)
+		log.Printf("[ERROR] Invalid list parameters: %v", err)
 		return nil, err
 	}
 
-	log.Printf("[SUCCESS] Retrieved %d subscriptions", len(subscriptions))
-	return subscriptions, nil
-}
+	subscriptions, err := s.Repo.ListSubscriptions(ctx, filter)
+	if err != nil {
+		log.Printf("[ERROR] Failed to list subscriptions from DB: %v", err)
+		return nil, wrapRepoError(err)
+	}
 
-func (s *SubscriptionService) CalculateTotalCost(userID, serviceName, startDateStr, endDateStr string) (int, error) {
-	log.Printf("[SERVICE] Calculating total cost for user: %s, service: %s, period: %s - %s", userID, serviceName, startDateStr, endDateStr)
+	page := &model.SubscriptionPage{Items: subscriptions}
+	if page.Items == nil {
+		page.Items = []model.Subscription{}
+	}
+	// Репозиторий возвращает на одну строку больше лимита, если есть следующая страница
+	if len(page.Items) > filter.Limit {
+		page.Items = page.Items[:filter.Limit]
+		page.NextCursor = encodeCursor(filter, page.Items[len(page.Items)-1])
+	}
 
-	// Преобразование дат
-	var startDate, endDate time.Time
-	var err error
+	log.Printf("[SUCCESS] Retrieved %d subscriptions", len(page.Items))
+	return page, nil
+}
 
-	if startDateStr != "" {
-		startDate, err = time.Parse("01-2006", startDateStr)
-		if err != nil {
-			log.Printf("[ERROR] Invalid start_date format for total cost: %s", startDateStr)
-			return 0, errors.New("invalid start_date format, expected MM-YYYY")
-		}
-	}
+func (s *SubscriptionService) CalculateTotalCost(ctx context.Context, params TotalCostParams) (*model.TotalCost, error) {
+	log.Printf("[SERVICE] Calculating total cost for user: %s, service: %s, period: %s - %s", params.UserID, params.ServiceName, params.StartDate, params.EndDate)
 
-	if endDateStr != "" {
-		endDate, err = time.Parse("01-2006", endDateStr)
-		if err != nil {
-			log.Printf("[ERROR] Invalid end_date format for total cost: %s", endDateStr)
-			return 0, errors.New("invalid end_date format, expected MM-YYYY")
-		}
+	filter, err := params.toFilter()
+	if err != nil {
+		log.Printf("[ERROR] Invalid total cost parameters: %v", err)
+		return nil, err
 	}
-
-	// Проверка логики дат
-	if startDateStr != "" && endDateStr != "" && endDate.Before(startDate) {
-		log.Printf("[ERROR] End date cannot be before start date for total cost: %s < %s", endDateStr, startDateStr)
-		return 0, errors.New("end_date cannot be before start_date")
+	currency, err := reportCurrency(params.Currency)
+	if err != nil {
+		log.Printf("[ERROR] Invalid total cost parameters: %v", err)
+		return nil, err
 	}
 
-	// Вызов репозитория для подсчёта
-	totalCost, err := repository.CalculateTotalCost(s.DB, userID, serviceName, startDate, endDate)
+	// Считаем стоимость каждой подписки, пересекающейся с периодом, по оплачиваемым месяцам
+	result := &model.TotalCost{Currency: currency, Items: []model.SubscriptionCost{}}
+	err = s.streamCosts(ctx, filter, currency, func(item model.SubscriptionCost) error {
+		result.TotalCost += item.Cost
+		result.Items = append(result.Items, item)
+		return nil
+	})
 	if err != nil {
-		log.Printf("[ERROR] Failed to calculate total cost in DB: %v", err)
-		return 0, err
+		log.Printf("[ERROR] Failed to calculate total cost: %v", err)
+		return nil, wrapRepoError(err)
 	}
 
-	log.Printf("[SUCCESS] Calculated total cost: %d", totalCost)
-	return totalCost, nil
+	log.Printf("[SUCCESS] Calculated total cost: %d %s over %d subscriptions", result.TotalCost, currency, len(result.Items))
+	return result, nil
 }
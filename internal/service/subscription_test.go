@@ -0,0 +1,402 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/repository"
+)
+
+const (
+	testUserID  = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+	otherUserID = "550e8400-e29b-41d4-a716-446655440000"
+	missingID   = "11111111-2222-3333-4444-555555555555"
+)
+
+func TestMain(m *testing.M) {
+	// Сервис подробно логирует каждую операцию; в тестах это только шум
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// newTestService создаёт сервис поверх пустого хранилища в памяти
+func newTestService() *SubscriptionService {
+	return &SubscriptionService{Repo: repository.NewMemoryRepository()}
+}
+
+// validInput возвращает корректные данные подписки: 100 ₽ в месяц с января по декабрь 2024
+func validInput() SubscriptionInput {
+	end := "12-2024"
+	return SubscriptionInput{
+		ServiceName: "Netflix",
+		Price:       10000,
+		UserID:      testUserID,
+		StartDate:   "01-2024",
+		EndDate:     &end,
+	}
+}
+
+// validationField возвращает поле первой ошибки валидации или пустую строку
+func validationField(err error) string {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) && len(validationErr.Fields) > 0 {
+		return validationErr.Fields[0].Field
+	}
+	return ""
+}
+
+func TestCreateSubscriptionValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(in *SubscriptionInput)
+		field  string
+	}{
+		{"missing service name", func(in *SubscriptionInput) { in.ServiceName = "" }, "service_name"},
+		{"zero price", func(in *SubscriptionInput) { in.Price = 0 }, "price"},
+		{"negative price", func(in *SubscriptionInput) { in.Price = -1 }, "price"},
+		{"unknown currency", func(in *SubscriptionInput) { in.Currency = "XYZ" }, "currency"},
+		{"unknown billing period", func(in *SubscriptionInput) { in.BillingPeriod = "daily" }, "billing_period"},
+		{"negative interval", func(in *SubscriptionInput) { in.IntervalCount = -2 }, "interval_count"},
+		{"tag with comma", func(in *SubscriptionInput) { in.Tags = []string{"a,b"} }, "tags"},
+		{"missing user", func(in *SubscriptionInput) { in.UserID = "" }, "user_id"},
+		{"user is not a UUID", func(in *SubscriptionInput) { in.UserID = "alice" }, "user_id"},
+		{"organization is not a UUID", func(in *SubscriptionInput) { in.OrganizationID = "acme" }, "organization_id"},
+		{"bad start date", func(in *SubscriptionInput) { in.StartDate = "2024/01" }, "start_date"},
+		{"end before start", func(in *SubscriptionInput) { end := "12-2023"; in.EndDate = &end }, "end_date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService()
+			in := validInput()
+			tt.modify(&in)
+
+			_, err := svc.CreateSubscription(context.Background(), in)
+			if !errors.Is(err, ErrValidation) {
+				t.Fatalf("expected validation error, got %v", err)
+			}
+			if field := validationField(err); field != tt.field {
+				t.Errorf("expected error for field %q, got %q (%v)", tt.field, field, err)
+			}
+		})
+	}
+}
+
+func TestCreateSubscriptionDefaults(t *testing.T) {
+	svc := newTestService()
+	in := validInput()
+	in.Currency = "usd"
+	in.Tags = []string{" Work", "family", "work"}
+
+	sub, err := svc.CreateSubscription(context.Background(), in)
+	if err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+	if sub.Currency != "USD" || sub.BillingPeriod != "monthly" || sub.IntervalCount != 1 {
+		t.Errorf("unexpected defaults: currency %s, period %s, interval %d", sub.Currency, sub.BillingPeriod, sub.IntervalCount)
+	}
+	if len(sub.Tags) != 2 || sub.Tags[0] != "family" || sub.Tags[1] != "work" {
+		t.Errorf("expected normalized tags [family work], got %v", sub.Tags)
+	}
+	if sub.EndDate == nil || sub.EndDate.Format(dateLayout) != "2024-12-31" {
+		t.Errorf("expected end_date 2024-12-31, got %v", sub.EndDate)
+	}
+
+	got, err := svc.GetSubscription(context.Background(), sub.ID, false)
+	if err != nil {
+		t.Fatalf("GetSubscription: %v", err)
+	}
+	if got.ServiceName != "Netflix" || got.Price != 10000 {
+		t.Errorf("stored subscription differs: %+v", got)
+	}
+}
+
+func TestGetSubscriptionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want error
+	}{
+		{"empty id", "", ErrValidation},
+		{"id is not a UUID", "not-a-uuid", ErrNotFound},
+		{"unknown id", missingID, ErrNotFound},
+	}
+
+	svc := newTestService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.GetSubscription(context.Background(), tt.id, false)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestUpdateSubscription(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService()
+	sub, err := svc.CreateSubscription(ctx, validInput())
+	if err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+
+	update := validInput()
+	update.Price = 25000
+
+	tests := []struct {
+		name    string
+		id      string
+		input   SubscriptionInput
+		version int64
+		want    error
+	}{
+		{"unknown id", missingID, update, 0, ErrNotFound},
+		{"invalid data", sub.ID, SubscriptionInput{UserID: testUserID, StartDate: "01-2024"}, 0, ErrValidation},
+		{"stale version", sub.ID, update, sub.Version + 5, ErrPreconditionFailed},
+		{"matching version", sub.ID, update, sub.Version, nil},
+		{"any version", sub.ID, update, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, err := svc.UpdateSubscription(ctx, tt.id, tt.input, tt.version)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+			if err == nil && updated.Price != 25000 {
+				t.Errorf("expected price 25000, got %d", updated.Price)
+			}
+		})
+	}
+
+	// При несовпадении версии ошибка несёт актуальное состояние подписки
+	_, err = svc.UpdateSubscription(ctx, sub.ID, update, 1)
+	var precondition *PreconditionFailedError
+	if !errors.As(err, &precondition) || precondition.Current == nil || precondition.Current.Price != 25000 {
+		t.Errorf("expected PreconditionFailedError with current state, got %v", err)
+	}
+}
+
+func TestDeleteAndRestoreSubscription(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService()
+	sub, err := svc.CreateSubscription(ctx, validInput())
+	if err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+
+	if _, err := svc.RestoreSubscription(ctx, sub.ID); !errors.Is(err, ErrConflict) {
+		t.Errorf("restore of active subscription: expected conflict, got %v", err)
+	}
+	if err := svc.DeleteSubscription(ctx, sub.ID, 0); err != nil {
+		t.Fatalf("DeleteSubscription: %v", err)
+	}
+	if err := svc.DeleteSubscription(ctx, sub.ID, 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second delete: expected not found, got %v", err)
+	}
+	if _, err := svc.GetSubscription(ctx, sub.ID, false); !errors.Is(err, ErrNotFound) {
+		t.Errorf("get deleted: expected not found, got %v", err)
+	}
+	if _, err := svc.GetSubscription(ctx, sub.ID, true); err != nil {
+		t.Errorf("get deleted with includeDeleted: %v", err)
+	}
+	if _, err := svc.RestoreSubscription(ctx, sub.ID); err != nil {
+		t.Fatalf("RestoreSubscription: %v", err)
+	}
+	if _, err := svc.GetSubscription(ctx, sub.ID, false); err != nil {
+		t.Errorf("get restored: %v", err)
+	}
+	if _, err := svc.RestoreSubscription(ctx, missingID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("restore unknown: expected not found, got %v", err)
+	}
+}
+
+func TestLifecycleTransitions(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService()
+	// Бессрочная подписка: подписка с прошедшим end_date уже истекла
+	in := validInput()
+	in.EndDate = nil
+	sub, err := svc.CreateSubscription(ctx, in)
+	if err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+
+	steps := []struct {
+		name   string
+		apply  func(context.Context, string) (*model.Subscription, error)
+		want   error
+		status string
+	}{
+		{"resume active", svc.ResumeSubscription, ErrConflict, ""},
+		{"pause active", svc.PauseSubscription, nil, model.StatusPaused},
+		{"pause paused", svc.PauseSubscription, ErrConflict, ""},
+		{"resume paused", svc.ResumeSubscription, nil, model.StatusActive},
+		{"cancel active", svc.CancelSubscription, nil, model.StatusCancelled},
+		{"resume cancelled", svc.ResumeSubscription, ErrConflict, ""},
+	}
+	for _, step := range steps {
+		result, err := step.apply(ctx, sub.ID)
+		if !errors.Is(err, step.want) {
+			t.Fatalf("%s: expected %v, got %v", step.name, step.want, err)
+		}
+		if step.status != "" {
+			if result.Status != step.status {
+				t.Errorf("%s: expected status %s, got %s", step.name, step.status, result.Status)
+			}
+		}
+	}
+
+	if _, err := svc.PauseSubscription(ctx, missingID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("pause unknown: expected not found, got %v", err)
+	}
+}
+
+func TestCreateServiceConflict(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService()
+	if _, err := svc.CreateService(ctx, ServiceInput{Name: "Netflix", Aliases: []string{"NFLX"}}); err != nil {
+		t.Fatalf("CreateService: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		input ServiceInput
+		want  error
+	}{
+		{"same name", ServiceInput{Name: "Netflix"}, ErrConflict},
+		{"name used as alias", ServiceInput{Name: "nflx"}, ErrConflict},
+		{"missing name", ServiceInput{}, ErrValidation},
+		{"new service", ServiceInput{Name: "Spotify"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.CreateService(ctx, tt.input); !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestCalculateTotalCost(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService()
+
+	inputs := []SubscriptionInput{validInput()}
+	// 300 ₽ в квартал у другого пользователя, без даты окончания
+	other := validInput()
+	other.ServiceName = "Spotify"
+	other.Price = 30000
+	other.BillingPeriod = "quarterly"
+	other.UserID = otherUserID
+	other.EndDate = nil
+	inputs = append(inputs, other)
+	for _, in := range inputs {
+		if _, err := svc.CreateSubscription(ctx, in); err != nil {
+			t.Fatalf("CreateSubscription: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		params TotalCostParams
+		total  int
+		items  int
+	}{
+		{"one user, one quarter", TotalCostParams{UserID: testUserID, StartDate: "01-2024", EndDate: "03-2024"}, 30000, 1},
+		{"whole year of one user", TotalCostParams{UserID: testUserID, StartDate: "01-2024", EndDate: "12-2024"}, 120000, 1},
+		{"after end date", TotalCostParams{UserID: testUserID, StartDate: "01-2025", EndDate: "06-2025"}, 0, 0},
+		{"all users, one quarter", TotalCostParams{StartDate: "01-2024", EndDate: "03-2024"}, 60000, 2},
+		{"by service name", TotalCostParams{ServiceName: "Spotify", StartDate: "01-2024", EndDate: "06-2024"}, 60000, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := svc.CalculateTotalCost(ctx, tt.params)
+			if err != nil {
+				t.Fatalf("CalculateTotalCost: %v", err)
+			}
+			if result.TotalCost != tt.total || len(result.Items) != tt.items {
+				t.Errorf("expected %d over %d items, got %d over %d items", tt.total, tt.items, result.TotalCost, len(result.Items))
+			}
+			if result.Currency != "RUB" {
+				t.Errorf("expected currency RUB, got %s", result.Currency)
+			}
+		})
+	}
+}
+
+func TestCalculateTotalCostValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		params TotalCostParams
+		field  string
+	}{
+		{"user is not a UUID", TotalCostParams{UserID: "alice"}, "user_id"},
+		{"service is not a UUID", TotalCostParams{ServiceID: "netflix"}, "service_id"},
+		{"bad start date", TotalCostParams{StartDate: "13-2024"}, "start_date"},
+		{"end before start", TotalCostParams{StartDate: "06-2024", EndDate: "01-2024"}, "end_date"},
+		{"unknown currency", TotalCostParams{Currency: "XYZ"}, "currency"},
+	}
+
+	svc := newTestService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.CalculateTotalCost(context.Background(), tt.params)
+			if field := validationField(err); field != tt.field {
+				t.Errorf("expected validation error for %q, got %v", tt.field, err)
+			}
+		})
+	}
+}
+
+func TestListSubscriptionsPagination(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestService()
+	for i := 0; i < 3; i++ {
+		if _, err := svc.CreateSubscription(ctx, validInput()); err != nil {
+			t.Fatalf("CreateSubscription: %v", err)
+		}
+	}
+
+	first, err := svc.ListSubscriptions(ctx, ListSubscriptionsParams{Limit: "2"})
+	if err != nil {
+		t.Fatalf("ListSubscriptions: %v", err)
+	}
+	if len(first.Items) != 2 || first.NextCursor == "" {
+		t.Fatalf("expected 2 items and a cursor, got %d items, cursor %q", len(first.Items), first.NextCursor)
+	}
+	second, err := svc.ListSubscriptions(ctx, ListSubscriptionsParams{Limit: "2", Cursor: first.NextCursor})
+	if err != nil {
+		t.Fatalf("ListSubscriptions with cursor: %v", err)
+	}
+	if len(second.Items) != 1 || second.NextCursor != "" {
+		t.Errorf("expected the last item without cursor, got %d items, cursor %q", len(second.Items), second.NextCursor)
+	}
+
+	tampered := base64.RawURLEncoding.EncodeToString([]byte(`{"s":"start_date","o":"asc","v":"2024-01-01T00:00:00Z","id":"x' OR 1=1"}`))
+	tests := []struct {
+		name   string
+		params ListSubscriptionsParams
+		field  string
+	}{
+		{"limit too large", ListSubscriptionsParams{Limit: "501"}, "limit"},
+		{"unknown sort", ListSubscriptionsParams{Sort: "colour"}, "sort"},
+		{"cursor is not base64", ListSubscriptionsParams{Cursor: "***"}, "cursor"},
+		{"cursor id is not a UUID", ListSubscriptionsParams{Cursor: tampered}, "cursor"},
+		{"cursor of another sort", ListSubscriptionsParams{Sort: "price", Cursor: first.NextCursor}, "cursor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.ListSubscriptions(ctx, tt.params)
+			if field := validationField(err); field != tt.field {
+				t.Errorf("expected validation error for %q, got %v", tt.field, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Headliner38/Subscription_Service/internal/model"
+	"github.com/Headliner38/Subscription_Service/internal/money"
+	"github.com/Headliner38/Subscription_Service/internal/utils"
+)
+
+// normalizeCurrency приводит код валюты к верхнему регистру; пустой код означает валюту по умолчанию
+func normalizeCurrency(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return money.DefaultCurrency
+	}
+	return code
+}
+
+// reportCurrency проверяет валюту, в которую пересчитываются суммы отчёта; по умолчанию — рубли
+func reportCurrency(code string) (string, error) {
+	currency := normalizeCurrency(code)
+	if !money.IsValidCurrency(currency) {
+		return "", NewValidationError("currency", "currency must be an ISO 4217 code")
+	}
+	return currency, nil
+}
+
+// Ограничения категории и тегов подписки
+const (
+	maxCategoryLength = 64
+	maxTagLength      = 64
+	maxTags           = 20
+)
+
+// normalizeCategory приводит категорию к нижнему регистру без пробелов по краям
+func normalizeCategory(category string) string {
+	return strings.ToLower(strings.TrimSpace(category))
+}
+
+// normalizeTags приводит теги к нижнему регистру, убирает пустые и повторы и упорядочивает их.
+// Запятая в теге запрещена: в фильтрах и при импорте теги перечисляются через запятую.
+func normalizeTags(field string, tags []string) ([]string, error) {
+	seen := map[string]bool{}
+	result := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > maxTagLength {
+			return nil, NewValidationError(field, fmt.Sprintf("tag %q must be at most %d characters", tag, maxTagLength))
+		}
+		if strings.Contains(tag, ",") {
+			return nil, NewValidationError(field, fmt.Sprintf("tag %q must not contain commas", tag))
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	if len(result) > maxTags {
+		return nil, NewValidationError(field, fmt.Sprintf("at most %d tags are allowed", maxTags))
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// parseTagFilter разбирает фильтр по тегам: список через запятую и режим tags_match
+// (any — хотя бы один из тегов, all — все теги; по умолчанию any)
+func parseTagFilter(tags, match string) ([]string, bool, error) {
+	var list []string
+	if tags != "" {
+		var err error
+		list, err = normalizeTags("tags", strings.Split(tags, ","))
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	switch strings.ToLower(match) {
+	case "", "any":
+		return list, false, nil
+	case "all":
+		return list, true, nil
+	default:
+		return nil, false, NewValidationError("tags_match", "tags_match must be one of: any, all")
+	}
+}
+
+// parseDate разбирает дату в формате MM-YYYY или YYYY-MM-DD. Месяц без дня означает
+// его первое число, а при endOfMonth — последнее, чтобы дата окончания включала весь месяц.
+func parseDate(field, value string, endOfMonth bool) (time.Time, error) {
+	if t, err := time.Parse(dateLayout, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(monthLayout, value)
+	if err != nil {
+		return time.Time{}, NewValidationError(field, fmt.Sprintf("invalid %s format, expected MM-YYYY or YYYY-MM-DD", field))
+	}
+	if endOfMonth {
+		t = t.AddDate(0, 1, -1)
+	}
+	return t, nil
+}
+
+// SubscriptionInput — данные подписки в том виде, в котором они пришли в запросе
+type SubscriptionInput struct {
+	// ServiceID — сервис из каталога; если не задан, сервис ищется по ServiceName
+	ServiceID   string
+	ServiceName string
+	Price       int
+	Currency    string
+	// BillingPeriod — период оплаты; по умолчанию monthly
+	BillingPeriod string
+	// IntervalCount — через сколько периодов повторяется списание; по умолчанию 1
+	IntervalCount int
+	// Category — категория подписки; если не задана, берётся категория сервиса каталога
+	Category string
+	Tags     []string
+	UserID   string
+	// OrganizationID — организация подписки; по умолчанию организация запроса или
+	// model.DefaultOrganizationID. Учитывается только при создании.
+	OrganizationID string
+	StartDate      string
+	EndDate        *string
+}
+
+// validateSubscriptionInput проверяет данные подписки из запроса, подставляет значения
+// по умолчанию и разбирает даты MM-YYYY или YYYY-MM-DD. Сервис каталога к этому моменту
+// уже должен быть найден serviceResolver. Одни и те же правила применяются
+// при создании, обновлении, пакетных операциях и импорте.
+func validateSubscriptionInput(in SubscriptionInput) (model.Subscription, error) {
+	sub := model.Subscription{
+		ServiceName:    in.ServiceName,
+		Price:          in.Price,
+		Currency:       normalizeCurrency(in.Currency),
+		BillingPeriod:  strings.ToLower(strings.TrimSpace(in.BillingPeriod)),
+		IntervalCount:  in.IntervalCount,
+		Category:       normalizeCategory(in.Category),
+		UserID:         in.UserID,
+		OrganizationID: strings.ToLower(strings.TrimSpace(in.OrganizationID)),
+	}
+	if in.ServiceID != "" {
+		serviceID := in.ServiceID
+		sub.ServiceID = &serviceID
+	}
+	if sub.BillingPeriod == "" {
+		sub.BillingPeriod = model.PeriodMonthly
+	}
+	if sub.IntervalCount == 0 {
+		sub.IntervalCount = 1
+	}
+
+	if sub.ServiceName == "" {
+		return sub, NewValidationError("service_name", "service name is required")
+	}
+	if sub.Price <= 0 {
+		return sub, NewValidationError("price", "price must be positive")
+	}
+	if !money.IsValidCurrency(sub.Currency) {
+		return sub, NewValidationError("currency", "currency must be an ISO 4217 code")
+	}
+	switch sub.BillingPeriod {
+	case model.PeriodWeekly, model.PeriodMonthly, model.PeriodQuarterly, model.PeriodYearly:
+	default:
+		return sub, NewValidationError("billing_period", "billing_period must be one of: weekly, monthly, quarterly, yearly")
+	}
+	if sub.IntervalCount < 1 {
+		return sub, NewValidationError("interval_count", "interval_count must be positive")
+	}
+	if len(sub.Category) > maxCategoryLength {
+		return sub, NewValidationError("category", fmt.Sprintf("category must be at most %d characters", maxCategoryLength))
+	}
+	tags, err := normalizeTags("tags", in.Tags)
+	if err != nil {
+		return sub, err
+	}
+	sub.Tags = tags
+	if sub.UserID == "" {
+		return sub, NewValidationError("user_id", "user_id is required")
+	}
+	if !utils.IsValidUUID(sub.UserID) {
+		return sub, NewValidationError("user_id", "user_id must be a valid UUID")
+	}
+	if sub.OrganizationID == "" {
+		sub.OrganizationID = model.DefaultOrganizationID
+	}
+	if !utils.IsValidUUID(sub.OrganizationID) {
+		return sub, NewValidationError("organization_id", "organization_id must be a valid UUID")
+	}
+
+	// Преобразование дат: end_date в формате MM-YYYY включает весь месяц
+	sub.StartDate, err = parseDate("start_date", in.StartDate, false)
+	if err != nil {
+		return sub, err
+	}
+	if in.EndDate != nil && *in.EndDate != "" {
+		t, err := parseDate("end_date", *in.EndDate, true)
+		if err != nil {
+			return sub, err
+		}
+		if t.Before(sub.StartDate) {
+			return sub, NewValidationError("end_date", "end_date cannot be before start_date")
+		}
+		sub.EndDate = &t
+	}
+
+	return sub, nil
+}
@@ -5,3 +5,9 @@ import "github.com/google/uuid"
 func GenerateUUID() string {
 	return uuid.New().String()
 }
+
+// IsValidUUID проверяет, что строка является корректным UUID
+func IsValidUUID(s string) bool {
+	_, err := uuid.Parse(s)
+	return err == nil
+}
@@ -0,0 +1,299 @@
+// Package xlsx читает и пишет таблицы Office Open XML (.xlsx) в минимальном объёме,
+// нужном для импорта и экспорта подписок: первый лист, значения ячеек как строки.
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ErrNoSheets — в книге нет ни одного листа
+var ErrNoSheets = errors.New("xlsx: workbook has no sheets")
+
+// Размеры листа Excel: строки 1–1048576, столбцы A–XFD
+const (
+	MaxRows    = 1048576
+	MaxColumns = 16384
+)
+
+// Reader построчно читает первый лист книги, не загружая его целиком в память.
+// Пропущенные в файле пустые строки возвращаются как пустые записи, чтобы номера строк совпадали с Excel.
+type Reader struct {
+	sheet   io.ReadCloser
+	decoder *xml.Decoder
+	shared  []string
+	// nextRow — номер строки (с 1), которую вернёт следующий Read
+	nextRow int
+	// pending — прочитанная строка, до которой ещё возвращаются пустые записи
+	pending    []string
+	pendingRow int
+}
+
+// NewReader открывает книгу размером size из r
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("xlsx: %w", err)
+	}
+
+	sheetPath, err := firstSheetPath(archive)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := readSharedStrings(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	sheet, err := archive.Open(sheetPath)
+	if err != nil {
+		return nil, fmt.Errorf("xlsx: open %s: %w", sheetPath, err)
+	}
+
+	return &Reader{
+		sheet:   sheet,
+		decoder: xml.NewDecoder(sheet),
+		shared:  shared,
+		nextRow: 1,
+	}, nil
+}
+
+// Close освобождает лист
+func (r *Reader) Close() error {
+	return r.sheet.Close()
+}
+
+// Read возвращает значения ячеек следующей строки; в конце листа — io.EOF
+func (r *Reader) Read() ([]string, error) {
+	if r.pending == nil {
+		row, number, err := r.readRow()
+		if err != nil {
+			return nil, err
+		}
+		r.pending, r.pendingRow = row, number
+	}
+
+	if r.nextRow < r.pendingRow {
+		r.nextRow++
+		return []string{}, nil
+	}
+
+	row := r.pending
+	r.pending = nil
+	r.nextRow++
+	return row, nil
+}
+
+// readRow читает очередной элемент <row> и возвращает его ячейки и номер строки
+func (r *Reader) readRow() ([]string, int, error) {
+	for {
+		tok, err := r.decoder.Token()
+		if err != nil {
+			return nil, 0, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		var row xmlRow
+		if err := r.decoder.DecodeElement(&row, &start); err != nil {
+			return nil, 0, fmt.Errorf("xlsx: %w", err)
+		}
+
+		number := r.nextRow
+		if row.R != "" {
+			if n, err := strconv.Atoi(row.R); err == nil && n >= r.nextRow {
+				number = n
+			}
+		}
+		// Иначе ссылка на далёкую строку заставила бы вернуть миллиарды пустых записей
+		if number > MaxRows {
+			return nil, 0, fmt.Errorf("xlsx: row %d is beyond the last row %d", number, MaxRows)
+		}
+
+		var cells []string
+		for i, c := range row.Cells {
+			col := i
+			if c.R != "" {
+				idx, ok, err := columnIndex(c.R)
+				if err != nil {
+					return nil, 0, err
+				}
+				if ok {
+					col = idx
+				}
+			}
+			if col >= MaxColumns {
+				return nil, 0, fmt.Errorf("xlsx: row %d has more than %d columns", number, MaxColumns)
+			}
+			for len(cells) < col {
+				cells = append(cells, "")
+			}
+			value, err := r.cellValue(c)
+			if err != nil {
+				return nil, 0, err
+			}
+			if col < len(cells) {
+				cells[col] = value
+			} else {
+				cells = append(cells, value)
+			}
+		}
+		if cells == nil {
+			cells = []string{}
+		}
+		return cells, number, nil
+	}
+}
+
+// cellValue возвращает текстовое значение ячейки с учётом её типа
+func (r *Reader) cellValue(c xmlCell) (string, error) {
+	switch c.T {
+	case "s":
+		idx, err := strconv.Atoi(strings.TrimSpace(c.V))
+		if err != nil || idx < 0 || idx >= len(r.shared) {
+			return "", fmt.Errorf("xlsx: cell %s refers to unknown shared string %q", c.R, c.V)
+		}
+		return r.shared[idx], nil
+	case "inlineStr":
+		return c.IS.text(), nil
+	case "b":
+		if c.V == "1" {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	default:
+		return c.V, nil
+	}
+}
+
+type xmlRow struct {
+	R     string    `xml:"r,attr"`
+	Cells []xmlCell `xml:"c"`
+}
+
+type xmlCell struct {
+	R  string      `xml:"r,attr"`
+	T  string      `xml:"t,attr"`
+	V  string      `xml:"v"`
+	IS xmlRichText `xml:"is"`
+}
+
+// xmlRichText — строка, возможно разбитая на фрагменты с разным форматированием
+type xmlRichText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xmlRichText) text() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	b.WriteString(t.T)
+	for _, run := range t.Runs {
+		b.WriteString(run.T)
+	}
+	return b.String()
+}
+
+// columnIndex переводит ссылку на ячейку вида "AB12" в индекс столбца с нуля.
+// Ссылка без букв столбца не учитывается (ok == false), столбец правее XFD — ошибка.
+func columnIndex(ref string) (int, bool, error) {
+	idx := 0
+	n := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		idx = idx*26 + int(ch-'A'+1)
+		if idx > MaxColumns {
+			return 0, false, fmt.Errorf("xlsx: cell %s is beyond the last column XFD", ref)
+		}
+		n++
+	}
+	if n == 0 {
+		return 0, false, nil
+	}
+	return idx - 1, true, nil
+}
+
+// firstSheetPath находит файл первого листа по workbook.xml и его связям
+func firstSheetPath(archive *zip.Reader) (string, error) {
+	var workbook struct {
+		Sheets []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodeFile(archive, "xl/workbook.xml", &workbook); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", ErrNoSheets
+	}
+
+	var rels struct {
+		Items []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeFile(archive, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", err
+	}
+	for _, rel := range rels.Items {
+		if rel.ID != workbook.Sheets[0].ID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return "", ErrNoSheets
+}
+
+// readSharedStrings читает таблицу общих строк; её может не быть, если строк в книге нет
+func readSharedStrings(archive *zip.Reader) ([]string, error) {
+	var sst struct {
+		Items []xmlRichText `xml:"si"`
+	}
+	err := decodeFile(archive, "xl/sharedStrings.xml", &sst)
+	if errors.Is(err, errMissingPart) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	shared := make([]string, len(sst.Items))
+	for i, item := range sst.Items {
+		shared[i] = item.text()
+	}
+	return shared, nil
+}
+
+var errMissingPart = errors.New("xlsx: missing part")
+
+// decodeFile разбирает XML-файл архива в v
+func decodeFile(archive *zip.Reader, name string, v interface{}) error {
+	f, err := archive.Open(name)
+	if err != nil {
+		return fmt.Errorf("%w %s", errMissingPart, name)
+	}
+	defer f.Close()
+
+	if err := xml.NewDecoder(f).Decode(v); err != nil {
+		return fmt.Errorf("xlsx: %s: %w", name, err)
+	}
+	return nil
+}
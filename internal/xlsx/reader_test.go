@@ -0,0 +1,100 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// workbook собирает книгу из одного листа с данными sheetData
+func workbook(t *testing.T, sheetData string) *Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml":   `<worksheet><sheetData>` + sheetData + `</sheetData></worksheet>`,
+	}
+	for name, content := range parts {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+// readAll читает лист до конца или до первой ошибки
+func readAll(r *Reader) ([][]string, error) {
+	var rows [][]string
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return rows, err
+		}
+		rows = append(rows, row)
+	}
+}
+
+func TestReaderRows(t *testing.T) {
+	r := workbook(t, `<row r="1"><c r="A1" t="inlineStr"><is><t>a</t></is></c><c r="C1"><v>3</v></c></row>`+
+		`<row r="3"><c r="B3" t="b"><v>1</v></c></row>`)
+
+	rows, err := readAll(r)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	want := [][]string{{"a", "", "3"}, {}, {"", "TRUE"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("expected %q, got %q", want, rows)
+	}
+}
+
+func TestReaderRejectsReferencesOutsideSheet(t *testing.T) {
+	tests := []struct {
+		name  string
+		sheet string
+	}{
+		{"column overflowing int", `<row r="1"><c r="ZZZZZZZZZZZZZZZ1"><v>1</v></c></row>`},
+		{"column past XFD", `<row r="1"><c r="XFE1"><v>1</v></c></row>`},
+		{"seven-letter column", `<row r="1"><c r="ZZZZZZZ1"><v>1</v></c></row>`},
+		{"row past the last row", `<row r="2000000000"><c r="A2000000000"><v>1</v></c></row>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := readAll(workbook(t, tt.sheet)); err == nil {
+				t.Error("expected error for reference outside the sheet")
+			}
+		})
+	}
+}
+
+func TestReaderAcceptsLastCell(t *testing.T) {
+	r := workbook(t, `<row r="1"><c r="XFD1"><v>x</v></c></row>`)
+	row, err := r.Read()
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(row) != MaxColumns || row[MaxColumns-1] != "x" {
+		t.Errorf("expected %d columns ending with x, got %d", MaxColumns, len(row))
+	}
+}
@@ -0,0 +1,129 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ContentType — MIME-тип книги XLSX
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// Служебные части книги из одного листа
+var staticParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`},
+}
+
+// Writer потоково пишет книгу из одного листа: строки отправляются в w по мере записи.
+// Целые числа записываются числовыми ячейками, остальные значения — строками.
+type Writer struct {
+	archive *zip.Writer
+	sheet   io.Writer
+	row     int
+}
+
+// NewWriter начинает книгу в w
+func NewWriter(w io.Writer) (*Writer, error) {
+	archive := zip.NewWriter(w)
+	for _, part := range staticParts {
+		f, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	// Лист пишется последним, чтобы его можно было отдавать потоком
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.WriteString(sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Writer{archive: archive, sheet: sheet}, nil
+}
+
+// WriteRow добавляет строку. Поддерживаются string, int и int64; пустые строки и nil дают пустую ячейку.
+func (w *Writer) WriteRow(values []interface{}) error {
+	w.row++
+	if _, err := fmt.Fprintf(w.sheet, `<row r="%d">`, w.row); err != nil {
+		return err
+	}
+
+	for i, value := range values {
+		ref := columnName(i) + strconv.Itoa(w.row)
+		var err error
+		switch v := value.(type) {
+		case nil:
+			continue
+		case int:
+			_, err = fmt.Fprintf(w.sheet, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case int64:
+			_, err = fmt.Fprintf(w.sheet, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case string:
+			if v == "" {
+				continue
+			}
+			if _, err = fmt.Fprintf(w.sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref); err == nil {
+				if err = xml.EscapeText(w.sheet, []byte(v)); err == nil {
+					_, err = io.WriteString(w.sheet, `</t></is></c>`)
+				}
+			}
+		default:
+			return fmt.Errorf("xlsx: unsupported cell value of type %T", value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w.sheet, `</row>`)
+	return err
+}
+
+// Close завершает лист и архив. Нижележащий io.Writer не закрывается.
+func (w *Writer) Close() error {
+	if _, err := io.WriteString(w.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return w.archive.Close()
+}
+
+// columnName переводит индекс столбца с нуля в буквенное обозначение: 0 → A, 26 → AA
+func columnName(idx int) string {
+	name := ""
+	for idx >= 0 {
+		name = string(rune('A'+idx%26)) + name
+		idx = idx/26 - 1
+	}
+	return name
+}
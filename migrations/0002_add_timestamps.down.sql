@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS subscriptions_set_updated_at ON subscriptions;
+DROP FUNCTION IF EXISTS set_updated_at();
+DROP INDEX IF EXISTS subscriptions_updated_at_idx;
+
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS created_at;
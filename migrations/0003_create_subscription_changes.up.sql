@@ -0,0 +1,11 @@
+-- Лента изменений подписок для инкрементальной выгрузки
+CREATE TABLE subscription_changes (
+    id BIGSERIAL PRIMARY KEY,                            -- Порядковый номер события
+    subscription_id UUID NOT NULL,                       -- ID подписки
+    change_type VARCHAR(16) NOT NULL,                    -- created, updated или deleted
+    snapshot JSONB,                                      -- Состояние подписки после изменения (NULL для удаления)
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now(),       -- Время изменения
+    txid XID8 NOT NULL DEFAULT pg_current_xact_id()      -- Транзакция, записавшая событие
+);
+
+CREATE INDEX subscription_changes_subscription_id_idx ON subscription_changes (subscription_id);
@@ -0,0 +1,5 @@
+-- Мягко удалённые строки при откате удаляются окончательно
+DELETE FROM subscriptions WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS subscriptions_deleted_at_idx;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,14 @@
+-- Статус жизненного цикла подписки. Истечение (expired) вычисляется по end_date и не хранится.
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'paused', 'cancelled'));
+
+-- История приостановок: месяцы, на первое число которых подписка была на паузе, не оплачиваются
+CREATE TABLE subscription_pauses (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id UUID NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+    paused_at TIMESTAMPTZ NOT NULL,                      -- Начало паузы
+    resumed_at TIMESTAMPTZ                               -- Возобновление (NULL — пауза продолжается)
+);
+
+CREATE INDEX subscription_pauses_subscription_id_idx ON subscription_pauses (subscription_id);
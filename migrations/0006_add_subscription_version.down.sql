@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS subscriptions_bump_version ON subscriptions;
+DROP FUNCTION IF EXISTS bump_version();
+
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS version;
@@ -0,0 +1,14 @@
+-- Версия строки для оптимистичной блокировки: отдаётся клиенту как ETag
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+
+-- Увеличиваем версию при каждом изменении строки
+CREATE OR REPLACE FUNCTION bump_version() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.version = OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER subscriptions_bump_version
+    BEFORE UPDATE ON subscriptions
+    FOR EACH ROW EXECUTE FUNCTION bump_version();
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS exchange_rates;
+
+ALTER TABLE subscriptions
+    ALTER COLUMN price TYPE INTEGER USING (price / 100)::INTEGER;
+
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS currency;
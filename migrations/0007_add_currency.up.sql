@@ -0,0 +1,19 @@
+-- Валюта подписки (код ISO 4217); подписки, созданные раньше, оплачиваются в рублях
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'RUB';
+
+-- Цена хранится в минимальных единицах валюты (копейках, центах) вместо целых рублей
+ALTER TABLE subscriptions
+    ALTER COLUMN price TYPE BIGINT USING price::BIGINT * 100;
+
+-- Курсы валют на дату: одна единица base_currency стоит rate единиц quote_currency.
+-- Курс действует с rate_date до следующего курса той же пары.
+CREATE TABLE exchange_rates (
+    base_currency CHAR(3) NOT NULL,
+    quote_currency CHAR(3) NOT NULL,
+    rate_date DATE NOT NULL,
+    rate NUMERIC(20, 10) NOT NULL CHECK (rate > 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (base_currency, quote_currency, rate_date),
+    CHECK (base_currency <> quote_currency)
+);
@@ -0,0 +1,13 @@
+ALTER TABLE subscriptions DISABLE TRIGGER subscriptions_bump_version;
+ALTER TABLE subscriptions DISABLE TRIGGER subscriptions_set_updated_at;
+
+UPDATE subscriptions
+SET end_date = date_trunc('month', end_date)::DATE
+WHERE end_date IS NOT NULL;
+
+ALTER TABLE subscriptions ENABLE TRIGGER subscriptions_bump_version;
+ALTER TABLE subscriptions ENABLE TRIGGER subscriptions_set_updated_at;
+
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS interval_count,
+    DROP COLUMN IF EXISTS billing_period;
@@ -0,0 +1,17 @@
+-- Период оплаты: price списывается раз в interval_count периодов billing_period, начиная со start_date
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS billing_period VARCHAR(16) NOT NULL DEFAULT 'monthly'
+        CHECK (billing_period IN ('weekly', 'monthly', 'quarterly', 'yearly')),
+    ADD COLUMN IF NOT EXISTS interval_count INTEGER NOT NULL DEFAULT 1 CHECK (interval_count > 0);
+
+-- end_date теперь последний день действия подписки включительно, а не первое число последнего месяца.
+-- Переносим существующие даты на последний день месяца, не меняя version и updated_at.
+ALTER TABLE subscriptions DISABLE TRIGGER subscriptions_bump_version;
+ALTER TABLE subscriptions DISABLE TRIGGER subscriptions_set_updated_at;
+
+UPDATE subscriptions
+SET end_date = (date_trunc('month', end_date) + INTERVAL '1 month' - INTERVAL '1 day')::DATE
+WHERE end_date IS NOT NULL;
+
+ALTER TABLE subscriptions ENABLE TRIGGER subscriptions_bump_version;
+ALTER TABLE subscriptions ENABLE TRIGGER subscriptions_set_updated_at;
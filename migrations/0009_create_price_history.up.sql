@@ -0,0 +1,9 @@
+-- История цен: price подписки действует со start_date, а каждая запись — с effective_from
+-- до следующей записи той же подписки. Цена — в минимальных единицах валюты подписки.
+CREATE TABLE price_history (
+    subscription_id UUID NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+    effective_from DATE NOT NULL,                        -- Дата, с которой действует цена
+    price BIGINT NOT NULL CHECK (price > 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (subscription_id, effective_from)
+);
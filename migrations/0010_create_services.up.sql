@@ -0,0 +1,20 @@
+-- Каталог сервисов: каноническое название, синонимы и значения по умолчанию для новых подписок
+CREATE TABLE services (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,                          -- Каноническое название
+    aliases TEXT[] NOT NULL DEFAULT '{}',                -- Другие написания, без учёта регистра
+    category VARCHAR(64) NOT NULL DEFAULT '',
+    default_price BIGINT CHECK (default_price > 0),      -- Цена по умолчанию в минимальных единицах currency
+    currency CHAR(3) NOT NULL DEFAULT 'RUB',
+    vendor_url TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX services_name_key ON services (lower(name));
+CREATE INDEX services_category_idx ON services (category);
+
+-- Подписка ссылается на сервис каталога; service_name остаётся названием на момент привязки.
+-- При удалении сервиса подписки остаются без ссылки.
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS service_id UUID REFERENCES services (id) ON DELETE SET NULL;
+CREATE INDEX subscriptions_service_id_idx ON subscriptions (service_id);
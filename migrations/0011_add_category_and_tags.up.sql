@@ -0,0 +1,20 @@
+-- Категория и свободные теги подписки для группировки расходов
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS category VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX subscriptions_category_idx ON subscriptions (category);
+-- GIN-индекс для фильтров tags && (любой из тегов) и tags @> (все теги)
+CREATE INDEX subscriptions_tags_idx ON subscriptions USING GIN (tags);
+
+-- Подписки, привязанные к каталогу, получают категорию своего сервиса, не меняя version и updated_at
+ALTER TABLE subscriptions DISABLE TRIGGER subscriptions_bump_version;
+ALTER TABLE subscriptions DISABLE TRIGGER subscriptions_set_updated_at;
+
+UPDATE subscriptions s
+SET category = sv.category
+FROM services sv
+WHERE s.service_id = sv.id;
+
+ALTER TABLE subscriptions ENABLE TRIGGER subscriptions_bump_version;
+ALTER TABLE subscriptions ENABLE TRIGGER subscriptions_set_updated_at;
@@ -0,0 +1,15 @@
+-- Статические API-ключи. Сам ключ не хранится: по запросу он ищется по SHA-256 хешу.
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,                         -- Начало ключа, чтобы его можно было узнать
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    user_id UUID NOT NULL,                               -- Пользователь, от имени которого действует ключ
+    role VARCHAR(16) NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX api_keys_user_id_idx ON api_keys (user_id);
@@ -0,0 +1,9 @@
+ALTER TABLE subscriptions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE subscriptions DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS subscriptions_tenant_isolation ON subscriptions;
+
+DROP INDEX IF EXISTS api_keys_organization_id_idx;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS organization_id;
+
+DROP INDEX IF EXISTS subscriptions_organization_user_idx;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS organization_id;
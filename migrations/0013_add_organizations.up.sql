@@ -0,0 +1,28 @@
+-- Организация (клиент), которой принадлежат подписки. Существующие подписки относятся
+-- к организации по умолчанию.
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS organization_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000';
+
+CREATE INDEX subscriptions_organization_user_idx ON subscriptions (organization_id, user_id);
+
+-- Ключ, привязанный к организации, работает только с её данными; NULL — с любой организацией
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS organization_id UUID;
+
+CREATE INDEX api_keys_organization_id_idx ON api_keys (organization_id);
+
+-- Вторая линия защиты: если сервис передал организацию в app.tenant_id (TENANT_ROW_SECURITY=true),
+-- строки других организаций не видны и не могут быть записаны, даже если в запросе забыто условие.
+-- Без app.tenant_id политика ничего не ограничивает. Суперпользователи и роли с BYPASSRLS
+-- политики не проверяют, поэтому сервис должен подключаться обычной ролью.
+CREATE POLICY subscriptions_tenant_isolation ON subscriptions
+    USING (
+        COALESCE(current_setting('app.tenant_id', true), '') = ''
+        OR organization_id = NULLIF(current_setting('app.tenant_id', true), '')::UUID
+    )
+    WITH CHECK (
+        COALESCE(current_setting('app.tenant_id', true), '') = ''
+        OR organization_id = NULLIF(current_setting('app.tenant_id', true), '')::UUID
+    );
+
+ALTER TABLE subscriptions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscriptions FORCE ROW LEVEL SECURITY;
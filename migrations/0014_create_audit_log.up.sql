@@ -0,0 +1,33 @@
+-- Журнал аудита изменений подписок: кто, когда и что изменил. Запись делается в той же
+-- транзакции, что и само изменение, и не удаляется вместе с подпиской.
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id UUID NOT NULL,
+    organization_id UUID NOT NULL,                       -- Организация подписки
+    user_id UUID NOT NULL,                               -- Владелец подписки на момент изменения
+    action VARCHAR(32) NOT NULL,                         -- create, update, delete, restore, pause, ...
+    actor TEXT NOT NULL DEFAULT '',                      -- ID пользователя или sub токена; пусто без аутентификации
+    actor_role VARCHAR(16) NOT NULL DEFAULT '',
+    auth_method VARCHAR(16) NOT NULL DEFAULT '',         -- api_key или jwt
+    request_id TEXT NOT NULL DEFAULT '',
+    changes JSONB NOT NULL DEFAULT '{}',                 -- Изменённые поля: {"price": {"old": 100, "new": 200}}
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX audit_log_subscription_id_idx ON audit_log (subscription_id, id);
+CREATE INDEX audit_log_organization_id_idx ON audit_log (organization_id, id);
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at);
+
+-- Та же изоляция организаций, что и у subscriptions (см. 0013_add_organizations)
+CREATE POLICY audit_log_tenant_isolation ON audit_log
+    USING (
+        COALESCE(current_setting('app.tenant_id', true), '') = ''
+        OR organization_id = NULLIF(current_setting('app.tenant_id', true), '')::UUID
+    )
+    WITH CHECK (
+        COALESCE(current_setting('app.tenant_id', true), '') = ''
+        OR organization_id = NULLIF(current_setting('app.tenant_id', true), '')::UUID
+    );
+
+ALTER TABLE audit_log ENABLE ROW LEVEL SECURITY;
+ALTER TABLE audit_log FORCE ROW LEVEL SECURITY;
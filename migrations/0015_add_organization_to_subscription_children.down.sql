@@ -0,0 +1,16 @@
+ALTER TABLE price_history NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE price_history DISABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_pauses NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE subscription_pauses DISABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_changes NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE subscription_changes DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS price_history_tenant_isolation ON price_history;
+DROP POLICY IF EXISTS subscription_pauses_tenant_isolation ON subscription_pauses;
+DROP POLICY IF EXISTS subscription_changes_tenant_isolation ON subscription_changes;
+
+DROP INDEX IF EXISTS subscription_changes_organization_id_idx;
+
+ALTER TABLE price_history DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE subscription_pauses DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE subscription_changes DROP COLUMN IF EXISTS organization_id;
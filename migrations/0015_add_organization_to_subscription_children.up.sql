@@ -0,0 +1,62 @@
+-- Лента изменений, паузы и история цен получают организацию своей подписки, чтобы политики
+-- построчной безопасности изолировали и их, а не только subscriptions (см. 0013_add_organizations).
+-- Организация подписки не меняется, поэтому копия в дочерних строках не расходится с ней.
+ALTER TABLE subscription_changes ADD COLUMN IF NOT EXISTS organization_id UUID;
+ALTER TABLE subscription_pauses ADD COLUMN IF NOT EXISTS organization_id UUID;
+ALTER TABLE price_history ADD COLUMN IF NOT EXISTS organization_id UUID;
+
+UPDATE subscription_changes c SET organization_id = s.organization_id
+FROM subscriptions s WHERE s.id = c.subscription_id;
+-- События окончательно удалённых подписок: организация из снимка, иначе организация по умолчанию
+UPDATE subscription_changes
+SET organization_id = COALESCE(NULLIF(snapshot->>'organization_id', '')::UUID, '00000000-0000-0000-0000-000000000000')
+WHERE organization_id IS NULL;
+
+UPDATE subscription_pauses p SET organization_id = s.organization_id
+FROM subscriptions s WHERE s.id = p.subscription_id;
+UPDATE price_history h SET organization_id = s.organization_id
+FROM subscriptions s WHERE s.id = h.subscription_id;
+
+ALTER TABLE subscription_changes ALTER COLUMN organization_id SET NOT NULL;
+ALTER TABLE subscription_pauses ALTER COLUMN organization_id SET NOT NULL;
+ALTER TABLE price_history ALTER COLUMN organization_id SET NOT NULL;
+
+CREATE INDEX subscription_changes_organization_id_idx ON subscription_changes (organization_id, id);
+
+-- Та же изоляция организаций, что и у subscriptions
+CREATE POLICY subscription_changes_tenant_isolation ON subscription_changes
+    USING (
+        COALESCE(current_setting('app.tenant_id', true), '') = ''
+        OR organization_id = NULLIF(current_setting('app.tenant_id', true), '')::UUID
+    )
+    WITH CHECK (
+        COALESCE(current_setting('app.tenant_id', true), '') = ''
+        OR organization_id = NULLIF(current_setting('app.tenant_id', true), '')::UUID
+    );
+
+CREATE POLICY subscription_pauses_tenant_isolation ON subscription_pauses
+    USING (
+        COALESCE(current_setting('app.tenant_id', true), '') = ''
+        OR organization_id = NULLIF(current_setting('app.tenant_id', true), '')::UUID
+    )
+    WITH CHECK (
+        COALESCE(current_setting('app.tenant_id', true), '') = ''
+        OR organization_id = NULLIF(current_setting('app.tenant_id', true), '')::UUID
+    );
+
+CREATE POLICY price_history_tenant_isolation ON price_history
+    USING (
+        COALESCE(current_setting('app.tenant_id', true), '') = ''
+        OR organization_id = NULLIF(current_setting('app.tenant_id', true), '')::UUID
+    )
+    WITH CHECK (
+        COALESCE(current_setting('app.tenant_id', true), '') = ''
+        OR organization_id = NULLIF(current_setting('app.tenant_id', true), '')::UUID
+    );
+
+ALTER TABLE subscription_changes ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_changes FORCE ROW LEVEL SECURITY;
+ALTER TABLE subscription_pauses ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_pauses FORCE ROW LEVEL SECURITY;
+ALTER TABLE price_history ENABLE ROW LEVEL SECURITY;
+ALTER TABLE price_history FORCE ROW LEVEL SECURITY;
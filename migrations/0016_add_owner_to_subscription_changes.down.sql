@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS subscription_changes_organization_user_idx;
+
+ALTER TABLE subscription_changes DROP COLUMN IF EXISTS user_id;
@@ -0,0 +1,25 @@
+-- Владелец подписки на момент события: лента пользователя фильтруется по нему, а не по текущему
+-- владельцу подписки, поэтому события остаются видны после окончательного удаления подписки,
+-- а при передаче подписки другому пользователю прежние события остаются у прежнего владельца.
+ALTER TABLE subscription_changes ADD COLUMN IF NOT EXISTS user_id UUID;
+
+-- Снимок события хранит владельца на момент изменения
+UPDATE subscription_changes
+SET user_id = NULLIF(snapshot->>'user_id', '')::UUID
+WHERE user_id IS NULL AND snapshot IS NOT NULL;
+-- Tombstone существующей подписки: текущий владелец
+UPDATE subscription_changes c SET user_id = s.user_id
+FROM subscriptions s WHERE c.user_id IS NULL AND s.id = c.subscription_id;
+-- Tombstone окончательно удалённой подписки: владелец из предыдущего события
+UPDATE subscription_changes c SET user_id = (
+    SELECT p.user_id FROM subscription_changes p
+    WHERE p.subscription_id = c.subscription_id AND p.id < c.id AND p.user_id IS NOT NULL
+    ORDER BY p.id DESC LIMIT 1
+)
+WHERE c.user_id IS NULL;
+-- Владельца восстановить не удалось: событие видно только администраторам
+UPDATE subscription_changes SET user_id = '00000000-0000-0000-0000-000000000000' WHERE user_id IS NULL;
+
+ALTER TABLE subscription_changes ALTER COLUMN user_id SET NOT NULL;
+
+CREATE INDEX subscription_changes_organization_user_idx ON subscription_changes (organization_id, user_id, id);
@@ -0,0 +1,10 @@
+// Package migrations содержит SQL-миграции схемы, встроенные в бинарник.
+//
+// Файлы именуются как NNNN_описание.up.sql и NNNN_описание.down.sql,
+// где NNNN — номер версии схемы.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS